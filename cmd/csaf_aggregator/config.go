@@ -97,6 +97,10 @@ type config struct {
 	// LockFile tries to lock to a given file.
 	LockFile *string `toml:"lock_file"`
 
+	// MetricsFile is the file to write metrics in the
+	// Prometheus text format to.
+	MetricsFile string `toml:"metrics_file"`
+
 	// Interim performs an interim scan.
 	Interim bool `short:"i" long:"interim" description:"Perform an interim scan" toml:"interim"`
 	Version bool `long:"version" description:"Display version of the binary" toml:"-"`
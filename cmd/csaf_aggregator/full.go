@@ -40,7 +40,7 @@ func (w *worker) setupProviderFull(provider *provider) error {
 	w.provider = provider
 
 	// Each job needs a separate client.
-	w.client = w.processor.cfg.httpClient(provider)
+	w.client = w.processor.metrics.client(w.processor.cfg.httpClient(provider))
 
 	// We need the provider metadata in all cases.
 	if err := w.locateProviderMetadata(provider.Domain); err != nil {
@@ -138,6 +138,7 @@ func (p *processor) full() error {
 
 	for i := range jobs {
 		j := &jobs[i]
+		p.metrics.finished(j.provider.Name, j.err)
 		if j.err != nil {
 			p.log.Error("Job execution failed",
 				slog.Group("job",
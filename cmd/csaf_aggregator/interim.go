@@ -25,6 +25,7 @@ import (
 
 	"github.com/gocsaf/csaf/v3/csaf"
 	"github.com/gocsaf/csaf/v3/internal/misc"
+	"github.com/gocsaf/csaf/v3/internal/tree"
 	"github.com/gocsaf/csaf/v3/util"
 )
 
@@ -98,6 +99,11 @@ func (w *worker) checkInterims(
 		if err != nil {
 			return nil, fmt.Errorf("failed to validate %s: %v", url, err)
 		}
+		if len(errors) > 0 {
+			w.processor.metrics.validated(w.provider.Name, "schema", "invalid")
+		} else {
+			w.processor.metrics.validated(w.provider.Name, "schema", "valid")
+		}
 
 		// XXX: Should we return an error here?
 		for _, e := range errors {
@@ -164,7 +170,7 @@ func (w *worker) setupProviderInterim(provider *provider) {
 	w.provider = provider
 
 	// Each job needs a separate client.
-	w.client = w.processor.cfg.httpClient(provider)
+	w.client = w.processor.metrics.client(w.processor.cfg.httpClient(provider))
 }
 
 func (w *worker) interimWork(wg *sync.WaitGroup, jobs <-chan *interimJob) {
@@ -193,7 +199,7 @@ func (w *worker) interimWork(wg *sync.WaitGroup, jobs <-chan *interimJob) {
 				label = strings.ToLower(label)
 				labelPath := filepath.Join(providerPath, label)
 
-				interCSV := filepath.Join(labelPath, interimsCSV)
+				interCSV := filepath.Join(labelPath, tree.InterimsCSV)
 				interims, olds, err := readInterims(interCSV, tooOld)
 				if err != nil {
 					return err
@@ -224,7 +230,7 @@ func (w *worker) interimWork(wg *sync.WaitGroup, jobs <-chan *interimJob) {
 				if err != nil {
 					return err
 				}
-				ninterCSV := filepath.Join(dst, label, interimsCSV)
+				ninterCSV := filepath.Join(dst, label, tree.InterimsCSV)
 				if err := writeInterims(ninterCSV, notFinalized); err != nil {
 					return err
 				}
@@ -280,6 +286,7 @@ func (p *processor) interim() error {
 	var errs []error
 
 	for i := range jobs {
+		p.metrics.finished(jobs[i].provider.Name, jobs[i].err)
 		if err := jobs[i].err; err != nil {
 			errs = append(errs, err)
 		}
@@ -49,6 +49,6 @@ func main() {
 	cfg.prepareLogging()
 	options.ErrorCheckStructured(err)
 	options.ErrorCheckStructured(cfg.prepare())
-	p := processor{cfg: cfg, log: slog.Default()}
+	p := processor{cfg: cfg, log: slog.Default(), metrics: newAggregatorMetrics(cfg)}
 	options.ErrorCheckStructured(lock(cfg.LockFile, p.process))
 }
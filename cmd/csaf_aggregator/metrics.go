@@ -0,0 +1,99 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package main
+
+import (
+	"time"
+
+	"github.com/gocsaf/csaf/v3/internal/metrics"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// aggregatorMetrics are the metrics of an aggregator run.
+type aggregatorMetrics struct {
+	registry    *metrics.Registry
+	http        *metrics.HTTP
+	advisories  *metrics.Vec
+	validations *metrics.Vec
+	lastRun     *metrics.Vec
+	lastSuccess *metrics.Vec
+}
+
+// newAggregatorMetrics registers the metrics of the aggregator.
+// Returns nil if no metrics are written.
+func newAggregatorMetrics(cfg *config) *aggregatorMetrics {
+	if cfg.MetricsFile == "" {
+		return nil
+	}
+	r := metrics.NewRegistry()
+	return &aggregatorMetrics{
+		registry: r,
+		http:     metrics.NewHTTP(r, "csaf_aggregator"),
+		advisories: r.Counter(
+			"csaf_aggregator_advisories_total",
+			"Mirrored advisories by provider and result.",
+			"provider", "result"),
+		validations: r.Counter(
+			"csaf_aggregator_validations_total",
+			"Validations of advisories by provider, validator and result.",
+			"provider", "validator", "result"),
+		lastRun: r.Gauge(
+			"csaf_aggregator_last_run_timestamp_seconds",
+			"Time of the end of the last run by provider.",
+			"provider"),
+		lastSuccess: r.Gauge(
+			"csaf_aggregator_last_success_timestamp_seconds",
+			"Time of the end of the last successful run by provider.",
+			"provider"),
+	}
+}
+
+// client returns a client recording the metrics of its requests.
+func (m *aggregatorMetrics) client(client util.Client) util.Client {
+	if m == nil {
+		return client
+	}
+	return m.http.Client(client)
+}
+
+// advisory records the result of mirroring an advisory.
+func (m *aggregatorMetrics) advisory(provider, result string) {
+	if m == nil {
+		return
+	}
+	m.advisories.Inc(provider, result)
+}
+
+// validated records the result of a validation of an advisory.
+func (m *aggregatorMetrics) validated(provider, validator, result string) {
+	if m == nil {
+		return
+	}
+	m.validations.Inc(provider, validator, result)
+}
+
+// finished records the end of the run of a provider.
+func (m *aggregatorMetrics) finished(provider string, err error) {
+	if m == nil {
+		return
+	}
+	now := float64(time.Now().Unix())
+	m.lastRun.Set(now, provider)
+	if err == nil {
+		m.lastSuccess.Set(now, provider)
+	}
+}
+
+// writeMetrics writes the metrics to the configured file.
+func (p *processor) writeMetrics() error {
+	if p.metrics == nil {
+		return nil
+	}
+	return p.metrics.registry.WriteFile(p.cfg.MetricsFile)
+}
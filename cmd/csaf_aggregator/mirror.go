@@ -31,6 +31,7 @@ import (
 
 	"github.com/gocsaf/csaf/v3/csaf"
 	"github.com/gocsaf/csaf/v3/internal/misc"
+	"github.com/gocsaf/csaf/v3/internal/tree"
 	"github.com/gocsaf/csaf/v3/util"
 )
 
@@ -62,7 +63,7 @@ func (w *worker) mirrorInternal() (*csaf.AggregatorCSAFProvider, error) {
 	}
 
 	// Collecting the summaries of the advisories.
-	w.summaries = make(map[string][]summary)
+	w.summaries = make(map[string][]tree.Summary)
 
 	// Collecting the categories per label.
 	w.categories = map[string]util.Set[string]{}
@@ -493,10 +494,6 @@ func (w *worker) extractCategories(label string, advisory any) error {
 }
 
 func (w *worker) mirrorFiles(tlpLabel csaf.TLPLabel, files []csaf.AdvisoryFile) error {
-	label := strings.ToLower(string(tlpLabel))
-
-	summaries := w.summaries[label]
-
 	dir, err := w.createDir()
 	if err != nil {
 		return err
@@ -504,7 +501,9 @@ func (w *worker) mirrorFiles(tlpLabel csaf.TLPLabel, files []csaf.AdvisoryFile)
 
 	var content bytes.Buffer
 
-	yearDirs := make(map[int]string)
+	m, name := w.processor.metrics, w.provider.Name
+
+	yearDirs := make(map[string]string)
 
 	for _, file := range files {
 
@@ -516,6 +515,7 @@ func (w *worker) mirrorFiles(tlpLabel csaf.TLPLabel, files []csaf.AdvisoryFile)
 
 		// Should we ignore this advisory?
 		if w.provider.ignoreURL(file.URL(), w.processor.cfg) {
+			m.advisory(name, "ignored")
 			if w.processor.cfg.Verbose {
 				w.log.Info("Ignoring advisory", slog.Group("provider", "name", w.provider.Name), "file", file)
 			}
@@ -526,6 +526,7 @@ func (w *worker) mirrorFiles(tlpLabel csaf.TLPLabel, files []csaf.AdvisoryFile)
 		filename := filepath.Base(u.Path)
 		if !util.ConformingFileName(filename) {
 			w.log.Warn("Ignoring advisory because of non-conforming filename", "filename", filename)
+			m.advisory(name, "filename_invalid")
 			continue
 		}
 
@@ -543,6 +544,7 @@ func (w *worker) mirrorFiles(tlpLabel csaf.TLPLabel, files []csaf.AdvisoryFile)
 
 		if err := downloadJSON(w.client, file.URL(), download); err != nil {
 			w.log.Error("Error while downloading JSON", "err", err)
+			m.advisory(name, "download_failed")
 			continue
 		}
 
@@ -550,29 +552,39 @@ func (w *worker) mirrorFiles(tlpLabel csaf.TLPLabel, files []csaf.AdvisoryFile)
 		errors, err := csaf.ValidateCSAF(advisory)
 		if err != nil {
 			w.log.Error("Error while validating CSAF schema", "err", err)
+			m.advisory(name, "schema_failed")
 			continue
 		}
 		if len(errors) > 0 {
 			w.log.Error("CSAF file has validation errors", "num.errors", len(errors), "file", file)
+			m.validated(name, "schema", "invalid")
+			m.advisory(name, "schema_failed")
 			continue
 		}
+		m.validated(name, "schema", "valid")
 
 		// Check against remote validator.
 		if rmv := w.processor.remoteValidator; rmv != nil {
 			rvr, err := rmv.Validate(advisory)
 			if err != nil {
 				w.log.Error("Calling remote validator failed", "err", err)
+				m.validated(name, "remote", "error")
+				m.advisory(name, "remote_failed")
 				continue
 			}
 			if !rvr.Valid {
 				w.log.Error("CSAF file does not validate remotely", "file", file.URL())
+				m.validated(name, "remote", "invalid")
+				m.advisory(name, "remote_failed")
 				continue
 			}
+			m.validated(name, "remote", "valid")
 		}
 
 		sum, err := csaf.NewAdvisorySummary(w.expr, advisory)
 		if err != nil {
 			w.log.Error("Error while creating new advisory", "file", file, "err", err)
+			m.advisory(name, "invalid")
 			continue
 		}
 
@@ -580,22 +592,31 @@ func (w *worker) mirrorFiles(tlpLabel csaf.TLPLabel, files []csaf.AdvisoryFile)
 			w.log.Error("ID mismatch", "id", sum.ID, "filename", filename)
 		}
 
+		// Advisories of directory based distributions
+		// are filed under the TLP labels of the documents.
+		advLabel, ok := csaf.AdvisoryTLPLabel(w.expr, file, tlpLabel, advisory)
+		if !ok {
+			w.log.Warn("Advisory has no TLP label", "file", file.URL(), "label", advLabel)
+		}
+		label := strings.ToLower(string(advLabel))
+
 		if err := w.extractCategories(label, advisory); err != nil {
 			w.log.Error("Could not extract categories", "file", file, "err", err)
+			m.advisory(name, "invalid")
 			continue
 		}
 
-		summaries = append(summaries, summary{
-			filename: filename,
-			summary:  sum,
-			url:      file.URL(),
+		w.summaries[label] = append(w.summaries[label], tree.Summary{
+			Filename: filename,
+			Summary:  sum,
+			URL:      file.URL(),
 		})
 
-		year := sum.InitialReleaseDate.Year()
+		year := filepath.Join(label, strconv.Itoa(sum.InitialReleaseDate.Year()))
 
 		yearDir := yearDirs[year]
 		if yearDir == "" {
-			yearDir = filepath.Join(dir, label, strconv.Itoa(year))
+			yearDir = filepath.Join(dir, year)
 			if err := os.MkdirAll(yearDir, 0755); err != nil {
 				return err
 			}
@@ -604,7 +625,7 @@ func (w *worker) mirrorFiles(tlpLabel csaf.TLPLabel, files []csaf.AdvisoryFile)
 
 		fname := filepath.Join(yearDir, filename)
 		data := content.Bytes()
-		if err := writeFileHashes(
+		if err := tree.WriteFileHashes(
 			fname, filename,
 			data, s256.Sum(nil), s512.Sum(nil),
 		); err != nil {
@@ -617,8 +638,8 @@ func (w *worker) mirrorFiles(tlpLabel csaf.TLPLabel, files []csaf.AdvisoryFile)
 		if err := w.downloadSignatureOrSign(sigURL, ascFile, data); err != nil {
 			return err
 		}
+		m.advisory(name, "mirrored")
 	}
-	w.summaries[label] = summaries
 
 	return nil
 }
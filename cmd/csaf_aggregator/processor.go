@@ -9,6 +9,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
@@ -16,6 +17,7 @@ import (
 	"path/filepath"
 
 	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/tree"
 	"github.com/gocsaf/csaf/v3/util"
 
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
@@ -30,12 +32,9 @@ type processor struct {
 
 	// log is the structured logger for the whole processor.
 	log *slog.Logger
-}
 
-type summary struct {
-	filename string
-	summary  *csaf.AdvisorySummary
-	url      string
+	// metrics are the metrics of the run if they are written.
+	metrics *aggregatorMetrics
 }
 
 type worker struct {
@@ -50,7 +49,7 @@ type worker struct {
 	metadataProvider any                         // current metadata provider
 	loc              string                      // URL of current provider-metadata.json
 	dir              string                      // Directory to store data to.
-	summaries        map[string][]summary        // the summaries of the advisories.
+	summaries        map[string][]tree.Summary   // the summaries of the advisories.
 	categories       map[string]util.Set[string] // the categories per label.
 	log              *slog.Logger                // the structured logger, supplied with the worker number.
 }
@@ -110,6 +109,11 @@ func (w *worker) locateProviderMetadata(domain string) error {
 	w.metadataProvider = lpmd.Document
 	w.loc = lpmd.URL
 
+	// Providers loaded from the file system are read from there.
+	if util.IsFileURL(lpmd.URL) {
+		w.client = &util.FileClient{Client: w.client}
+	}
+
 	return nil
 }
 
@@ -212,7 +216,13 @@ func (p *processor) removeOrphans() error {
 }
 
 // process is the main driver of the jobs handled by work.
-func (p *processor) process() error {
+func (p *processor) process() (err error) {
+	defer func() {
+		if merr := p.writeMetrics(); merr != nil {
+			err = errors.Join(err, fmt.Errorf("writing metrics failed: %w", merr))
+		}
+	}()
+
 	if err := ensureDir(p.cfg.Folder); err != nil {
 		return err
 	}
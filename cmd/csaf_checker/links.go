@@ -9,14 +9,10 @@
 package main
 
 import (
-	"io"
 	"net/http"
 	"net/url"
 
 	"github.com/gocsaf/csaf/v3/internal/misc"
-
-	"github.com/PuerkitoBio/goquery"
-
 	"github.com/gocsaf/csaf/v3/util"
 )
 
@@ -89,7 +85,7 @@ func (pgs pages) listed(
 
 	if err := func() error {
 		defer res.Body.Close()
-		return linksOnPage(res.Body, func(link string) error {
+		return misc.LinksOnPage(res.Body, func(link string) error {
 			u, err := url.Parse(link)
 			if err != nil {
 				return err
@@ -110,22 +106,3 @@ func (pgs pages) listed(
 
 	return content.links.Contains(path), nil
 }
-
-func linksOnPage(r io.Reader, visit func(string) error) error {
-
-	doc, err := goquery.NewDocumentFromReader(r)
-	if err != nil {
-		return err
-	}
-
-	doc.Find("a").Each(func(_ int, s *goquery.Selection) {
-		if err != nil {
-			return
-		}
-		if link, ok := s.Attr("href"); ok {
-			err = visit(link)
-		}
-	})
-
-	return err
-}
@@ -10,6 +10,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log"
 
 	"github.com/gocsaf/csaf/v3/pkg/options"
@@ -23,7 +25,11 @@ func run(cfg *config, domains []string) (*Report, error) {
 		return nil, err
 	}
 	defer p.close()
-	return p.run(domains)
+	report, err := p.run(domains)
+	if merr := p.writeMetrics(); merr != nil {
+		err = errors.Join(err, fmt.Errorf("writing metrics failed: %w", merr))
+	}
+	return report, err
 }
 
 func main() {
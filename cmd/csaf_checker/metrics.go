@@ -0,0 +1,128 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/internal/metrics"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// checkerMetrics are the metrics of a checker run.
+type checkerMetrics struct {
+	registry     *metrics.Registry
+	http         *metrics.HTTP
+	validations  *metrics.Vec
+	messages     *metrics.Vec
+	requirements *metrics.Vec
+	passed       *metrics.Vec
+	lastRun      *metrics.Vec
+	lastSuccess  *metrics.Vec
+}
+
+// newCheckerMetrics registers the metrics of the checker.
+// Returns nil if no metrics are written.
+func newCheckerMetrics(cfg *config) *checkerMetrics {
+	if cfg.MetricsFile == "" {
+		return nil
+	}
+	r := metrics.NewRegistry()
+	return &checkerMetrics{
+		registry: r,
+		http:     metrics.NewHTTP(r, "csaf_checker"),
+		validations: r.Counter(
+			"csaf_checker_validations_total",
+			"Validations of advisories by domain, validator and result.",
+			"domain", "validator", "result"),
+		messages: r.Gauge(
+			"csaf_checker_requirement_messages",
+			"Messages of the requirements by domain, requirement and type.",
+			"domain", "requirement", "type"),
+		requirements: r.Gauge(
+			"csaf_checker_requirement_passed",
+			"Whether a requirement of a domain has no errors (1) or not (0).",
+			"domain", "requirement"),
+		passed: r.Gauge(
+			"csaf_checker_domain_passed",
+			"Whether a domain passed the checks (1) or not (0).",
+			"domain"),
+		lastRun: r.Gauge(
+			"csaf_checker_last_run_timestamp_seconds",
+			"Time of the end of the last check by domain.",
+			"domain"),
+		lastSuccess: r.Gauge(
+			"csaf_checker_last_success_timestamp_seconds",
+			"Time of the end of the last passed check by domain.",
+			"domain"),
+	}
+}
+
+// client returns a client recording the metrics of its requests.
+func (m *checkerMetrics) client(client util.Client) util.Client {
+	if m == nil {
+		return client
+	}
+	return m.http.Client(client)
+}
+
+// validated records the result of a validation of an advisory.
+func (m *checkerMetrics) validated(domain, validator, result string) {
+	if m == nil {
+		return
+	}
+	m.validations.Inc(domain, validator, result)
+}
+
+// checked records the result of the check of a domain.
+// domain is nil if no result could be generated.
+func (m *checkerMetrics) checked(name string, domain *Domain) {
+	if m == nil {
+		return
+	}
+	now := float64(time.Now().Unix())
+	m.lastRun.Set(now, name)
+	if domain == nil {
+		m.passed.Set(0, name)
+		return
+	}
+	for _, r := range domain.Requirements {
+		num := strconv.Itoa(r.Num)
+		counts := map[MessageType]int{}
+		for _, msg := range r.Messages {
+			counts[msg.Type]++
+		}
+		for _, typ := range []MessageType{InfoType, WarnType, ErrorType} {
+			m.messages.Set(float64(counts[typ]), name, num, strings.ToLower(typ.String()))
+		}
+		m.requirements.Set(boolValue(!r.HasErrors()), name, num)
+	}
+	m.passed.Set(boolValue(domain.Passed), name)
+	if domain.Passed {
+		m.lastSuccess.Set(now, name)
+	}
+}
+
+// boolValue returns 1 for true and 0 for false.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// writeMetrics writes the metrics to the configured file.
+func (p *processor) writeMetrics() error {
+	if p.metrics == nil {
+		return nil
+	}
+	return p.metrics.registry.WriteFile(p.cfg.MetricsFile)
+}
@@ -0,0 +1,55 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckerMetrics(t *testing.T) {
+	cfg := &config{MetricsFile: filepath.Join(t.TempDir(), "checker.prom")}
+	p := &processor{cfg: cfg, metrics: newCheckerMetrics(cfg)}
+
+	p.metrics.validated("example.com", "schema", "valid")
+	p.metrics.checked("example.com", &Domain{
+		Name: "example.com",
+		Requirements: []*Requirement{
+			{Num: 1, Messages: []Message{{Type: InfoType}}},
+			{Num: 2, Messages: []Message{{Type: ErrorType}, {Type: ErrorType}}},
+		},
+	})
+	p.metrics.checked("broken.example.com", nil)
+
+	if err := p.writeMetrics(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(cfg.MetricsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`csaf_checker_validations_total{domain="example.com",validator="schema",result="valid"} 1`,
+		`csaf_checker_requirement_messages{domain="example.com",requirement="2",type="error"} 2`,
+		`csaf_checker_requirement_passed{domain="example.com",requirement="1"} 1`,
+		`csaf_checker_requirement_passed{domain="example.com",requirement="2"} 0`,
+		`csaf_checker_domain_passed{domain="example.com"} 0`,
+		`csaf_checker_domain_passed{domain="broken.example.com"} 0`,
+		`csaf_checker_last_run_timestamp_seconds{domain="broken.example.com"}`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("missing %q in:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "csaf_checker_last_success_timestamp_seconds") {
+		t.Error("no domain passed")
+	}
+}
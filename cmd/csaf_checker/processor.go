@@ -45,17 +45,24 @@ type processor struct {
 	validator    csaf.RemoteValidator
 	client       util.Client
 	unauthClient util.Client
+	metrics      *checkerMetrics
+
+	// domain is the domain currently checked.
+	domain string
 
 	redirects      map[string][]string
 	noneTLS        util.Set[string]
 	alreadyChecked map[string]whereType
 	pmdURL         string
-	pmd256         []byte
-	pmd            any
-	keys           *crypto.KeyRing
-	labelChecker   labelChecker
-	timesChanges   map[string]time.Time
-	timesAdv       map[string]time.Time
+	// local is true if the provider metadata was loaded
+	// from the file system.
+	local        bool
+	pmd256       []byte
+	pmd          any
+	keys         *crypto.KeyRing
+	labelChecker labelChecker
+	timesChanges map[string]time.Time
+	timesAdv     map[string]time.Time
 
 	invalidAdvisories      topicMessages
 	badFilenames           topicMessages
@@ -194,6 +201,7 @@ func newProcessor(cfg *config) (*processor, error) {
 		timesAdv:     map[string]time.Time{},
 		timesChanges: map[string]time.Time{},
 		noneTLS:      util.Set[string]{},
+		metrics:      newCheckerMetrics(cfg),
 	}, nil
 }
 
@@ -209,6 +217,7 @@ func (p *processor) close() {
 func (p *processor) reset() {
 	p.redirects = nil
 	p.pmdURL = ""
+	p.local = false
 	p.pmd256 = nil
 	p.pmd = nil
 	p.keys = nil
@@ -250,6 +259,7 @@ func (p *processor) run(domains []string) (*Report, error) {
 
 	for _, d := range domains {
 		p.reset()
+		p.domain = d
 
 		if !p.checkProviderMetadata(d) {
 			// We need to fail the domain if the PMD cannot be parsed.
@@ -265,6 +275,7 @@ func (p *processor) run(domains []string) (*Report, error) {
 
 		if err := p.fillMeta(domain); err != nil {
 			log.Printf("Filling meta data failed: %v\n", err)
+			p.metrics.checked(d, nil)
 			// reporters depend on role.
 			continue
 		}
@@ -291,6 +302,7 @@ func (p *processor) run(domains []string) (*Report, error) {
 		}
 
 		domain.Passed = rules.eval(p)
+		p.metrics.checked(d, domain)
 
 		report.Domains = append(report.Domains, domain)
 	}
@@ -470,18 +482,27 @@ func (p *processor) basicClient() *http.Client {
 // download remote ressources.
 func (p *processor) httpClient() util.Client {
 	if p.client == nil {
-		p.client = p.fullClient()
+		p.client = p.metrics.client(p.fullClient())
 	}
-	return p.client
+	return p.localClient(p.client)
 }
 
 // unauthorizedClient returns a cached HTTP client without
 // authentification.
 func (p *processor) unauthorizedClient() util.Client {
 	if p.unauthClient == nil {
-		p.unauthClient = p.basicClient()
+		p.unauthClient = p.metrics.client(p.basicClient())
 	}
-	return p.unauthClient
+	return p.localClient(p.unauthClient)
+}
+
+// localClient lets the client read the files
+// of a provider loaded from the file system.
+func (p *processor) localClient(client util.Client) util.Client {
+	if p.local {
+		return &util.FileClient{Client: client}
+	}
+	return client
 }
 
 // usedAuthorizedClient tells if an authorized client is used
@@ -710,7 +731,10 @@ func (p *processor) integrity(
 			continue
 		}
 		if len(errors) > 0 {
+			p.metrics.validated(p.domain, "schema", "invalid")
 			p.invalidAdvisories.error("CSAF file %s has %d validation errors.", u, len(errors))
+		} else {
+			p.metrics.validated(p.domain, "schema", "valid")
 		}
 
 		if err := util.IDMatchesFilename(p.expr, doc, filepath.Base(u)); err != nil {
@@ -721,9 +745,13 @@ func (p *processor) integrity(
 		// Validate against remote validator.
 		if p.validator != nil {
 			if rvr, err := p.validator.Validate(doc); err != nil {
+				p.metrics.validated(p.domain, "remote", "error")
 				p.invalidAdvisories.error("Calling remote validator on %s failed: %v", u, err)
 			} else if !rvr.Valid {
+				p.metrics.validated(p.domain, "remote", "invalid")
 				p.invalidAdvisories.error("Remote validation of %s failed.", u)
+			} else {
+				p.metrics.validated(p.domain, "remote", "valid")
 			}
 		}
 
@@ -1307,6 +1335,7 @@ func (p *processor) checkProviderMetadata(domain string) bool {
 	}
 
 	p.pmdURL = lpmd.URL
+	p.local = util.IsFileURL(lpmd.URL)
 	p.pmd256 = lpmd.Hash
 	p.pmd = lpmd.Document
 
@@ -11,16 +11,44 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"os/signal"
+	"syscall"
 
-	"github.com/gocsaf/csaf/v3/cmd/csaf_downloader"
+	"github.com/gocsaf/csaf/v3/internal/downloader"
 	"github.com/gocsaf/csaf/v3/pkg/options"
 )
 
-func run(cfg *csaf_downloader.Config, domains []string) error {
-	d, err := csaf_downloader.NewDownloader(cfg)
+// forward attaches the configured forwarders to the downloader.
+// The returned function shuts the forwarders down.
+func forward(cfg *downloader.Config, d *downloader.Downloader) func() {
+	fs := downloader.NewForwarders(cfg)
+	for _, f := range fs {
+		go f.Run()
+	}
+	d.Forwarders = fs
+	return func() {
+		for _, f := range fs {
+			f.Log()
+			f.Close()
+		}
+	}
+}
+
+// replay forwards the advisories failed forwarding before
+// to all the configured targets.
+func replay(cfg *downloader.Config) error {
+	var errs []error
+	for _, f := range downloader.NewForwarders(cfg) {
+		errs = append(errs, f.Replay())
+	}
+	return errors.Join(errs...)
+}
+
+func run(cfg *downloader.Config, domains []string) error {
+	d, err := downloader.NewDownloader(cfg)
 	if err != nil {
 		return err
 	}
@@ -31,15 +59,7 @@ func run(cfg *csaf_downloader.Config, domains []string) error {
 	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
 	defer stop()
 
-	if cfg.ForwardURL != "" {
-		f := csaf_downloader.NewForwarder(cfg)
-		go f.Run()
-		defer func() {
-			f.Log()
-			f.Close()
-		}()
-		d.Forwarder = f
-	}
+	defer forward(cfg, d)()
 
 	// If the enumerate-only flag is set, enumerate found PMDs,
 	// else use the normal load method
@@ -49,16 +69,77 @@ func run(cfg *csaf_downloader.Config, domains []string) error {
 	return d.Run(ctx, domains)
 }
 
+// runDaemon downloads from the domains periodically until
+// the process is interrupted. On SIGHUP the configuration is
+// read again.
+func runDaemon(cfg *downloader.Config, domains []string) error {
+	ctx, stop := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	dm := downloader.NewDaemon(cfg, domains)
+	dm.Setup = forward
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+			}
+			domains, cfg, err := downloader.ParseArgsConfig()
+			if err == nil {
+				err = cfg.Prepare()
+			}
+			if err == nil && len(domains) == 0 && cfg.Aggregator == "" {
+				err = errors.New("no domains given")
+			}
+			if err != nil {
+				slog.Error("Reloading configuration failed", "error", err)
+				continue
+			}
+			dm.Reload(cfg, domains)
+		}
+	}()
+
+	return dm.Run(ctx)
+}
+
 func main() {
 
-	domains, cfg, err := csaf_downloader.ParseArgsConfig()
+	domains, cfg, err := downloader.ParseArgsConfig()
 	options.ErrorCheck(err)
 	options.ErrorCheck(cfg.Prepare())
 
-	if len(domains) == 0 {
+	// Only forward the advisories failed forwarding before.
+	if cfg.ForwardReplay {
+		options.ErrorCheck(replay(cfg))
+		return
+	}
+
+	// Only import the advisories of a bundle.
+	if cfg.ImportBundle != "" {
+		options.ErrorCheck(downloader.ImportBundle(cfg))
+		return
+	}
+
+	// Without domains the providers of the aggregator are used.
+	if len(domains) == 0 && cfg.Aggregator == "" {
+		// Only write the provider tree of the stored advisories.
+		if cfg.ProviderTree != "" {
+			options.ErrorCheck(downloader.WriteProviderTree(cfg))
+			return
+		}
 		slog.Warn("No domains given.")
 		return
 	}
 
+	if cfg.Daemon {
+		options.ErrorCheck(runDaemon(cfg, domains))
+		return
+	}
 	options.ErrorCheck(run(cfg, domains))
 }
@@ -11,8 +11,10 @@ package csaf
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"log/slog"
 	"net/http"
 	"net/url"
@@ -68,6 +70,9 @@ func (paf PlainAdvisoryFile) LogValue() slog.Value {
 // the file name.
 type DirectoryAdvisoryFile struct {
 	Path string
+	// label is the TLP label named by the folder
+	// of the distribution. See [AdvisoryTLPLabel].
+	label TLPLabel
 }
 
 // URL returns the URL of this advisory.
@@ -95,20 +100,30 @@ func (daf DirectoryAdvisoryFile) LogValue() slog.Value {
 type AdvisoryFileProcessor struct {
 	AgeAccept func(time.Time) bool
 	Log       func(loglevel slog.Level, format string, args ...any)
-	client    util.Client
-	expr      *util.PathEval
-	doc       any
-	pmdURL    *url.URL
+	// Incomplete is called if a feed was not listed completely
+	// without this being reported as an error, e.g. because the
+	// access to it is forbidden or crawling its year folders
+	// failed in degraded mode.
+	Incomplete func(feed string, reason error)
+	client     util.Client
+	expr       *util.PathEval
+	doc        any
+	pmdURL     *url.URL
 }
 
 // NewAdvisoryFileProcessor constructs a filename extractor
-// for a given metadata document.
+// for a given metadata document. If the metadata document
+// was loaded from a file:// URL the feeds, indices and
+// advisories may be local files, too.
 func NewAdvisoryFileProcessor(
 	client util.Client,
 	expr *util.PathEval,
 	doc any,
 	pmdURL *url.URL,
 ) *AdvisoryFileProcessor {
+	if pmdURL != nil && pmdURL.Scheme == "file" {
+		client = &util.FileClient{Client: client}
+	}
 	return &AdvisoryFileProcessor{
 		client: client,
 		expr:   expr,
@@ -127,17 +142,142 @@ func empty(arr []string) bool {
 	return true
 }
 
+// FeedFile is an advisory file as listed in a feed of a provider.
+type FeedFile struct {
+	// File are the URLs of the advisory.
+	File AdvisoryFile
+	// Label is the TLP label of the feed. It is TLP:WHITE for directory
+	// based distributions, see [AdvisoryFileProcessor.Process].
+	Label TLPLabel
+	// Feed is the URL of the ROLIE feed or of the folder of
+	// the directory based distribution.
+	Feed string
+	// Published is the publication time of the ROLIE feed entry.
+	// It is zero for directory based distributions.
+	Published time.Time
+	// Updated is the update time of the ROLIE feed entry or the
+	// time stamp of the advisory in the changes.csv. It is zero
+	// if the advisories of a directory based distribution are
+	// enumerated in degraded mode.
+	Updated time.Time
+}
+
+// feedVisitor receives the results of walking the feeds of
+// a provider. Its functions return false to stop the walk.
+type feedVisitor struct {
+	// file is called for every listed advisory file.
+	file func(FeedFile) bool
+	// fail is called for the errors of single feeds and entries.
+	fail func(error) bool
+	// done is called after the files of a feed if not nil.
+	done func(label TLPLabel, feed string) bool
+	// abort is called instead of done if reading a feed broke
+	// off after some of its files were passed on, if not nil.
+	abort func()
+}
+
+// logger returns the log function of the processor.
+func (afp *AdvisoryFileProcessor) logger() func(slog.Level, string, ...any) {
+	if afp.Log != nil {
+		return afp.Log
+	}
+	return func(loglevel slog.Level, format string, args ...any) {
+		slog.Log(context.Background(), loglevel, "AdvisoryFileProcessor.Process: "+format, args...)
+	}
+}
+
+// incomplete reports a feed which was not listed completely.
+func (afp *AdvisoryFileProcessor) incomplete(feed string, reason error) {
+	if afp.Incomplete != nil {
+		afp.Incomplete(feed, reason)
+	}
+}
+
+// fallback tells if the advisories of a directory based
+// distribution should be crawled as loading its changes.csv
+// failed with err. This is not done for invalid credentials
+// and temporary errors.
+func fallback(err error) bool {
+	var invalid errs.ErrInvalidCredentials
+	return !errors.As(err, &invalid) && !errors.Is(err, errs.ErrRetryable)
+}
+
+// fetch issues a GET request for the given URL which
+// is canceled with ctx.
+func (afp *AdvisoryFileProcessor) fetch(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return afp.client.Do(req)
+}
+
 // Process extracts the advisory filenames and passes them with
-// the corresponding label to fn.
+// the corresponding label to fn. Directory based distributions
+// have no labels. Their files are passed with TLP:WHITE as before.
+// Use [AdvisoryTLPLabel] to determine the label of their advisories
+// from the documents and the folders of the distributions.
+// Process collects the files of a whole feed before calling fn.
+// If the changes.csv of a directory based distribution cannot be
+// read completely fn is not called for it and the error is
+// returned. Use [AdvisoryFileProcessor.Files] to stream the files
+// instead.
 func (afp *AdvisoryFileProcessor) Process(
 	fn func(TLPLabel, []AdvisoryFile) error,
 ) error {
-	lg := afp.Log
-	if lg == nil {
-		lg = func(loglevel slog.Level, format string, args ...any) {
-			slog.Log(context.Background(), loglevel, "AdvisoryFileProcessor.Process: "+format, args...)
+	var (
+		files    []AdvisoryFile
+		feedErrs []error // errors encountered while processing directories/feeds
+	)
+	if err := afp.walk(context.Background(), feedVisitor{
+		file: func(f FeedFile) bool {
+			files = append(files, f.File)
+			return true
+		},
+		fail: func(err error) bool {
+			feedErrs = append(feedErrs, err)
+			return true
+		},
+		done: func(label TLPLabel, _ string) bool {
+			if err := fn(label, files); err != nil {
+				feedErrs = append(feedErrs, err)
+			}
+			files = nil
+			return true
+		},
+		abort: func() { files = nil },
+	}); err != nil {
+		return err
+	}
+	if len(feedErrs) > 0 {
+		return &errs.CompositeErrFeed{Errs: feedErrs}
+	}
+	return nil
+}
+
+// Files returns an iterator over the advisory files listed by the
+// provider. The files are yielded while the feeds are read without
+// collecting them first. ROLIE feeds are loaded and checked as a
+// whole before their entries are yielded. The errors of single
+// feeds and entries are yielded with an empty [FeedFile] and the
+// iteration goes on. If ctx is canceled the iteration ends with
+// the error of ctx. Breaking the loop stops reading the feeds.
+func (afp *AdvisoryFileProcessor) Files(ctx context.Context) iter.Seq2[FeedFile, error] {
+	return func(yield func(FeedFile, error) bool) {
+		if err := afp.walk(ctx, feedVisitor{
+			file: func(f FeedFile) bool { return yield(f, nil) },
+			fail: func(err error) bool { return yield(FeedFile{}, err) },
+		}); err != nil {
+			yield(FeedFile{}, err)
 		}
 	}
+}
+
+// walk reads the feeds of the provider and passes the listed
+// advisory files to v. Errors which prevent reading any
+// feed are returned.
+func (afp *AdvisoryFileProcessor) walk(ctx context.Context, v feedVisitor) error {
+	lg := afp.logger()
 
 	// Check if we have ROLIE feeds.
 	rolie, err := afp.expr.Eval(
@@ -157,98 +297,151 @@ func (afp *AdvisoryFileProcessor) Process(
 		}
 		lg(slog.LevelInfo, "Found ROLIE feed(s)", "length", len(feeds))
 
-		for _, feed := range feeds {
-			if err := afp.processROLIE(feed, fn); err != nil {
-				return err
+		for _, labeledFeeds := range feeds {
+			for i := range labeledFeeds {
+				if !afp.walkROLIE(ctx, &labeledFeeds[i], lg, v) {
+					return nil
+				}
 			}
 		}
-	} else {
-		// No rolie feeds -> try to load files from index.txt
+		return nil
+	}
 
-		directoryURLs, err := afp.expr.Eval(
-			"$.distributions[*].directory_url", afp.doc)
+	// No rolie feeds -> try to load files from changes.csv
 
-		var dirURLs []string
+	directoryURLs, err := afp.expr.Eval(
+		"$.distributions[*].directory_url", afp.doc)
 
-		if err != nil {
-			lg(slog.LevelError, "extracting directory URLs failed", "err", err)
-		} else {
-			var ok bool
-			dirURLs, ok = util.AsStrings(directoryURLs)
-			if !ok {
-				lg(slog.LevelError, "directory_urls are not strings")
-			}
+	var dirURLs []string
+
+	if err != nil {
+		lg(slog.LevelError, "extracting directory URLs failed", "err", err)
+	} else {
+		var ok bool
+		dirURLs, ok = util.AsStrings(directoryURLs)
+		if !ok {
+			lg(slog.LevelError, "directory_urls are not strings")
 		}
+	}
 
-		// Not found -> fall back to PMD url
-		if empty(dirURLs) {
-			baseURL, err := util.BaseURL(afp.pmdURL)
-			if err != nil {
-				return err
-			}
-			dirURLs = []string{baseURL}
+	// Not found -> fall back to PMD url
+	if empty(dirURLs) {
+		baseURL, err := util.BaseURL(afp.pmdURL)
+		if err != nil {
+			return err
 		}
+		dirURLs = []string{baseURL}
+	}
 
-		feedErrs := []error{} // errors encountered while processing directories/feeds
-		for _, base := range dirURLs {
-			if base == "" {
-				continue
-			}
+	for _, base := range dirURLs {
+		if base != "" && !afp.walkDirectory(ctx, base, lg, v) {
+			return nil
+		}
+	}
+	return nil
+}
 
-			// Use changes.csv to be able to filter by age.
-			files, err := afp.loadChanges(base, lg)
-			if err != nil {
-				feedErrs = append(feedErrs, err)
-				continue
+// walkDirectory passes the advisory files of the directory based
+// distribution at base to v. It returns false if the walk is stopped.
+func (afp *AdvisoryFileProcessor) walkDirectory(
+	ctx context.Context,
+	base string,
+	lg func(slog.Level, string, ...any),
+	v feedVisitor,
+) bool {
+	if err := ctx.Err(); err != nil {
+		v.fail(err)
+		return false
+	}
+	// The label named by the folder is kept with the files
+	// to be used if their documents have no label.
+	dirLabel := directoryTLPLabel(base)
+	labeled := func(file AdvisoryFile) AdvisoryFile {
+		if daf, ok := file.(DirectoryAdvisoryFile); ok {
+			daf.label = dirLabel
+			return daf
+		}
+		return file
+	}
+	const label = TLPLabelWhite
+
+	var listed, stopped bool
+	// Use changes.csv to be able to filter by age.
+	err := afp.loadChanges(ctx, base, lg, func(file AdvisoryFile, updated time.Time) bool {
+		listed = true
+		stopped = !v.file(FeedFile{File: labeled(file), Label: label, Feed: base, Updated: updated})
+		return !stopped
+	})
+	switch {
+	case stopped:
+		return false
+	case ctx.Err() != nil:
+		v.fail(ctx.Err())
+		return false
+	case err != nil && !listed && fallback(err):
+		// Fall back to index.txt and the directory listings.
+		files, err := afp.crawl(ctx, base, err, lg)
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
 			}
-			// XXX: Is treating as white okay? better look into the advisories?
-			if err := fn(TLPLabelWhite, files); err != nil {
-				feedErrs = append(feedErrs, err)
+			return v.fail(err) && ctx.Err() == nil
+		}
+		for _, file := range files {
+			if !v.file(FeedFile{File: labeled(file), Label: label, Feed: base}) {
+				return false
 			}
 		}
-
-		if len(feedErrs) > 0 {
-			return &errs.CompositeErrFeed{Errs: feedErrs}
+	case err != nil:
+		// As the files listed so far are already passed on
+		// falling back would list them twice.
+		if listed && v.abort != nil {
+			v.abort()
 		}
-	} // TODO: else scan directories?
-	return nil
+		return v.fail(err)
+	}
+	return v.done == nil || v.done(label, base)
 }
 
-// loadChanges loads baseURL/changes.csv and returns a list of files
-// prefixed by baseURL/.
+// loadChanges loads baseURL/changes.csv and passes the listed
+// files prefixed by baseURL/ with their time stamps to fn
+// until it returns false.
 func (afp *AdvisoryFileProcessor) loadChanges(
+	ctx context.Context,
 	baseURL string,
 	lg func(slog.Level, string, ...any),
-) ([]AdvisoryFile, error) {
+	fn func(AdvisoryFile, time.Time) bool,
+) error {
 	base, err := url.Parse(baseURL)
 	if err != nil {
-		return nil, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("invalid directory url %s: %v", baseURL, err)}
+		return errs.ErrCsafProviderIssue{Message: fmt.Sprintf("invalid directory url %s: %v", baseURL, err)}
 	}
 	changesURL := base.JoinPath("changes.csv").String()
 
-	resp, err := afp.client.Get(changesURL)
+	resp, err := afp.fetch(ctx, changesURL)
 	if err != nil {
-		return nil, errs.ErrNetwork{Message: fmt.Sprintf("failed get request for url %s: %v", changesURL, err)}
+		return errs.ErrNetwork{Message: fmt.Sprintf("failed get request for url %s: %v", changesURL, err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		switch { // we don't expect 401 and 403, as directory based feeds are supposed to be public, but just to be on the safe side
 		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == httpext.StatusNGINXInvalidClientCert || resp.StatusCode == httpext.StatusNGINXNoClientCert:
-			return nil, errs.ErrInvalidCredentials{Message: fmt.Sprintf("invalid credentials for accessing %s: %s", changesURL, resp.Status)}
+			return errs.ErrInvalidCredentials{Message: fmt.Sprintf("invalid credentials for accessing %s: %s", changesURL, resp.Status)}
 		case resp.StatusCode == http.StatusForbidden:
-			return []AdvisoryFile{}, nil // user has insufficient permissions to access feed, no error
+			// user has insufficient permissions to access feed, no error
+			afp.incomplete(baseURL, fmt.Errorf("access to %s forbidden: %s", changesURL, resp.Status))
+			return nil
 		case resp.StatusCode == http.StatusNotFound:
-			return nil, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not find changes.csv at %s: %s", changesURL, resp.Status)}
+			return errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not find changes.csv at %s: %s", changesURL, resp.Status)}
 		case resp.StatusCode >= 500:
 			providerErr := errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not retrieve changes.csv at %s: %s", changesURL, resp.Status)}
-			return nil, fmt.Errorf("%w %w", providerErr, errs.ErrRetryable) // mark error as retryable as failure for server side errors are often temporary
+			return fmt.Errorf("%w %w", providerErr, errs.ErrRetryable) // mark error as retryable as failure for server side errors are often temporary
 		default: // client error or fringe case
-			return nil, fmt.Errorf("could not retrieve changes.csv at %s: %s", changesURL, resp.Status)
+			return fmt.Errorf("could not retrieve changes.csv at %s: %s", changesURL, resp.Status)
 		}
 	}
 
-	var files []AdvisoryFile
 	c := csv.NewReader(resp.Body)
 	// format specification:
 	// https://docs.oasis-open.org/csaf/csaf/v2.0/os/csaf-v2.0-os.html#7113-requirement-13-changescsv
@@ -263,189 +456,204 @@ func (afp *AdvisoryFileProcessor) loadChanges(
 			break
 		}
 		if err != nil {
-			return nil, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not read record from changes.csv: %v", err)}
+			return errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not read record from changes.csv: %v", err)}
 		}
 		t, err := time.Parse(time.RFC3339, r[timeColumn])
 		if err != nil {
 			lg(slog.LevelError, "Invalid time stamp in line", "url", changesURL, "line", line, "err", err)
-			return nil, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not read timestamp from changes.csv: %v", err)}
+			return errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not read timestamp from changes.csv: %v", err)}
 		}
 		// Apply date range filtering.
 		if afp.AgeAccept != nil && !afp.AgeAccept(t) {
 			continue
 		}
 		path := r[pathColumn]
-		if _, err := url.Parse(path); err != nil {
-			lg(slog.LevelError, "Contains an invalid URL", "url", changesURL, "path", path, "line", line)
-			return nil, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not read url from changes.csv: %v", err)}
-		}
-
 		pathURL, err := url.Parse(path)
 		if err != nil {
-			return nil, err
+			lg(slog.LevelError, "Contains an invalid URL", "url", changesURL, "path", path, "line", line)
+			return errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not read url from changes.csv: %v", err)}
 		}
 
-		files = append(files,
-			DirectoryAdvisoryFile{Path: misc.JoinURL(base, pathURL).String()})
+		if !fn(DirectoryAdvisoryFile{Path: misc.JoinURL(base, pathURL).String()}, t) {
+			break
+		}
 	}
-	return files, nil
+	return nil
 }
 
-func (afp *AdvisoryFileProcessor) processROLIE(
-	labeledFeeds []Feed,
-	fn func(TLPLabel, []AdvisoryFile) error,
-) error {
-	var feedErrs []error
-	for i := range labeledFeeds {
-		feed := &labeledFeeds[i]
-		if feed.URL == nil {
-			continue
-		}
+// walkROLIE passes the advisory files of a ROLIE feed to v.
+// It returns false if the walk is stopped.
+func (afp *AdvisoryFileProcessor) walkROLIE(
+	ctx context.Context,
+	feed *Feed,
+	lg func(slog.Level, string, ...any),
+	v feedVisitor,
+) bool {
+	if feed.URL == nil {
+		return true
+	}
+	if err := ctx.Err(); err != nil {
+		v.fail(err)
+		return false
+	}
 
-		var label TLPLabel
-		if feed.TLPLabel != nil {
-			label = *feed.TLPLabel
-		} else {
-			label = "unknown"
-		}
+	var label TLPLabel
+	if feed.TLPLabel != nil {
+		label = *feed.TLPLabel
+	} else {
+		label = "unknown"
+	}
 
-		feedURL, err := url.Parse(string(*feed.URL))
-		if err != nil {
-			slog.Error("Invalid URL in feed", "feed", *feed.URL, "err", err)
-			feedErrs = append(feedErrs, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("invalid TLP:%s feed URL %s: %v", label, *feed.URL, err)})
-			continue
-		}
-		slog.Info("Got feed URL", "feed", feedURL)
+	feedURL, err := url.Parse(string(*feed.URL))
+	if err != nil {
+		lg(slog.LevelError, "Invalid URL in feed", "feed", *feed.URL, "err", err)
+		return v.fail(errs.ErrCsafProviderIssue{Message: fmt.Sprintf("invalid TLP:%s feed URL %s: %v", label, *feed.URL, err)})
+	}
+	lg(slog.LevelInfo, "Got feed URL", "feed", feedURL)
 
-		fb, err := util.BaseURL(feedURL)
-		if err != nil {
-			slog.Error("Invalid feed base URL", "url", fb, "err", err)
-			feedErrs = append(feedErrs, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("invalid TLP:%s feed base URL %s: %v", label, fb, err)})
-			continue
-		}
+	fb, err := util.BaseURL(feedURL)
+	if err != nil {
+		lg(slog.LevelError, "Invalid feed base URL", "url", fb, "err", err)
+		return v.fail(errs.ErrCsafProviderIssue{Message: fmt.Sprintf("invalid TLP:%s feed base URL %s: %v", label, fb, err)})
+	}
 
-		res, err := afp.client.Get(feedURL.String())
-		if err != nil {
-			slog.Error("Cannot get feed", "err", err)
-			feedErrs = append(feedErrs, errs.ErrNetwork{Message: fmt.Sprintf("failed get for TLP:%s feed url %s: %v", label, feedURL.String(), err)})
-			continue
+	res, err := afp.fetch(ctx, feedURL.String())
+	if err != nil {
+		if ctx.Err() != nil {
+			v.fail(ctx.Err())
+			return false
 		}
-		if res.StatusCode != http.StatusOK {
-			res.Body.Close()
-			slog.Error("Fetching failed",
-				"url", feedURL, "status_code", res.StatusCode, "status", res.Status)
-			switch {
-			case res.StatusCode == http.StatusUnauthorized:
-				feedErrs = append(feedErrs, errs.ErrInvalidCredentials{Message: fmt.Sprintf("invalid credentials for TLP:%s ROLIE feed at %s: %s", label, feedURL.String(), res.Status)})
-			case res.StatusCode == http.StatusForbidden:
-				// user has insufficient permissions to access feed, no error
-			case res.StatusCode == http.StatusNotFound:
-				feedErrs = append(feedErrs, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not find TLP:%s ROLIE feed at %s: %s", label, feedURL.String(), res.Status)})
-			case res.StatusCode >= 500:
-				providerErr := errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not retrieve TLP:%s ROLIE feed at %s: %s", label, feedURL.String(), res.Status)}
-				feedErrs = append(feedErrs, fmt.Errorf("%w %w", providerErr, errs.ErrRetryable)) // mark error as retryable as failure for server side errors are often temporary
-			default: // client error or fringe case
-				feedErrs = append(feedErrs, fmt.Errorf("could not retrieve TLP:%s ROLIE feed at %s: %s", label, feedURL.String(), res.Status))
-			}
-			continue
+		lg(slog.LevelError, "Cannot get feed", "err", err)
+		return v.fail(errs.ErrNetwork{Message: fmt.Sprintf("failed get for TLP:%s feed url %s: %v", label, feedURL.String(), err)})
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		lg(slog.LevelError, "Fetching failed",
+			"url", feedURL, "status_code", res.StatusCode, "status", res.Status)
+		switch {
+		case res.StatusCode == http.StatusUnauthorized:
+			return v.fail(errs.ErrInvalidCredentials{Message: fmt.Sprintf("invalid credentials for TLP:%s ROLIE feed at %s: %s", label, feedURL.String(), res.Status)})
+		case res.StatusCode == http.StatusForbidden:
+			// user has insufficient permissions to access feed, no error
+			afp.incomplete(feedURL.String(), fmt.Errorf("access to TLP:%s ROLIE feed at %s forbidden: %s", label, feedURL, res.Status))
+			return true
+		case res.StatusCode == http.StatusNotFound:
+			return v.fail(errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not find TLP:%s ROLIE feed at %s: %s", label, feedURL.String(), res.Status)})
+		case res.StatusCode >= 500:
+			providerErr := errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not retrieve TLP:%s ROLIE feed at %s: %s", label, feedURL.String(), res.Status)}
+			return v.fail(fmt.Errorf("%w %w", providerErr, errs.ErrRetryable)) // mark error as retryable as failure for server side errors are often temporary
+		default: // client error or fringe case
+			return v.fail(fmt.Errorf("could not retrieve TLP:%s ROLIE feed at %s: %s", label, feedURL.String(), res.Status))
 		}
-		rfeed, err := func() (*ROLIEFeed, error) {
-			defer res.Body.Close()
-			return LoadROLIEFeed(res.Body)
-		}()
-		if err != nil {
-			slog.Error("Loading ROLIE feed failed", "err", err)
-			feedErrs = append(feedErrs, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("TLP:%s ROLIE feed at %s is not valid JSON: %v", label, feedURL.String(), err)})
-			continue
+	}
+	rfeed, err := func() (*ROLIEFeed, error) {
+		defer res.Body.Close()
+		return LoadROLIEFeed(res.Body)
+	}()
+	if err != nil {
+		if ctx.Err() != nil {
+			v.fail(ctx.Err())
+			return false
 		}
+		lg(slog.LevelError, "Loading ROLIE feed failed", "err", err)
+		return v.fail(errs.ErrCsafProviderIssue{Message: fmt.Sprintf("TLP:%s ROLIE feed at %s is not valid JSON: %v", label, feedURL.String(), err)})
+	}
 
-		var files []AdvisoryFile
-
-		resolve := func(u string) (string, error) {
-			if u == "" {
-				return "", errs.ErrCsafProviderIssue{Message: fmt.Sprintf("empty url in TLP:%s ROLIE feed at %s to file", label, feedURL.String())}
-			}
-			p, err := url.Parse(u)
-			if err != nil {
-				slog.Error("Invalid URL", "url", u, "err", err)
-				return "", errs.ErrCsafProviderIssue{Message: fmt.Sprintf("invalid url in TLP:%s ROLIE feed at %s to file %s: %v", label, feedURL.String(), u, err)}
-			}
-			return p.String(), nil
+	resolve := func(u string) (string, error) {
+		if u == "" {
+			return "", errs.ErrCsafProviderIssue{Message: fmt.Sprintf("empty url in TLP:%s ROLIE feed at %s to file", label, feedURL.String())}
 		}
+		p, err := url.Parse(u)
+		if err != nil {
+			lg(slog.LevelError, "Invalid URL", "url", u, "err", err)
+			return "", errs.ErrCsafProviderIssue{Message: fmt.Sprintf("invalid url in TLP:%s ROLIE feed at %s to file %s: %v", label, feedURL.String(), u, err)}
+		}
+		return p.String(), nil
+	}
 
-		rfeed.Entries(func(entry *Entry) {
-			// Filter if we have date checking.
-			if afp.AgeAccept != nil {
-				if t := time.Time(entry.Updated); !t.IsZero() && !afp.AgeAccept(t) {
-					return
+	// entryFile returns the advisory file of an entry
+	// and the errors found in the entry.
+	entryFile := func(entry *Entry) (AdvisoryFile, []error) {
+		var (
+			self, sha256, sha512, sign string
+			entryErrs                  []error
+			csafLinkExists             bool
+			err                        error
+		)
+		for i := range entry.Link {
+			link := &entry.Link[i]
+			lower := strings.ToLower(link.HRef)
+			switch link.Rel {
+			case "self":
+				csafLinkExists = true
+				self, err = resolve(link.HRef)
+				if err != nil {
+					return nil, append(entryErrs, err)
 				}
-			}
-
-			var self, sha256, sha512, sign string
-
-			var csafLinkExists bool
-			for i := range entry.Link {
-				link := &entry.Link[i]
-				lower := strings.ToLower(link.HRef)
-				switch link.Rel {
-				case "self":
-					csafLinkExists = true
-					self, err = resolve(link.HRef)
+			case "signature":
+				sign, err = resolve(link.HRef)
+				if err != nil {
+					entryErrs = append(entryErrs, err)
+				}
+			case "hash":
+				switch {
+				case strings.HasSuffix(lower, ".sha256"):
+					sha256, err = resolve(link.HRef)
 					if err != nil {
-						feedErrs = append(feedErrs, err)
-						return
+						entryErrs = append(entryErrs, err)
 					}
-				case "signature":
-					sign, err = resolve(link.HRef)
+				case strings.HasSuffix(lower, ".sha512"):
+					sha512, err = resolve(link.HRef)
 					if err != nil {
-						feedErrs = append(feedErrs, err)
-					}
-				case "hash":
-					switch {
-					case strings.HasSuffix(lower, ".sha256"):
-						sha256, err = resolve(link.HRef)
-						if err != nil {
-							feedErrs = append(feedErrs, err)
-						}
-					case strings.HasSuffix(lower, ".sha512"):
-						sha512, err = resolve(link.HRef)
-						if err != nil {
-							feedErrs = append(feedErrs, err)
-						}
+						entryErrs = append(entryErrs, err)
 					}
 				}
 			}
+		}
 
-			if !csafLinkExists {
-				feedErrs = append(feedErrs, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("TLP:%s ROLIE feed at %s contains entry (ID '%s') without link to csaf document", label, feedURL.String(), entry.ID)})
-			}
+		if !csafLinkExists {
+			entryErrs = append(entryErrs, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("TLP:%s ROLIE feed at %s contains entry (ID '%s') without link to csaf document", label, feedURL.String(), entry.ID)})
+		}
 
-			var file AdvisoryFile
+		switch {
+		case sha256 == "" && sha512 == "":
+			lg(slog.LevelError, "No hash listed on ROLIE feed", "file", self)
+			err := errs.ErrCsafProviderIssue{Message: fmt.Sprintf("no hash listed on TLP:%s ROLIE feed (%s) for CSAF %s", label, feedURL.String(), self)}
+			return nil, append(entryErrs, err)
+		case sign == "":
+			lg(slog.LevelError, "No signature listed on ROLIE feed", "file", self)
+			err := errs.ErrCsafProviderIssue{Message: fmt.Sprintf("no signature listed on TLP:%s ROLIE feed (%s) for CSAF %s", label, feedURL.String(), self)}
+			return nil, append(entryErrs, err)
+		}
+		return PlainAdvisoryFile{self, sha256, sha512, sign}, entryErrs
+	}
 
-			switch {
-			case sha256 == "" && sha512 == "":
-				slog.Error("No hash listed on ROLIE feed", "file", self)
-				err := errs.ErrCsafProviderIssue{Message: fmt.Sprintf("no hash listed on TLP:%s ROLIE feed (%s) for CSAF %s", label, feedURL.String(), self)}
-				feedErrs = append(feedErrs, err)
-				return
-			case sign == "":
-				slog.Error("No signature listed on ROLIE feed", "file", self)
-				err := errs.ErrCsafProviderIssue{Message: fmt.Sprintf("no signature listed on TLP:%s ROLIE feed (%s) for CSAF %s", label, feedURL.String(), self)}
-				feedErrs = append(feedErrs, err)
-				return
-			default:
-				file = PlainAdvisoryFile{self, sha256, sha512, sign}
+	for _, entry := range rfeed.Feed.Entry {
+		if err := ctx.Err(); err != nil {
+			v.fail(err)
+			return false
+		}
+		// Filter if we have date checking.
+		if afp.AgeAccept != nil {
+			if t := time.Time(entry.Updated); !t.IsZero() && !afp.AgeAccept(t) {
+				continue
 			}
-
-			files = append(files, file)
-		})
-
-		if err := fn(label, files); err != nil {
-			feedErrs = append(feedErrs, err)
+		}
+		file, entryErrs := entryFile(entry)
+		for _, err := range entryErrs {
+			if !v.fail(err) {
+				return false
+			}
+		}
+		if file != nil && !v.file(FeedFile{
+			File:      file,
+			Label:     label,
+			Feed:      feedURL.String(),
+			Published: time.Time(entry.Published),
+			Updated:   time.Time(entry.Updated),
+		}) {
+			return false
 		}
 	}
-	if len(feedErrs) > 0 {
-		return &errs.CompositeErrFeed{Errs: feedErrs}
-	}
-	return nil
+	return v.done == nil || v.done(label, feedURL.String())
 }
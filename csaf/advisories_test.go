@@ -0,0 +1,231 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package csaf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestFiles(t *testing.T) {
+	published := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	feed := func(label TLPLabel, n int) http.HandlerFunc {
+		rfeed := &ROLIEFeed{Feed: FeedData{ID: string(label)}}
+		for i := range n {
+			self := fmt.Sprintf("%s/%s/a-%03d.json", server.URL, label, i)
+			rfeed.Feed.Entry = append(rfeed.Feed.Entry, &Entry{
+				ID: fmt.Sprintf("a-%03d", i),
+				Link: []Link{
+					{Rel: "self", HRef: self},
+					{Rel: "hash", HRef: self + ".sha256"},
+					{Rel: "signature", HRef: self + ".asc"},
+				},
+				Published: TimeStamp(published),
+				Updated:   TimeStamp(published.AddDate(0, 0, i)),
+			})
+		}
+		return func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			rfeed.WriteTo(w)
+		}
+	}
+	mux.Handle("/white.json", feed(TLPLabelWhite, 3))
+	mux.Handle("/green.json", feed(TLPLabelGreen, 2))
+
+	feeds := []any{
+		map[string]any{"summary": "white", "tlp_label": "WHITE", "url": server.URL + "/white.json"},
+		map[string]any{"summary": "green", "tlp_label": "GREEN", "url": server.URL + "/green.json"},
+	}
+	doc := map[string]any{
+		"distributions": []any{
+			map[string]any{"rolie": map[string]any{"feeds": feeds}},
+		},
+	}
+	pmdURL, _ := url.Parse(server.URL + "/provider-metadata.json")
+	afp := NewAdvisoryFileProcessor(server.Client(), util.NewPathEval(), doc, pmdURL)
+
+	t.Run("all", func(t *testing.T) {
+		requests.Store(0)
+		var got []FeedFile
+		for f, err := range afp.Files(context.Background()) {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, f)
+		}
+		if len(got) != 5 {
+			t.Fatalf("got %d files, want 5", len(got))
+		}
+		last := got[2]
+		if last.Label != TLPLabelWhite ||
+			last.Feed != server.URL+"/white.json" ||
+			last.File.URL() != server.URL+"/WHITE/a-002.json" ||
+			!last.Published.Equal(published) ||
+			!last.Updated.Equal(published.AddDate(0, 0, 2)) {
+			t.Errorf("unexpected file %+v", last)
+		}
+		if got[3].Label != TLPLabelGreen {
+			t.Errorf("got label %q, want %q", got[3].Label, TLPLabelGreen)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		requests.Store(0)
+		n := 0
+		for range afp.Files(context.Background()) {
+			if n++; n == 2 {
+				break
+			}
+		}
+		if r := requests.Load(); r != 1 {
+			t.Errorf("got %d feed requests, want 1", r)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var last error
+		n := 0
+		for _, err := range afp.Files(ctx) {
+			if n++; n == 1 {
+				cancel()
+			}
+			last = err
+		}
+		if !errors.Is(last, context.Canceled) {
+			t.Errorf("got %v, want %v", last, context.Canceled)
+		}
+		if n != 2 {
+			t.Errorf("got %d results, want 2", n)
+		}
+	})
+}
+
+func TestFilesForbidden(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	for name, distribution := range map[string]any{
+		"directory": map[string]any{"directory_url": server.URL + "/white/"},
+		"rolie": map[string]any{"rolie": map[string]any{"feeds": []any{
+			map[string]any{"summary": "red", "tlp_label": "RED", "url": server.URL + "/red.json"},
+		}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			doc := map[string]any{"distributions": []any{distribution}}
+			pmdURL, _ := url.Parse(server.URL + "/provider-metadata.json")
+			afp := NewAdvisoryFileProcessor(server.Client(), util.NewPathEval(), doc, pmdURL)
+			var incomplete []string
+			afp.Incomplete = func(feed string, _ error) {
+				incomplete = append(incomplete, feed)
+			}
+			for _, err := range afp.Files(t.Context()) {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			if len(incomplete) != 1 {
+				t.Errorf("got incomplete feeds %q, want one", incomplete)
+			}
+		})
+	}
+}
+
+func TestProcessBrokenChanges(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "\"2020/a-001.json\",\"2020-01-01T00:00:00Z\"\n\"2020/a-002.json\"\n")
+	}))
+	defer server.Close()
+
+	doc := map[string]any{"distributions": []any{
+		map[string]any{"directory_url": server.URL + "/white/"},
+	}}
+	pmdURL, _ := url.Parse(server.URL + "/provider-metadata.json")
+	afp := NewAdvisoryFileProcessor(server.Client(), util.NewPathEval(), doc, pmdURL)
+	afp.Log = func(slog.Level, string, ...any) {}
+
+	called := false
+	if err := afp.Process(func(TLPLabel, []AdvisoryFile) error {
+		called = true
+		return nil
+	}); err == nil {
+		t.Error("broken changes.csv not reported")
+	}
+	if called {
+		t.Error("partial listing passed on")
+	}
+
+	// Streaming passes the files read so far.
+	var files int
+	var failed bool
+	for f, err := range afp.Files(t.Context()) {
+		if err != nil {
+			failed = true
+		} else if f.File != nil {
+			files++
+		}
+	}
+	if files != 1 || !failed {
+		t.Errorf("got %d files and error %t, want 1 and true", files, failed)
+	}
+}
+
+func TestProcessDirectoryLabel(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "\"2020/a-001.json\",\"2020-01-01T00:00:00Z\"\n")
+	}))
+	defer server.Close()
+
+	doc := map[string]any{"distributions": []any{
+		map[string]any{"directory_url": server.URL + "/amber/"},
+	}}
+	pmdURL, _ := url.Parse(server.URL + "/provider-metadata.json")
+	afp := NewAdvisoryFileProcessor(server.Client(), util.NewPathEval(), doc, pmdURL)
+	afp.Log = func(slog.Level, string, ...any) {}
+
+	var files int
+	if err := afp.Process(func(label TLPLabel, fs []AdvisoryFile) error {
+		// Directory based distributions are passed as TLP:WHITE.
+		if label != TLPLabelWhite {
+			t.Errorf("got label %q, want %q", label, TLPLabelWhite)
+		}
+		for _, f := range fs {
+			files++
+			got, ok := AdvisoryTLPLabel(util.NewPathEval(), f, label, map[string]any{})
+			if got != TLPLabelAmber || !ok {
+				t.Errorf("got advisory label %q/%t, want %q/true", got, ok, TLPLabelAmber)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if files != 1 {
+		t.Errorf("got %d files, want 1", files)
+	}
+}
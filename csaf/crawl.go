@@ -0,0 +1,192 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package csaf
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gocsaf/csaf/v3/internal/misc"
+	"github.com/gocsaf/csaf/v3/pkg/errs"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// yearFolder matches the names of the year folders
+// of a directory based distribution.
+var yearFolder = regexp.MustCompile(`^\d{4}$`)
+
+// crawl enumerates the advisories of a directory based
+// distribution if its changes.csv is absent or broken as
+// reported by cause. The index.txt is used or, if it is not
+// usable either, the directory listings of the folder and
+// its year folders. As the advisories cannot be filtered by
+// age without the changes.csv nothing is enumerated if an age
+// filter is set. The distribution is reported as incomplete then.
+func (afp *AdvisoryFileProcessor) crawl(
+	ctx context.Context,
+	baseURL string,
+	cause error,
+	lg func(slog.Level, string, ...any),
+) ([]AdvisoryFile, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, cause
+	}
+	lg(slog.LevelWarn, "Loading changes.csv failed", "url", baseURL, "err", cause)
+	if afp.AgeAccept != nil {
+		lg(slog.LevelWarn, "Cannot filter by age without changes.csv, skipping advisories", "url", base)
+		afp.incomplete(baseURL, fmt.Errorf(
+			"cannot filter advisories of %s by age without changes.csv: %w", baseURL, cause))
+		return nil, nil
+	}
+
+	files, err := afp.loadIndex(ctx, base)
+	if err == nil {
+		lg(slog.LevelWarn, "Degraded mode: enumerating advisories from index.txt",
+			"url", base, "files", len(files))
+		return files, nil
+	}
+	lg(slog.LevelWarn, "Loading index.txt failed", "url", base, "err", err)
+
+	files, lerr := afp.crawlListings(ctx, base, lg)
+	if lerr != nil {
+		return nil, errors.Join(cause, err, lerr)
+	}
+	lg(slog.LevelWarn, "Degraded mode: enumerating advisories from directory listings",
+		"url", base, "files", len(files))
+	return files, nil
+}
+
+// get fetches the resource at the given URL.
+func (afp *AdvisoryFileProcessor) get(ctx context.Context, u string) (io.ReadCloser, error) {
+	resp, err := afp.fetch(ctx, u)
+	if err != nil {
+		return nil, errs.ErrNetwork{Message: fmt.Sprintf("failed get request for url %s: %v", u, err)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not retrieve %s: %s", u, resp.Status)}
+	}
+	return resp.Body, nil
+}
+
+// loadIndex loads base/index.txt and returns a list of files
+// prefixed by base/.
+func (afp *AdvisoryFileProcessor) loadIndex(ctx context.Context, base *url.URL) ([]AdvisoryFile, error) {
+	indexURL := base.JoinPath("index.txt").String()
+	body, err := afp.get(ctx, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var files []AdvisoryFile
+	scanner := bufio.NewScanner(body)
+	for line := 1; scanner.Scan(); line++ {
+		p := strings.TrimSpace(scanner.Text())
+		if p == "" {
+			continue
+		}
+		pathURL, err := url.Parse(p)
+		if err != nil {
+			return nil, errs.ErrCsafProviderIssue{Message: fmt.Sprintf(
+				"invalid url in line %d of %s: %v", line, indexURL, err)}
+		}
+		files = append(files,
+			DirectoryAdvisoryFile{Path: misc.JoinURL(base, pathURL).String()})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not read %s: %v", indexURL, err)}
+	}
+	return files, nil
+}
+
+// listing returns the targets of the links of the directory listing
+// at the given URL.
+func (afp *AdvisoryFileProcessor) listing(ctx context.Context, dir *url.URL) ([]*url.URL, error) {
+	body, err := afp.get(ctx, dir.String())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var links []*url.URL
+	if err := misc.LinksOnPage(body, func(link string) error {
+		// Ignore links which cannot be followed.
+		if u, err := url.Parse(link); err == nil {
+			links = append(links, dir.ResolveReference(u))
+		}
+		return nil
+	}); err != nil {
+		return nil, errs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not parse listing %s: %v", dir, err)}
+	}
+	return links, nil
+}
+
+// crawlListings collects the advisories linked in the directory
+// listing of base and in the listings of its year folders.
+// Year folders which cannot be listed are reported as incomplete.
+func (afp *AdvisoryFileProcessor) crawlListings(
+	ctx context.Context,
+	base *url.URL,
+	lg func(slog.Level, string, ...any),
+) ([]AdvisoryFile, error) {
+	base = base.JoinPath("/")
+	prefix := base.String()
+
+	var (
+		files []AdvisoryFile
+		seen  = util.Set[string]{}
+	)
+	visit := func(dir *url.URL, years bool) ([]*url.URL, error) {
+		links, err := afp.listing(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		var folders []*url.URL
+		for _, link := range links {
+			link.RawQuery, link.Fragment = "", ""
+			s := link.String()
+			// Stay below the folder of the distribution.
+			if !strings.HasPrefix(s, prefix) || s == prefix {
+				continue
+			}
+			name := path.Base(link.Path)
+			switch {
+			case years && strings.HasSuffix(link.Path, "/") && yearFolder.MatchString(name):
+				folders = append(folders, link)
+			case util.ConformingFileName(name) && !seen.Contains(s):
+				seen.Add(s)
+				files = append(files, DirectoryAdvisoryFile{Path: s})
+			}
+		}
+		return folders, nil
+	}
+
+	folders, err := visit(base, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, folder := range folders {
+		if _, err := visit(folder, false); err != nil {
+			lg(slog.LevelWarn, "Crawling year folder failed", "url", folder, "err", err)
+			afp.incomplete(prefix, fmt.Errorf("crawling year folder %s failed: %w", folder, err))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,141 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package csaf
+
+import (
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestProcessDirectoryFallback(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		files      map[string]string
+		status     map[string]int
+		age        bool
+		want       []string
+		degrade    bool
+		incomplete bool
+		fail       bool
+	}{{
+		name: "changes.csv",
+		files: map[string]string{
+			"changes.csv": `"2020/a-001.json","2020-01-01T00:00:00Z"` + "\n",
+			"index.txt":   "2020/a-001.json\n2021/b-001.json\n",
+		},
+		want: []string{"2020/a-001.json"},
+	}, {
+		name: "index.txt",
+		files: map[string]string{
+			"changes.csv": "broken",
+			"index.txt":   "2020/a-001.json\n\n2021/b-001.json\n",
+		},
+		want:    []string{"2020/a-001.json", "2021/b-001.json"},
+		degrade: true,
+	}, {
+		name:    "listings",
+		want:    []string{"2020/a-001.json", "2021/b-001.json", "c-001.json"},
+		degrade: true,
+	}, {
+		name:       "partial listings",
+		status:     map[string]int{"/white/2021/": http.StatusInternalServerError},
+		want:       []string{"2020/a-001.json", "c-001.json"},
+		degrade:    true,
+		incomplete: true,
+	}, {
+		name:       "age filter",
+		age:        true,
+		incomplete: true,
+	}, {
+		name:   "unauthorized",
+		status: map[string]int{"/white/changes.csv": http.StatusUnauthorized},
+		fail:   true,
+	}, {
+		name:   "retryable",
+		status: map[string]int{"/white/changes.csv": http.StatusServiceUnavailable},
+		fail:   true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{}
+			for _, name := range []string{
+				"2020/a-001.json", "2020/a-001.json.sha256",
+				"2021/b-001.json", "c-001.json", "notes/d-001.json",
+			} {
+				files[name] = "{}"
+			}
+			maps.Copy(files, tc.files)
+			for name, content := range files {
+				fname := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(fname, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			fs := http.StripPrefix("/white", http.FileServer(http.Dir(dir)))
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if status := tc.status[r.URL.Path]; status != 0 {
+					w.WriteHeader(status)
+					return
+				}
+				fs.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+
+			pmdURL, _ := url.Parse(server.URL + "/provider-metadata.json")
+			doc := map[string]any{
+				"distributions": []any{
+					map[string]any{"directory_url": server.URL + "/white/"},
+				},
+			}
+			afp := NewAdvisoryFileProcessor(server.Client(), util.NewPathEval(), doc, pmdURL)
+			var degraded, incomplete bool
+			afp.Log = func(level slog.Level, msg string, _ ...any) {
+				degraded = degraded || strings.HasPrefix(msg, "Degraded mode")
+			}
+			afp.Incomplete = func(string, error) { incomplete = true }
+			if tc.age {
+				afp.AgeAccept = func(time.Time) bool { return true }
+			}
+
+			var got []string
+			if err := afp.Process(func(_ TLPLabel, files []AdvisoryFile) error {
+				for _, f := range files {
+					got = append(got, f.URL()[len(server.URL+"/white/"):])
+				}
+				return nil
+			}); (err != nil) != tc.fail {
+				t.Fatalf("processing failed: %v", err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			if degraded != tc.degrade {
+				t.Errorf("degraded mode: got %t, want %t", degraded, tc.degrade)
+			}
+			if incomplete != tc.incomplete {
+				t.Errorf("incomplete: got %t, want %t", incomplete, tc.incomplete)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package csaf
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// DocumentMetadata are the commonly extracted fields of the
+// document section of a CSAF advisory. Fields which are absent
+// or of an unexpected type are empty.
+type DocumentMetadata struct {
+	Category           string
+	Title              string
+	ID                 string
+	Version            string
+	Status             string
+	InitialReleaseDate string
+	CurrentReleaseDate string
+	TLPLabel           string
+	// Summary is the text of the first note of category summary.
+	Summary string
+	// Publisher is the undecoded publisher object.
+	Publisher map[string]any
+}
+
+// DecodeDocumentMetadata extracts the commonly used fields of
+// an advisory decoded by [encoding/json] into a tree of maps and
+// slices. It walks the tree directly which is much faster than
+// evaluating a JSONPath expression per field. It returns false if
+// doc has no document object.
+func DecodeDocumentMetadata(doc any) (*DocumentMetadata, bool) {
+	root, ok := doc.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	document, ok := root["document"].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	str := func(m map[string]any, key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	obj := func(m map[string]any, key string) map[string]any {
+		o, _ := m[key].(map[string]any)
+		return o
+	}
+	tracking := obj(document, "tracking")
+	md := &DocumentMetadata{
+		Category:           str(document, "category"),
+		Title:              str(document, "title"),
+		ID:                 str(tracking, "id"),
+		Version:            str(tracking, "version"),
+		Status:             str(tracking, "status"),
+		InitialReleaseDate: str(tracking, "initial_release_date"),
+		CurrentReleaseDate: str(tracking, "current_release_date"),
+		TLPLabel:           str(obj(obj(document, "distribution"), "tlp"), "label"),
+		Publisher:          obj(document, "publisher"),
+	}
+	notes, _ := document["notes"].([]any)
+	for _, n := range notes {
+		note, _ := n.(map[string]any)
+		if note["category"] != "summary" && note["type"] != "summary" {
+			continue
+		}
+		if text, ok := note["text"].(string); ok {
+			md.Summary = text
+			break
+		}
+	}
+	return md, true
+}
+
+// metadataDocument is the part of an advisory decoded
+// by [ParseDocumentMetadata].
+type metadataDocument struct {
+	Document *struct {
+		Category  string         `json:"category"`
+		Title     string         `json:"title"`
+		Publisher map[string]any `json:"publisher"`
+		Tracking  struct {
+			ID                 string `json:"id"`
+			Version            string `json:"version"`
+			Status             string `json:"status"`
+			InitialReleaseDate string `json:"initial_release_date"`
+			CurrentReleaseDate string `json:"current_release_date"`
+		} `json:"tracking"`
+		Distribution struct {
+			TLP struct {
+				Label string `json:"label"`
+			} `json:"tlp"`
+		} `json:"distribution"`
+		Notes []struct {
+			Category string  `json:"category"`
+			Type     string  `json:"type"`
+			Text     *string `json:"text"`
+		} `json:"notes"`
+	} `json:"document"`
+}
+
+// ParseDocumentMetadata decodes the commonly used fields of an
+// advisory from its raw JSON data. Only these fields are decoded,
+// the rest of the document is skipped without building a tree of
+// maps and slices. Use [DecodeDocumentMetadata] if the document
+// is already decoded. It returns an error if data is not valid
+// JSON, a field has an unexpected type or there is no document
+// object.
+func ParseDocumentMetadata(data []byte) (*DocumentMetadata, error) {
+	var md metadataDocument
+	if err := json.Unmarshal(data, &md); err != nil {
+		return nil, err
+	}
+	document := md.Document
+	if document == nil {
+		return nil, errors.New("no document object")
+	}
+	dm := &DocumentMetadata{
+		Category:           document.Category,
+		Title:              document.Title,
+		ID:                 document.Tracking.ID,
+		Version:            document.Tracking.Version,
+		Status:             document.Tracking.Status,
+		InitialReleaseDate: document.Tracking.InitialReleaseDate,
+		CurrentReleaseDate: document.Tracking.CurrentReleaseDate,
+		TLPLabel:           document.Distribution.TLP.Label,
+		Publisher:          document.Publisher,
+	}
+	for _, note := range document.Notes {
+		if (note.Category == "summary" || note.Type == "summary") && note.Text != nil {
+			dm.Summary = *note.Text
+			break
+		}
+	}
+	return dm, nil
+}
@@ -15,6 +15,9 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/gocsaf/csaf/v3/internal/misc"
@@ -24,6 +27,9 @@ import (
 // ProviderMetadataLoader helps load provider-metadata.json from
 // the various locations.
 type ProviderMetadataLoader struct {
+	// Logger is used for logging. If nil the default logger is used.
+	Logger *slog.Logger
+
 	client   util.Client
 	already  map[string]*LoadedProviderMetadata
 	messages ProviderMetadataLoadMessages
@@ -101,6 +107,14 @@ func (lpm *LoadedProviderMetadata) Valid() bool {
 	return lpm != nil && lpm.Document != nil && lpm.Hash != nil
 }
 
+// logger returns the logger to be used.
+func (pmdl *ProviderMetadataLoader) logger() *slog.Logger {
+	if pmdl.Logger != nil {
+		return pmdl.Logger
+	}
+	return slog.Default()
+}
+
 // NewProviderMetadataLoader create a new loader.
 func NewProviderMetadataLoader(client util.Client) *ProviderMetadataLoader {
 	return &ProviderMetadataLoader{
@@ -119,23 +133,28 @@ func (pmdl *ProviderMetadataLoader) Enumerate(domain string) []*LoadedProviderMe
 
 	// Check direct path
 	if strings.HasPrefix(domain, "https://") {
-		return []*LoadedProviderMetadata{pmdl.loadFromURL(domain)}
+		return []*LoadedProviderMetadata{pmdl.loadFromURL(pmdl.client, domain)}
+	}
+
+	// Check local path
+	if path, ok := localProviderMetadata(domain); ok {
+		return []*LoadedProviderMetadata{pmdl.loadFromFile(path)}
 	}
 
 	// First try the well-known path.
 	wellknownURL := "https://" + domain + "/.well-known/csaf/provider-metadata.json"
 
-	wellknownResult := pmdl.loadFromURL(wellknownURL)
+	wellknownResult := pmdl.loadFromURL(pmdl.client, wellknownURL)
 
 	// Validate the candidate and add to the result array
 	if wellknownResult.Valid() {
-		slog.Debug("Found well known provider-metadata.json")
+		pmdl.logger().Debug("Found well known provider-metadata.json")
 		resPMDs = append(resPMDs, wellknownResult)
 	}
 
 	// Next load the PMDs from security.txt
 	secResults := pmdl.loadFromSecurity(domain)
-	slog.Info("Found provider metadata results in security.txt", "num", len(secResults))
+	pmdl.logger().Info("Found provider metadata results in security.txt", "num", len(secResults))
 
 	for _, result := range secResults {
 		if result.Valid() {
@@ -148,23 +167,30 @@ func (pmdl *ProviderMetadataLoader) Enumerate(domain string) []*LoadedProviderMe
 		return resPMDs
 	}
 	dnsURL := "https://csaf.data.security." + domain
-	return []*LoadedProviderMetadata{pmdl.loadFromURL(dnsURL)}
+	return []*LoadedProviderMetadata{pmdl.loadFromURL(pmdl.client, dnsURL)}
 }
 
 // Load loads one valid provider metadata for a given path.
 // If the domain starts with `https://` it only attempts to load
-// the data from that URL.
+// the data from that URL. If the domain is a `file://` URL or
+// a local path the data is loaded from the local file system.
+// For folders the provider-metadata.json in it is loaded.
 func (pmdl *ProviderMetadataLoader) Load(domain string) *LoadedProviderMetadata {
 
 	// Check direct path
 	if strings.HasPrefix(domain, "https://") {
-		return pmdl.loadFromURL(domain)
+		return pmdl.loadFromURL(pmdl.client, domain)
+	}
+
+	// Check local path
+	if path, ok := localProviderMetadata(domain); ok {
+		return pmdl.loadFromFile(path)
 	}
 
 	// First try the well-known path.
 	wellknownURL := "https://" + domain + "/.well-known/csaf/provider-metadata.json"
 
-	wellknownResult := pmdl.loadFromURL(wellknownURL)
+	wellknownResult := pmdl.loadFromURL(pmdl.client, wellknownURL)
 
 	// Valid provider metadata under well-known.
 	var wellknownGood *LoadedProviderMetadata
@@ -226,7 +252,7 @@ func (pmdl *ProviderMetadataLoader) Load(domain string) *LoadedProviderMetadata
 
 	// Last resort: fall back to DNS.
 	dnsURL := "https://csaf.data.security." + domain
-	dnsURLResult := pmdl.loadFromURL(dnsURL)
+	dnsURLResult := pmdl.loadFromURL(pmdl.client, dnsURL)
 	pmdl.messages.AppendUnique(dnsURLResult.Messages) // keep order of messages consistent (i.e. last occurred message is last element)
 	dnsURLResult.Messages = pmdl.messages
 	return dnsURLResult
@@ -273,7 +299,7 @@ func (pmdl *ProviderMetadataLoader) loadFromSecurity(domain string) []*LoadedPro
 		// Load the URLs
 	nextURL:
 		for _, url := range urls {
-			lpmd := pmdl.loadFromURL(url)
+			lpmd := pmdl.loadFromURL(pmdl.client, url)
 			// If loading failed note it down.
 			if !lpmd.Valid() {
 				pmdl.messages.AppendUnique(lpmd.Messages)
@@ -293,12 +319,46 @@ func (pmdl *ProviderMetadataLoader) loadFromSecurity(domain string) []*LoadedPro
 	return nil
 }
 
+// localProviderMetadata returns the file:// URL of the
+// provider metadata if the domain is a file:// URL or a local path.
+func localProviderMetadata(domain string) (string, bool) {
+	var path string
+	switch {
+	case util.IsFileURL(domain):
+		u, err := url.Parse(domain)
+		if err != nil {
+			// Let the loading report the invalid URL.
+			return domain, true
+		}
+		path = util.FilePath(u.Path)
+	case strings.Contains(domain, "://"):
+		return "", false
+	case strings.ContainsAny(domain, `/\`) || strings.HasPrefix(domain, "."):
+		path = domain
+	default:
+		return "", false
+	}
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		path = filepath.Join(path, "provider-metadata.json")
+	}
+	u, err := util.FileURL(path)
+	if err != nil {
+		return domain, true
+	}
+	return u, true
+}
+
+// loadFromFile loads a provider metadata from a given file:// URL.
+func (pmdl *ProviderMetadataLoader) loadFromFile(path string) *LoadedProviderMetadata {
+	return pmdl.loadFromURL(&util.FileClient{Client: pmdl.client}, path)
+}
+
 // loadFromURL loads a provider metadata from a given URL.
-func (pmdl *ProviderMetadataLoader) loadFromURL(path string) *LoadedProviderMetadata {
+func (pmdl *ProviderMetadataLoader) loadFromURL(client util.Client, path string) *LoadedProviderMetadata {
 
 	result := LoadedProviderMetadata{URL: path}
 
-	res, err := pmdl.client.Get(path)
+	res, err := client.Get(path)
 	if err != nil {
 		result.Messages.Add(
 			HTTPFailed,
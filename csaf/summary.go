@@ -9,6 +9,7 @@
 package csaf
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/gocsaf/csaf/v3/util"
@@ -39,10 +40,136 @@ type AdvisorySummary struct {
 
 // NewAdvisorySummary creates a summary from an advisory doc
 // with the help of an expression evaluator expr.
+// Use [ParseAdvisorySummary] if doc is not decoded yet.
 func NewAdvisorySummary(
 	pe *util.PathEval,
 	doc any,
 ) (*AdvisorySummary, error) {
+	// Well-formed documents take the fast path.
+	if e := fastAdvisorySummary(doc); e != nil {
+		return e, nil
+	}
+	return pathAdvisorySummary(pe, doc)
+}
+
+// ParseAdvisorySummary creates a summary from the raw JSON data
+// of an advisory. Well-formed advisories are decoded with
+// [ParseDocumentMetadata] without decoding the whole document.
+// The others are decoded completely and evaluated with the help
+// of pe to report the problem like [NewAdvisorySummary].
+func ParseAdvisorySummary(
+	pe *util.PathEval,
+	data []byte,
+) (*AdvisorySummary, error) {
+	if md, err := ParseDocumentMetadata(data); err == nil {
+		if e := metadataAdvisorySummary(md); e != nil {
+			return e, nil
+		}
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return pathAdvisorySummary(pe, doc)
+}
+
+// fastAdvisorySummary creates a summary from the [DocumentMetadata]
+// of doc. It returns nil if doc is not well-formed. In this case
+// the expressions are evaluated to report the problem.
+func fastAdvisorySummary(doc any) *AdvisorySummary {
+	md, ok := DecodeDocumentMetadata(doc)
+	if !ok {
+		return nil
+	}
+	return metadataAdvisorySummary(md)
+}
+
+// metadataAdvisorySummary creates a summary from md.
+// It returns nil if a field is missing or invalid.
+func metadataAdvisorySummary(md *DocumentMetadata) *AdvisorySummary {
+	if md.ID == "" || md.Title == "" || md.Status == "" {
+		return nil
+	}
+	initial, err := time.Parse(time.RFC3339, md.InitialReleaseDate)
+	if err != nil {
+		return nil
+	}
+	current, err := time.Parse(time.RFC3339, md.CurrentReleaseDate)
+	if err != nil {
+		return nil
+	}
+	publisher, ok := decodePublisher(md.Publisher)
+	if !ok {
+		return nil
+	}
+	return &AdvisorySummary{
+		ID:                 md.ID,
+		Title:              md.Title,
+		Publisher:          publisher,
+		InitialReleaseDate: initial,
+		CurrentReleaseDate: current,
+		Summary:            md.Summary,
+		TLPLabel:           md.TLPLabel,
+		Status:             md.Status,
+	}
+}
+
+// decodePublisher decodes a publisher object consisting of
+// strings only. It returns false for all other objects.
+func decodePublisher(m map[string]any) (*Publisher, bool) {
+	if m == nil {
+		return nil, false
+	}
+	p := new(Publisher)
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		switch k {
+		case "category":
+			category := new(Category)
+			if err := category.UnmarshalText([]byte(s)); err != nil {
+				return nil, false
+			}
+			p.Category = category
+		case "name":
+			p.Name = &s
+		case "namespace":
+			p.Namespace = &s
+		case "contact_details":
+			p.ContactDetails = s
+		case "issuing_authority":
+			p.IssuingAuthority = s
+		default:
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+// firstStringMatcher stores the first string of
+// the matched result in a string.
+func firstStringMatcher(dst *string) func(any) error {
+	return func(x any) error {
+		if list, ok := x.([]any); ok {
+			for _, y := range list {
+				if s, ok := y.(string); ok {
+					*dst = s
+					return nil
+				}
+			}
+		}
+		return util.StringMatcher(dst)(x)
+	}
+}
+
+// pathAdvisorySummary creates a summary from an advisory doc
+// by evaluating the expressions of the fields.
+func pathAdvisorySummary(
+	pe *util.PathEval,
+	doc any,
+) (*AdvisorySummary, error) {
 
 	e := &AdvisorySummary{
 		Publisher: new(Publisher),
@@ -53,7 +180,7 @@ func NewAdvisorySummary(
 		{Expr: titleExpr, Action: util.StringMatcher(&e.Title)},
 		{Expr: currentReleaseDateExpr, Action: util.TimeMatcher(&e.CurrentReleaseDate, time.RFC3339)},
 		{Expr: initialReleaseDateExpr, Action: util.TimeMatcher(&e.InitialReleaseDate, time.RFC3339)},
-		{Expr: summaryExpr, Action: util.StringMatcher(&e.Summary), Optional: true},
+		{Expr: summaryExpr, Action: firstStringMatcher(&e.Summary), Optional: true},
 		{Expr: tlpLabelExpr, Action: util.StringMatcher(&e.TLPLabel), Optional: true},
 		{Expr: publisherExpr, Action: util.ReMarshalMatcher(e.Publisher)},
 		{Expr: statusExpr, Action: util.StringMatcher(&e.Status)},
@@ -0,0 +1,179 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package csaf
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// loadAdvisories loads the raw data of the advisories
+// found in the test data.
+func loadAdvisories(tb testing.TB) [][]byte {
+	tb.Helper()
+	var advisories [][]byte
+	if err := filepath.WalkDir("../testdata", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var doc any
+		if json.Unmarshal(data, &doc) != nil {
+			return nil
+		}
+		if _, ok := DecodeDocumentMetadata(doc); ok {
+			advisories = append(advisories, data)
+		}
+		return nil
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	if len(advisories) == 0 {
+		tb.Fatal("no advisories found")
+	}
+	return advisories
+}
+
+// decodeAdvisory decodes data into a tree of maps and slices.
+func decodeAdvisory(tb testing.TB, data []byte) any {
+	tb.Helper()
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		tb.Fatal(err)
+	}
+	return doc
+}
+
+func TestNewAdvisorySummary(t *testing.T) {
+	pe := util.NewPathEval()
+	var fastPath int
+	for _, data := range loadAdvisories(t) {
+		doc := decodeAdvisory(t, data)
+		fast := fastAdvisorySummary(doc)
+		if fast == nil {
+			continue
+		}
+		fastPath++
+		want, err := pathAdvisorySummary(pe, doc)
+		if err != nil {
+			t.Fatalf("fast path accepted %q rejected by expressions: %v", fast.ID, err)
+		}
+		if !reflect.DeepEqual(fast, want) {
+			t.Errorf("fast path differs:\ngot  %+v\nwant %+v", fast, want)
+		}
+		if fast.Summary == "" {
+			t.Errorf("%s: summary missing", fast.ID)
+		}
+	}
+
+	if fastPath == 0 {
+		t.Error("no advisory took the fast path")
+	}
+
+	// Malformed documents are reported as before.
+	doc := decodeAdvisory(t, loadAdvisories(t)[0]).(map[string]any)
+	doc["document"].(map[string]any)["tracking"].(map[string]any)["status"] = 42
+	if _, err := NewAdvisorySummary(pe, doc); err == nil {
+		t.Error("malformed document accepted")
+	}
+}
+
+func TestParseAdvisorySummary(t *testing.T) {
+	pe := util.NewPathEval()
+	advisories := append(loadAdvisories(t), testutil.GenerateAdvisories(20)...)
+	for _, data := range advisories {
+		doc := decodeAdvisory(t, data)
+		md, err := ParseDocumentMetadata(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := DecodeDocumentMetadata(doc)
+		if !reflect.DeepEqual(md, want) {
+			t.Errorf("metadata differs:\ngot  %+v\nwant %+v", md, want)
+		}
+		sum, err := ParseAdvisorySummary(pe, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantSum, err := NewAdvisorySummary(pe, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sum, wantSum) {
+			t.Errorf("summary differs:\ngot  %+v\nwant %+v", sum, wantSum)
+		}
+	}
+
+	// Malformed documents are reported by the expressions.
+	malformed := []byte(`{"document":{"title":"t","tracking":{"status":42}}}`)
+	if _, err := ParseAdvisorySummary(pe, malformed); err == nil {
+		t.Error("malformed document accepted")
+	}
+	if _, err := ParseDocumentMetadata([]byte(`{"product_tree":{}}`)); err == nil {
+		t.Error("missing document object accepted")
+	}
+}
+
+// benchmarkCorpusSize is the number of generated
+// advisories the benchmarks run on.
+const benchmarkCorpusSize = 2000
+
+func BenchmarkAdvisorySummary(b *testing.B) {
+	advisories := testutil.GenerateAdvisories(benchmarkCorpusSize)
+	var size int64
+	for _, data := range advisories {
+		size += int64(len(data))
+	}
+	// All variants start with the raw data as
+	// the downloader and the aggregator do.
+	run := func(name string, summary func(*util.PathEval, []byte) error) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(size)
+			pe := util.NewPathEval()
+			for b.Loop() {
+				for _, data := range advisories {
+					if err := summary(pe, data); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+	run("parse", func(pe *util.PathEval, data []byte) error {
+		_, err := ParseAdvisorySummary(pe, data)
+		return err
+	})
+	run("decode-fast", func(pe *util.PathEval, data []byte) error {
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		_, err := NewAdvisorySummary(pe, doc)
+		return err
+	})
+	run("decode-expressions", func(pe *util.PathEval, data []byte) error {
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		_, err := pathAdvisorySummary(pe, doc)
+		return err
+	})
+}
@@ -0,0 +1,80 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package csaf
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// NormalizeTLPLabel returns a TLP label in upper case.
+// TLP:CLEAR is considered to be the same as TLP:WHITE.
+func NormalizeTLPLabel(label string) TLPLabel {
+	if label = strings.ToUpper(label); label == "CLEAR" {
+		return TLPLabelWhite
+	}
+	return TLPLabel(label)
+}
+
+// DocumentTLPLabel returns the TLP label of a CSAF document
+// in upper case with TLP:CLEAR mapped to TLP:WHITE.
+// The label is empty if the document has none.
+func DocumentTLPLabel(expr *util.PathEval, doc any) TLPLabel {
+	var label string
+	if doc == nil || expr.Extract(
+		tlpLabelExpr, util.StringMatcher(&label), true, doc,
+	) != nil || label == "" {
+		return ""
+	}
+	return NormalizeTLPLabel(label)
+}
+
+// AdvisoryTLPLabel returns the TLP label a downloaded advisory
+// is to be filed under. For advisories of ROLIE feeds this is
+// the label of the feed. For advisories of directory based
+// distributions it is the label of the document or, if it has
+// none, the label named by the folder of the distribution like
+// in `.../white/`. The label passed for their feed is ignored as
+// [AdvisoryFileProcessor.Process] passes them as TLP:WHITE. If
+// there is no label at all TLP:UNLABELED is returned and ok is false.
+func AdvisoryTLPLabel(
+	expr *util.PathEval,
+	file AdvisoryFile,
+	feed TLPLabel,
+	doc any,
+) (label TLPLabel, ok bool) {
+	if !file.IsDirectory() {
+		return feed, true
+	}
+	if label = DocumentTLPLabel(expr, doc); label != "" {
+		return label, true
+	}
+	if daf, isDaf := file.(DirectoryAdvisoryFile); isDaf && daf.label != "" {
+		return daf.label, true
+	}
+	return TLPLabelUnlabeled, false
+}
+
+// directoryTLPLabel returns the TLP label of a directory based
+// distribution if the last folder of its URL is named after
+// a TLP label like in `.../white/`. Otherwise it is empty.
+func directoryTLPLabel(directoryURL string) TLPLabel {
+	u, err := url.Parse(directoryURL)
+	if err != nil {
+		return ""
+	}
+	switch label := NormalizeTLPLabel(path.Base(u.Path)); label {
+	case TLPLabelUnlabeled, TLPLabelWhite, TLPLabelGreen, TLPLabelAmber, TLPLabelRed:
+		return label
+	}
+	return ""
+}
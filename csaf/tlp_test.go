@@ -0,0 +1,85 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package csaf
+
+import (
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestNormalizeTLPLabel(t *testing.T) {
+	for _, x := range []struct {
+		label string
+		want  TLPLabel
+	}{
+		{"white", TLPLabelWhite},
+		{"CLEAR", TLPLabelWhite},
+		{"Green", TLPLabelGreen},
+		{"RED", TLPLabelRed},
+	} {
+		if got := NormalizeTLPLabel(x.label); got != x.want {
+			t.Errorf("%s: got %q, want %q", x.label, got, x.want)
+		}
+	}
+}
+
+func TestDirectoryTLPLabel(t *testing.T) {
+	for _, x := range []struct {
+		url  string
+		want TLPLabel
+	}{
+		{"https://example.com/.well-known/csaf/white/", TLPLabelWhite},
+		{"https://example.com/.well-known/csaf/Amber", TLPLabelAmber},
+		{"https://example.com/.well-known/csaf/clear/", TLPLabelWhite},
+		{"https://example.com/.well-known/csaf/", ""},
+		{"https://example.com", ""},
+	} {
+		if got := directoryTLPLabel(x.url); got != x.want {
+			t.Errorf("%s: got %q, want %q", x.url, got, x.want)
+		}
+	}
+}
+
+func TestAdvisoryTLPLabel(t *testing.T) {
+	withLabel := func(label string) any {
+		return map[string]any{
+			"document": map[string]any{
+				"distribution": map[string]any{
+					"tlp": map[string]any{"label": label},
+				},
+			},
+		}
+	}
+	var (
+		expr      = util.NewPathEval()
+		directory = DirectoryAdvisoryFile{Path: "https://example.com/a-001.json"}
+		red       = DirectoryAdvisoryFile{Path: "https://example.com/red/a-001.json", label: TLPLabelRed}
+		rolie     = PlainAdvisoryFile{Path: "https://example.com/a-001.json"}
+	)
+	for _, x := range []struct {
+		name  string
+		file  AdvisoryFile
+		feed  TLPLabel
+		doc   any
+		want  TLPLabel
+		label bool
+	}{
+		{"rolie feed wins", rolie, TLPLabelGreen, withLabel("AMBER"), TLPLabelGreen, true},
+		{"document wins", directory, TLPLabelWhite, withLabel("AMBER"), TLPLabelAmber, true},
+		{"clear is white", directory, "", withLabel("CLEAR"), TLPLabelWhite, true},
+		{"folder fallback", red, TLPLabelWhite, map[string]any{}, TLPLabelRed, true},
+		{"no label", directory, TLPLabelWhite, map[string]any{}, TLPLabelUnlabeled, false},
+	} {
+		got, ok := AdvisoryTLPLabel(expr, x.file, x.feed, x.doc)
+		if got != x.want || ok != x.label {
+			t.Errorf("%s: got %q/%t, want %q/%t", x.name, got, ok, x.want, x.label)
+		}
+	}
+}
@@ -10,6 +10,7 @@ package csaf
 
 import (
 	"bufio"
+	"cmp"
 	"io"
 	"strings"
 )
@@ -97,3 +98,85 @@ func (pt *ProductTree) FindProductIdentificationHelpers(
 		}
 	}
 }
+
+// Compare compares two revision numbers. It returns -1 if v is
+// older than w, 1 if v is newer than w and 0 if both are equal.
+// Integer versions are compared numerically, semantic versions
+// following the precedence rules of https://semver.org/#spec-item-11 .
+// An integer version n is treated as semantic version n.0.0.
+func (v RevisionNumber) Compare(w RevisionNumber) int {
+	vCore, vPre := splitRevision(string(v))
+	wCore, wPre := splitRevision(string(w))
+	for i := range 3 {
+		if c := compareNumeric(vCore[i], wCore[i]); c != 0 {
+			return c
+		}
+	}
+	// A version without pre-release has a higher precedence.
+	switch {
+	case vPre == "" && wPre == "":
+		return 0
+	case vPre == "":
+		return 1
+	case wPre == "":
+		return -1
+	}
+	vIDs, wIDs := strings.Split(vPre, "."), strings.Split(wPre, ".")
+	for i := 0; i < len(vIDs) && i < len(wIDs); i++ {
+		vNum, wNum := isNumeric(vIDs[i]), isNumeric(wIDs[i])
+		var c int
+		switch {
+		case vNum && wNum:
+			c = compareNumeric(vIDs[i], wIDs[i])
+		case vNum:
+			c = -1
+		case wNum:
+			c = 1
+		default:
+			c = strings.Compare(vIDs[i], wIDs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(vIDs), len(wIDs))
+}
+
+// splitRevision splits a revision number into its numeric
+// core parts and its pre-release. Build metadata is dropped.
+func splitRevision(s string) ([3]string, string) {
+	if idx := strings.IndexByte(s, '+'); idx >= 0 {
+		s = s[:idx]
+	}
+	var pre string
+	if idx := strings.IndexByte(s, '-'); idx >= 0 {
+		s, pre = s[:idx], s[idx+1:]
+	}
+	core := [3]string{"0", "0", "0"}
+	for i, part := range strings.SplitN(s, ".", 3) {
+		core[i] = part
+	}
+	return core, pre
+}
+
+// isNumeric checks if s only consists of decimal digits.
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// compareNumeric compares two decimal numbers of arbitrary length
+// given as strings without leading zeros.
+func compareNumeric(a, b string) int {
+	if c := cmp.Compare(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
@@ -180,3 +180,32 @@ func TestProductTree_FindProductIdentificationHelpers(t *testing.T) {
 		})
 	}
 }
+
+func TestRevisionNumberCompare(t *testing.T) {
+	for _, x := range []struct {
+		v, w RevisionNumber
+		want int
+	}{
+		{"1", "1", 0},
+		{"1", "2", -1},
+		{"10", "9", 1},
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0", "1.0.1", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2", "1.9.9", 1},
+		{"1", "1.0.0", 0},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+		{"1.0.0+build.1", "1.0.0+build.2", 0},
+	} {
+		if got := x.v.Compare(x.w); got != x.want {
+			t.Errorf("%q.Compare(%q): got %d expected %d", x.v, x.w, got, x.want)
+		}
+		if got := x.w.Compare(x.v); got != -x.want {
+			t.Errorf("%q.Compare(%q): got %d expected %d", x.w, x.v, got, -x.want)
+		}
+	}
+}
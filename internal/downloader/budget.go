@@ -0,0 +1,86 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+// workerBudget limits the number of concurrent downloads
+// over all domains and from a single host.
+type workerBudget struct {
+	// global is nil if the number of downloads is unlimited.
+	global  chan struct{}
+	perHost int
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+// newWorkerBudget creates a new budget. Limits less
+// than one mean that there is no limit.
+func newWorkerBudget(global, perHost int) *workerBudget {
+	wb := &workerBudget{
+		perHost: perHost,
+		hosts:   map[string]chan struct{}{},
+	}
+	if global > 0 {
+		wb.global = make(chan struct{}, global)
+	}
+	return wb
+}
+
+// hostSlots returns the slots of the given host.
+// Returns nil if the downloads per host are unlimited.
+func (wb *workerBudget) hostSlots(host string) chan struct{} {
+	if wb.perHost < 1 {
+		return nil
+	}
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	slots := wb.hosts[host]
+	if slots == nil {
+		slots = make(chan struct{}, wb.perHost)
+		wb.hosts[host] = slots
+	}
+	return slots
+}
+
+// acquire waits until a download from the host of the given URL
+// is within the budget. The returned function has to be called
+// to give back the acquired slots after the download.
+func (wb *workerBudget) acquire(ctx context.Context, rawURL string) (func(), error) {
+	var host string
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Hostname()
+	}
+	var acquired []chan struct{}
+	release := func() {
+		for _, slots := range acquired {
+			<-slots
+		}
+	}
+	// The host slot is acquired first so that no global slot
+	// is blocked while waiting for a busy host.
+	for _, slots := range []chan struct{}{wb.hostSlots(host), wb.global} {
+		if slots == nil {
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+			acquired = append(acquired, slots)
+		case <-ctx.Done():
+			release()
+			return nil, context.Cause(ctx)
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,108 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerBudget(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		global, host  int
+		urls          []string
+		wantMax       int
+		wantMaxOfHost int
+	}{
+		{
+			name:          "per host",
+			global:        0,
+			host:          1,
+			urls:          []string{"https://a.example.com/1.json", "https://b.example.com/1.json"},
+			wantMax:       2,
+			wantMaxOfHost: 1,
+		},
+		{
+			name:          "global",
+			global:        1,
+			host:          0,
+			urls:          []string{"https://a.example.com/1.json", "https://b.example.com/1.json"},
+			wantMax:       1,
+			wantMaxOfHost: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			wb := newWorkerBudget(tc.global, tc.host)
+			var (
+				wg                  sync.WaitGroup
+				mu                  sync.Mutex
+				running, maxRunning int
+				perHost, maxOfHost  = map[string]int{}, 0
+				started             atomic.Int32
+			)
+			for range 4 {
+				for _, u := range tc.urls {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						release, err := wb.acquire(context.Background(), u)
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						started.Add(1)
+						mu.Lock()
+						running++
+						perHost[u]++
+						maxRunning = max(maxRunning, running)
+						maxOfHost = max(maxOfHost, perHost[u])
+						mu.Unlock()
+						time.Sleep(5 * time.Millisecond)
+						mu.Lock()
+						running--
+						perHost[u]--
+						mu.Unlock()
+						release()
+					}()
+				}
+			}
+			wg.Wait()
+			if n := int(started.Load()); n != 4*len(tc.urls) {
+				t.Fatalf("started %d downloads, want %d", n, 4*len(tc.urls))
+			}
+			if maxRunning > tc.wantMax {
+				t.Errorf("got %d concurrent downloads, want at most %d", maxRunning, tc.wantMax)
+			}
+			if maxOfHost > tc.wantMaxOfHost {
+				t.Errorf("got %d concurrent downloads from a host, want at most %d",
+					maxOfHost, tc.wantMaxOfHost)
+			}
+		})
+	}
+}
+
+func TestWorkerBudgetCancel(t *testing.T) {
+	wb := newWorkerBudget(1, 0)
+	release, err := wb.acquire(context.Background(), "https://a.example.com/1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := wb.acquire(ctx, "https://b.example.com/1.json"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
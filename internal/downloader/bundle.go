@@ -0,0 +1,327 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/storage"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+const (
+	// bundleVersion is the version of the bundle format.
+	bundleVersion = 1
+	// bundleManifestName is the name of the manifest in a bundle.
+	bundleManifestName = "manifest.json"
+	// bundleProvidersDir is the folder of the provider metadata
+	// and the public OpenPGP keys of the providers in a bundle.
+	bundleProvidersDir = "providers"
+	// bundleAdvisoriesDir is the folder of the advisories in a bundle.
+	bundleAdvisoriesDir = "advisories"
+)
+
+// bundleManifest describes the content of a bundle.
+type bundleManifest struct {
+	Version    int               `json:"version"`
+	Created    time.Time         `json:"created"`
+	Providers  []*bundleProvider `json:"providers"`
+	Advisories []*bundleAdvisory `json:"advisories"`
+	Files      []*bundleFile     `json:"files"`
+}
+
+// bundleProvider is a provider of the advisories of a bundle.
+type bundleProvider struct {
+	Domain string `json:"domain"`
+	// URL is the URL of the provider metadata.
+	URL string `json:"url"`
+	// Metadata is the name of the provider metadata in the bundle.
+	Metadata string       `json:"metadata"`
+	Keys     []*bundleKey `json:"keys,omitempty"`
+}
+
+// bundleKey is a public OpenPGP key of a provider.
+type bundleKey struct {
+	Fingerprint string `json:"fingerprint"`
+	// File is the name of the armored key in the bundle.
+	File string `json:"file"`
+}
+
+// bundleAdvisory is an advisory of a bundle.
+type bundleAdvisory struct {
+	// Name is the name of the advisory in the storage.
+	// In the bundle it is stored below the advisories folder.
+	Name   string        `json:"name"`
+	Domain string        `json:"domain"`
+	URL    string        `json:"url"`
+	TLP    csaf.TLPLabel `json:"tlp"`
+	// Fingerprint is the fingerprint of the key which
+	// made the signature of the provider if any.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// bundleFile are the hashes of a file of a bundle.
+type bundleFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	SHA512 string `json:"sha512"`
+}
+
+// newBundleFile returns the description of a file of a bundle.
+func newBundleFile(name string, data []byte) *bundleFile {
+	s256 := sha256.Sum256(data)
+	s512 := sha512.Sum512(data)
+	return &bundleFile{
+		Name:   name,
+		Size:   int64(len(data)),
+		SHA256: hex.EncodeToString(s256[:]),
+		SHA512: hex.EncodeToString(s512[:]),
+	}
+}
+
+// matches tells if the data matches the hashes.
+func (bf *bundleFile) matches(data []byte) bool {
+	other := newBundleFile(bf.Name, data)
+	return bf.Size == other.Size &&
+		strings.EqualFold(bf.SHA256, other.SHA256) &&
+		strings.EqualFold(bf.SHA512, other.SHA512)
+}
+
+// bundle writes the downloaded advisories of a run into a bundle.
+type bundle struct {
+	cfg *Config
+	// tmp is the archive written while downloading.
+	tmp string
+	arc *storage.Archive
+
+	mu         sync.Mutex
+	manifest   bundleManifest
+	providers  map[string]*bundleProvider
+	advisories map[string]int
+	files      map[string]int
+}
+
+// checkBundle checks the settings of the export and
+// the import of bundles.
+func (cfg *Config) checkBundle() error {
+	if cfg.Bundle != "" {
+		switch {
+		case cfg.BundleKey == "":
+			return errors.New("bundle needs a bundle_key to sign it")
+		case cfg.NoStore:
+			return errors.New("bundle cannot be used together with no_store")
+		case cfg.Daemon:
+			return errors.New("bundle cannot be used in daemon mode")
+		}
+		key, err := loadPrivateKey(cfg.BundleKey, cfg.BundlePassphrase)
+		if err != nil {
+			return err
+		}
+		cfg.bundleKey = key
+	}
+	if cfg.ImportBundle != "" {
+		switch {
+		case cfg.BundleKeyring == "":
+			return errors.New("importing a bundle needs a bundle_keyring to verify it")
+		case cfg.NoStore:
+			return errors.New("importing a bundle cannot be used together with no_store")
+		case cfg.Daemon:
+			return errors.New("importing a bundle cannot be used in daemon mode")
+		}
+		keys, err := loadKeyring(cfg.BundleKeyring)
+		if err != nil {
+			return err
+		}
+		cfg.bundleKeyring = keys
+	}
+	return nil
+}
+
+// newBundle starts a bundle of the advisories to download.
+// Returns nil if no bundle is configured.
+func newBundle(cfg *Config) (*bundle, error) {
+	if cfg.Bundle == "" {
+		return nil, nil
+	}
+	dir, base := filepath.Split(cfg.Bundle)
+	tmp := filepath.Join(dir, ".tmp-"+base)
+	arc, err := storage.NewTar(tmp)
+	if err != nil {
+		return nil, err
+	}
+	return &bundle{
+		cfg: cfg,
+		tmp: tmp,
+		arc: arc,
+		manifest: bundleManifest{
+			Version: bundleVersion,
+			Created: time.Now().UTC(),
+		},
+		providers:  map[string]*bundleProvider{},
+		advisories: map[string]int{},
+		files:      map[string]int{},
+	}, nil
+}
+
+// write adds a file to the bundle. Files written more
+// than once replace their former version.
+func (b *bundle) write(name string, data []byte) error {
+	if err := b.arc.WriteFile(name, data); err != nil {
+		return err
+	}
+	bf := newBundleFile(name, data)
+	if i, ok := b.files[name]; ok {
+		b.manifest.Files[i] = bf
+	} else {
+		b.files[name] = len(b.manifest.Files)
+		b.manifest.Files = append(b.manifest.Files, bf)
+	}
+	return nil
+}
+
+// provider adds the provider metadata and the public
+// OpenPGP keys of a provider to the bundle.
+func (b *bundle) provider(domain string, lpmd *csaf.LoadedProviderMetadata, keys *crypto.KeyRing) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.providers[domain] != nil {
+		return nil
+	}
+	dir := path.Join(bundleProvidersDir,
+		strings.TrimSuffix(util.CleanFileName(domain), ".json"))
+	data, err := json.MarshalIndent(lpmd.Document, "", "  ")
+	if err != nil {
+		return err
+	}
+	bp := &bundleProvider{
+		Domain:   domain,
+		URL:      lpmd.URL,
+		Metadata: path.Join(dir, "provider-metadata.json"),
+	}
+	if err := b.write(bp.Metadata, data); err != nil {
+		return err
+	}
+	if keys != nil {
+		for _, key := range keys.GetKeys() {
+			armored, err := key.GetArmoredPublicKey()
+			if err != nil {
+				return err
+			}
+			fp := strings.ToUpper(key.GetFingerprint())
+			bk := &bundleKey{
+				Fingerprint: fp,
+				File:        path.Join(dir, "openpgp", fp+".asc"),
+			}
+			if err := b.write(bk.File, []byte(armored)); err != nil {
+				return err
+			}
+			bp.Keys = append(bp.Keys, bk)
+		}
+	}
+	b.providers[domain] = bp
+	b.manifest.Providers = append(b.manifest.Providers, bp)
+	return nil
+}
+
+// advisory adds a stored advisory with its hashes
+// and its signature to the bundle.
+func (b *bundle) advisory(ba *bundleAdvisory, data, s256, s512, sign []byte) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	name := path.Join(bundleAdvisoriesDir, ba.Name)
+	for _, x := range []struct {
+		n string
+		d []byte
+	}{
+		{name, data},
+		{name + ".sha256", s256},
+		{name + ".sha512", s512},
+		{name + ".asc", sign},
+	} {
+		if x.d != nil {
+			if err := b.write(x.n, x.d); err != nil {
+				return err
+			}
+		}
+	}
+	if i, ok := b.advisories[ba.Name]; ok {
+		b.manifest.Advisories[i] = ba
+	} else {
+		b.advisories[ba.Name] = len(b.manifest.Advisories)
+		b.manifest.Advisories = append(b.manifest.Advisories, ba)
+	}
+	return nil
+}
+
+// finish writes the signed manifest and moves the
+// completed bundle to its configured location.
+func (b *bundle) finish() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := func() error {
+		data, err := json.MarshalIndent(&b.manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		sig, err := signDetached(b.cfg.bundleKey, data)
+		if err != nil {
+			return err
+		}
+		if err := b.arc.WriteFile(bundleManifestName, data); err != nil {
+			return err
+		}
+		if err := b.arc.WriteFile(bundleManifestName+".asc", []byte(sig)); err != nil {
+			return err
+		}
+		if err := b.arc.Close(); err != nil {
+			return err
+		}
+		return os.Rename(b.tmp, b.cfg.Bundle)
+	}(); err != nil {
+		b.abort()
+		return err
+	}
+	b.cfg.logger().Info("Bundle written",
+		"path", b.cfg.Bundle,
+		"advisories", len(b.manifest.Advisories),
+		"providers", len(b.manifest.Providers))
+	return nil
+}
+
+// abort removes an unfinished bundle.
+func (b *bundle) abort() {
+	if b == nil {
+		return
+	}
+	b.arc.Close()
+	os.Remove(b.tmp)
+}
@@ -0,0 +1,193 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+const (
+	testPrivateKey = "../../testdata/simple-rolie-provider/openpgp/privkey.asc"
+	testPublicKey  = "../../testdata/simple-rolie-provider/openpgp/pubkey.asc"
+)
+
+func TestCheckBundle(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		fail bool
+	}{
+		{"disabled", Config{}, false},
+		{"export", Config{Bundle: "b.tar", BundleKey: testPrivateKey}, false},
+		{"export without key", Config{Bundle: "b.tar"}, true},
+		{"export no store", Config{Bundle: "b.tar", BundleKey: testPrivateKey, NoStore: true}, true},
+		{"export daemon", Config{Bundle: "b.tar", BundleKey: testPrivateKey, Daemon: true}, true},
+		{"import", Config{ImportBundle: "b.tar", BundleKeyring: testPublicKey}, false},
+		{"import without keyring", Config{ImportBundle: "b.tar"}, true},
+		{"import no store", Config{ImportBundle: "b.tar", BundleKeyring: testPublicKey, NoStore: true}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			if err := cfg.checkBundle(); (err != nil) != tc.fail {
+				t.Errorf("unexpected result: %v", err)
+			}
+		})
+	}
+}
+
+// rewriteBundle copies a bundle and lets change modify its files.
+func rewriteBundle(t *testing.T, src, dst string, change func(name string, data []byte) []byte) {
+	t.Helper()
+	in, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	var buf bytes.Buffer
+	tr, tw := tar.NewReader(in), tar.NewWriter(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = change(hdr.Name, data)
+		hdr.Size = int64(len(data))
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client := util.Client(server.Client())
+	dir := t.TempDir()
+	bundleFile := filepath.Join(dir, "bundle.tar")
+
+	// Export the downloaded advisories.
+	cfg := Config{
+		LogLevel:  &options.LogLevel{Level: slog.LevelError},
+		Directory: filepath.Join(dir, "download"),
+		Bundle:    bundleFile,
+		BundleKey: testPrivateKey,
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+	d, err := NewDownloader(&cfg)
+	if err != nil {
+		t.Fatalf("could not init downloader: %v", err)
+	}
+	d.client = &client
+	if err := d.Run(context.Background(), []string{server.URL + "/provider-metadata.json"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	d.Close()
+
+	importBundle := func(fname, target string) error {
+		cfg := Config{
+			LogLevel:      &options.LogLevel{Level: slog.LevelError},
+			Directory:     target,
+			ImportBundle:  fname,
+			BundleKeyring: testPublicKey,
+		}
+		if err := cfg.Prepare(); err != nil {
+			t.Fatalf("config failed: %v", err)
+		}
+		return ImportBundle(&cfg)
+	}
+
+	// Import it on the other side.
+	imported := filepath.Join(dir, "imported")
+	if err := importBundle(bundleFile, imported); err != nil {
+		t.Fatalf("importing bundle failed: %v", err)
+	}
+	for _, name := range []string{
+		"white/2020/avendor-advisory-0004.json",
+		"white/2020/avendor-advisory-0004.json.sha256",
+		"white/2020/avendor-advisory-0004.json.sha512",
+		"white/2020/avendor-advisory-0004.json.asc",
+	} {
+		want, err := os.ReadFile(filepath.Join(cfg.Directory, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(imported, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("missing %s: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from the exported one", name)
+		}
+	}
+
+	// Tampered bundles are refused without storing anything.
+	for _, tc := range []struct {
+		name   string
+		change func(string, []byte) []byte
+	}{
+		{"manifest", func(name string, data []byte) []byte {
+			if name == bundleManifestName {
+				return bytes.Replace(data, []byte(`"version": 1`), []byte(`"version": 1 `), 1)
+			}
+			return data
+		}},
+		{"advisory", func(name string, data []byte) []byte {
+			if name == bundleAdvisoriesDir+"/white/2020/avendor-advisory-0004.json" {
+				return bytes.Replace(data, []byte("ACME"), []byte("EMCA"), 1)
+			}
+			return data
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tampered := filepath.Join(dir, "tampered-"+tc.name+".tar")
+			rewriteBundle(t, bundleFile, tampered, tc.change)
+			target := filepath.Join(dir, "tampered-"+tc.name)
+			if err := importBundle(tampered, target); err == nil {
+				t.Error("importing tampered bundle succeeded")
+			}
+			if checkIfFileExists(filepath.Join(target, "white", "2020", "avendor-advisory-0004.json"), t) {
+				t.Error("advisory of tampered bundle was stored")
+			}
+		})
+	}
+}
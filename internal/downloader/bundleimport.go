@@ -0,0 +1,364 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/misc"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// ImportBundle verifies a bundle exported by a downloader and
+// stores its advisories. The signature of the manifest, the hashes
+// of all files and the hashes and signatures of the advisories are
+// checked first. Nothing is stored if any of the checks fails.
+// The provider tree is written afterwards if configured.
+func ImportBundle(cfg *Config) error {
+	dir, err := os.MkdirTemp("", "csaf_bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := extractBundle(cfg.ImportBundle, dir); err != nil {
+		return fmt.Errorf("extracting bundle failed: %w", err)
+	}
+	manifest, err := loadBundleManifest(cfg, dir)
+	if err != nil {
+		return err
+	}
+	if err := checkBundleFiles(dir, manifest); err != nil {
+		return err
+	}
+	if err := checkBundleAdvisories(cfg, dir, manifest); err != nil {
+		return err
+	}
+
+	st, err := cfg.openStorage()
+	if err != nil {
+		return err
+	}
+	for _, ba := range manifest.Advisories {
+		src := filepath.Join(dir, bundleAdvisoriesDir, filepath.FromSlash(ba.Name))
+		for _, ext := range sidecarExts {
+			data, err := os.ReadFile(src + ext)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err == nil {
+				err = st.WriteFile(ba.Name+ext, data)
+			}
+			if err != nil {
+				st.Close()
+				return err
+			}
+		}
+	}
+	if err := st.Close(); err != nil {
+		return err
+	}
+	cfg.logger().Info("Bundle imported",
+		"path", cfg.ImportBundle,
+		"created", manifest.Created,
+		"advisories", len(manifest.Advisories))
+
+	if cfg.ProviderTree != "" {
+		return WriteProviderTree(cfg)
+	}
+	return nil
+}
+
+// extractBundle extracts the files of a bundle into dir.
+func extractBundle(fname, dir string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, err := r.(*bufio.Reader).Peek(2); err == nil &&
+		magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return fmt.Errorf("unexpected entry %q in bundle", hdr.Name)
+		}
+		if !fs.ValidPath(hdr.Name) {
+			return fmt.Errorf("invalid name %q in bundle", hdr.Name)
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		out, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		_, err1 := io.Copy(out, tr)
+		err2 := out.Close()
+		if err := errors.Join(err1, err2); err != nil {
+			return err
+		}
+	}
+}
+
+// loadBundleManifest loads the manifest of an extracted bundle
+// and verifies its signature with the trusted keys.
+func loadBundleManifest(cfg *Config, dir string) (*bundleManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, bundleManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("loading bundle manifest failed: %w", err)
+	}
+	armored, err := os.ReadFile(filepath.Join(dir, bundleManifestName+".asc"))
+	if err != nil {
+		return nil, fmt.Errorf("loading signature of bundle manifest failed: %w", err)
+	}
+	sign, err := crypto.NewPGPSignatureFromArmored(string(armored))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature of bundle manifest: %w", err)
+	}
+	fp, err := verifyDetached(cfg.bundleKeyring, data, sign)
+	if err != nil {
+		return nil, fmt.Errorf("verifying bundle manifest failed: %w", err)
+	}
+	var manifest bundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	if manifest.Version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", manifest.Version)
+	}
+	cfg.logger().Debug("Bundle manifest verified", "fingerprint", fp)
+	return &manifest, nil
+}
+
+// verifyDetached verifies a detached signature with the given keys.
+// Returns the fingerprint of the key which made the signature.
+func verifyDetached(keys []*crypto.Key, data []byte, sign *crypto.PGPSignature) (string, error) {
+	pc := providerContext{}
+	for _, key := range keys {
+		kr, err := crypto.NewKeyRing(key)
+		if err != nil {
+			return "", err
+		}
+		if pc.keys == nil {
+			pc.keys = kr
+		} else if err := pc.keys.AddKey(key); err != nil {
+			return "", err
+		}
+	}
+	if pc.keys == nil {
+		return "", errors.New("no keys to verify signature")
+	}
+	return pc.checkSignature(data, sign)
+}
+
+// checkBundleFiles checks that the extracted files are the ones
+// listed in the manifest and that their hashes match.
+func checkBundleFiles(dir string, manifest *bundleManifest) error {
+	listed := util.Set[string]{
+		bundleManifestName:          {},
+		bundleManifestName + ".asc": {},
+	}
+	for _, bf := range manifest.Files {
+		if !fs.ValidPath(bf.Name) {
+			return fmt.Errorf("invalid name %q in bundle manifest", bf.Name)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(bf.Name)))
+		if err != nil {
+			return fmt.Errorf("file of bundle manifest missing: %w", err)
+		}
+		if !bf.matches(data) {
+			return fmt.Errorf("hashes of %q do not match the bundle manifest", bf.Name)
+		}
+		listed.Add(bf.Name)
+	}
+	return filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); !listed.Contains(name) {
+			return fmt.Errorf("file %q is not listed in the bundle manifest", name)
+		}
+		return nil
+	})
+}
+
+// bundleProviderContext sets up the keys to verify the
+// signatures of the advisories of a provider of a bundle.
+// A configured keyring replaces the keys of the provider.
+func bundleProviderContext(cfg *Config, dir string, bp *bundleProvider) (*providerContext, error) {
+	pc := &providerContext{
+		cfg:    cfg.forDomain(bp.Domain),
+		domain: bp.Domain,
+	}
+	if err := pc.pinKeys(nil); err != nil {
+		return nil, err
+	}
+	if pc.cfg.keyring != nil {
+		for _, key := range pc.cfg.keyring {
+			pc.addKey(key, pc.cfg.Keyring)
+		}
+		return pc, nil
+	}
+	for _, bk := range bp.Keys {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(bk.File)))
+		if err != nil {
+			return nil, err
+		}
+		key, err := crypto.NewKeyFromArmored(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q in bundle: %w", bk.File, err)
+		}
+		if fp := strings.ToUpper(key.GetFingerprint()); fp != strings.ToUpper(bk.Fingerprint) {
+			return nil, fmt.Errorf("fingerprint of key %q does not match", bk.File)
+		}
+		pc.addKey(key, bk.File)
+	}
+	return pc, nil
+}
+
+// checkBundleAdvisories checks the advisories of an extracted bundle
+// against the schema, their hashes and their signatures.
+func checkBundleAdvisories(cfg *Config, dir string, manifest *bundleManifest) error {
+	pcs := map[string]*providerContext{}
+	for _, bp := range manifest.Providers {
+		pc, err := bundleProviderContext(cfg, dir, bp)
+		if err != nil {
+			return fmt.Errorf("loading keys of %q failed: %w", bp.Domain, err)
+		}
+		pcs[bp.Domain] = pc
+	}
+
+	var failed int
+	for _, ba := range manifest.Advisories {
+		if err := checkBundleAdvisory(dir, pcs[ba.Domain], ba); err != nil {
+			cfg.logger().Error("Checking advisory of bundle failed",
+				"name", ba.Name,
+				"url", ba.URL,
+				"error", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d advisories of the bundle failed the checks", failed)
+	}
+	return nil
+}
+
+// checkBundleAdvisory checks an advisory of an extracted bundle.
+func checkBundleAdvisory(dir string, pc *providerContext, ba *bundleAdvisory) error {
+	if pc == nil {
+		return fmt.Errorf("unknown provider %q", ba.Domain)
+	}
+	if !fs.ValidPath(ba.Name) || !util.ConformingFileName(path.Base(ba.Name)) {
+		return errors.New("invalid name")
+	}
+	fname := filepath.Join(dir, bundleAdvisoriesDir, filepath.FromSlash(ba.Name))
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+
+	var doc any
+	if err := misc.StrictJSONParse(bytes.NewReader(data), &doc); err != nil {
+		return err
+	}
+	msgs, err := csaf.ValidateCSAF(doc)
+	if err != nil {
+		return err
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("schema validation failed: %s", strings.Join(msgs, ", "))
+	}
+
+	s256 := sha256.Sum256(data)
+	s512 := sha512.Sum512(data)
+	for _, x := range []struct {
+		ext  string
+		hash []byte
+	}{
+		{".sha256", s256[:]},
+		{".sha512", s512[:]},
+	} {
+		content, err := readOptional(fname + x.ext)
+		if err != nil {
+			return err
+		}
+		if content != "" && !strings.EqualFold(hashValue(content), hex.EncodeToString(x.hash)) {
+			return fmt.Errorf("%s checksum does not match", strings.TrimPrefix(x.ext, "."))
+		}
+	}
+
+	if pc.hashesOnly() {
+		return nil
+	}
+	armored, err := readOptional(fname + ".asc")
+	switch {
+	case err != nil:
+		return err
+	case armored == "":
+		if pc.signatureRequired() {
+			return errors.New("signature missing")
+		}
+		return nil
+	case pc.keys == nil:
+		if pc.needsKeys() {
+			return errors.New("no trusted OpenPGP keys to verify the signature")
+		}
+		return nil
+	}
+	sign, err := crypto.NewPGPSignatureFromArmored(armored)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if _, err := pc.checkSignature(data, sign); err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,837 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2022 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2022 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/certs"
+	"github.com/gocsaf/csaf/v3/internal/filter"
+	"github.com/gocsaf/csaf/v3/internal/storage"
+	"github.com/gocsaf/csaf/v3/pkg/models"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+const (
+	defaultWorker         = 2
+	defaultParallel       = 4
+	defaultInterval       = time.Hour
+	defaultPreset         = "mandatory"
+	defaultForwardQueue   = 5
+	defaultValidationMode = ValidationStrict
+	defaultSignature      = SignatureOptional
+	defaultRemovedMode    = RemovedIgnore
+	defaultStorage        = StorageDir
+	defaultDistribution   = DistributionBoth
+	defaultLogFile        = "downloader.log"
+	defaultLogLevel       = slog.LevelInfo
+)
+
+type ValidationMode string
+
+const (
+	ValidationStrict = ValidationMode("strict")
+	ValidationUnsafe = ValidationMode("unsafe")
+)
+
+// SignaturePolicy is the policy how the integrity
+// of the advisories has to be proven.
+type SignaturePolicy string
+
+const (
+	// SignatureOptional checks the signatures if the provider has keys.
+	SignatureOptional = SignaturePolicy("optional")
+	// SignatureRequired requires a valid signature for every advisory.
+	SignatureRequired = SignaturePolicy("required")
+	// SignatureHashes requires a valid hash and ignores the signatures.
+	SignatureHashes = SignaturePolicy("hashes")
+)
+
+// RemovedMode is the mode how advisories are handled which
+// were removed from the feeds of their provider.
+type RemovedMode string
+
+const (
+	RemovedIgnore = RemovedMode("ignore")
+	RemovedReport = RemovedMode("report")
+	RemovedMove   = RemovedMode("move")
+	RemovedDelete = RemovedMode("delete")
+)
+
+// StorageKind is the kind of storage the downloaded files are stored in.
+type StorageKind string
+
+const (
+	StorageDir = StorageKind("dir")
+	StorageTar = StorageKind("tar")
+	StorageZip = StorageKind("zip")
+	StorageS3  = StorageKind("s3")
+)
+
+// Distribution is the kind of distribution of a provider tree.
+type Distribution string
+
+const (
+	// DistributionROLIE writes ROLIE feeds.
+	DistributionROLIE = Distribution("rolie")
+	// DistributionDirectory writes index.txt and changes.csv.
+	DistributionDirectory = Distribution("directory")
+	// DistributionBoth writes both.
+	DistributionBoth = Distribution("both")
+)
+
+type hashAlgorithm string
+
+const (
+	algSha256 = hashAlgorithm("sha256")
+	algSha512 = hashAlgorithm("sha512")
+)
+
+type Config struct {
+	Directory            string            `short:"d" long:"directory" description:"DIRectory to store the downloaded files in" value-name:"DIR" toml:"directory"`
+	Insecure             bool              `long:"insecure" description:"Do not check TLS certificates from provider" toml:"insecure"`
+	IgnoreSignatureCheck bool              `long:"ignore_sigcheck" description:"Ignore signature check results, just warn on mismatch" toml:"ignore_sigcheck"`
+	TrustedKeys          []string          `long:"trusted_key" description:"Only trust the public OpenPGP keys with the given FINGERPRINTs" value-name:"FINGERPRINT" toml:"trusted_keys"`
+	Keyring              string            `long:"keyring" description:"FILE of public OpenPGP keys to use instead of the ones of the providers" value-name:"FILE" toml:"keyring"`
+	ClientCert           *string           `long:"client_cert" description:"TLS client certificate file (PEM encoded data)" value-name:"CERT-FILE" toml:"client_cert"`
+	ClientKey            *string           `long:"client_key" description:"TLS client private key file (PEM encoded data)" value-name:"KEY-FILE" toml:"client_key"`
+	ClientPassphrase     *string           `long:"client_passphrase" description:"Optional passphrase for the client cert (limited, experimental, see doc)" value-name:"PASSPHRASE" toml:"client_passphrase"`
+	Version              bool              `long:"version" description:"Display version of the binary" toml:"-"`
+	NoStore              bool              `long:"no_store" short:"n" description:"Do not store files" toml:"no_store"`
+	Rate                 *float64          `long:"rate" short:"r" description:"The average upper limit of https operations per second (defaults to unlimited)" toml:"rate"`
+	Worker               int               `long:"worker" short:"w" description:"NUMber of concurrent downloads" value-name:"NUM" toml:"worker"`
+	Parallel             int               `long:"parallel" description:"NUMber of domains processed concurrently" value-name:"NUM" toml:"parallel"`
+	MaxWorker            int               `long:"max_worker" description:"Maximal NUMber of concurrent downloads over all domains (0 means unlimited)" value-name:"NUM" toml:"max_worker"`
+	HostWorker           int               `long:"host_worker" description:"Maximal NUMber of concurrent downloads from a single host (0 means unlimited)" value-name:"NUM" toml:"host_worker"`
+	Range                *models.TimeRange `long:"time_range" short:"t" description:"RANGE of time from which advisories to download" value-name:"RANGE" toml:"time_range"`
+	Folder               string            `long:"folder" short:"f" description:"Download into a given subFOLDER" value-name:"FOLDER" toml:"folder"`
+	PathTemplate         string            `long:"path_template" description:"TEMPLATE of the folders to download into" value-name:"TEMPLATE" toml:"path_template"`
+	IgnorePattern        []string          `long:"ignore_pattern" short:"i" description:"Do not download files if their URLs match any of the given PATTERNs" value-name:"PATTERN" toml:"ignore_pattern"`
+	Include              []string          `long:"include" description:"Only keep advisories matching any of the given FILTERs" value-name:"FILTER" toml:"include"`
+	Exclude              []string          `long:"exclude" description:"Do not keep advisories matching any of the given FILTERs" value-name:"FILTER" toml:"exclude"`
+	MinSeverity          string            `long:"min_severity" description:"Only keep advisories with a CVSS base score of at least SEVERITY" value-name:"SEVERITY" toml:"min_severity"`
+	ExtraHeader          http.Header       `long:"header" short:"H" description:"One or more extra HTTP header fields" toml:"header"`
+	KeepRevisions        bool              `long:"revisions" description:"Keep every distinct revision of the advisories" toml:"revisions"`
+	Report               string            `long:"report" description:"FILE to write a JSON report of the run to" value-name:"FILE" toml:"report"`
+	MetricsFile          string            `long:"metrics_file" description:"FILE to write metrics in the Prometheus text format to" value-name:"FILE" toml:"metrics_file"`
+	Quarantine           bool              `long:"quarantine" description:"Keep advisories failing the checks with the reasons in the quarantine folder" toml:"quarantine"`
+	Resume               bool              `long:"resume" description:"Resume an interrupted run skipping the completed advisories" toml:"resume"`
+	Daemon               bool              `long:"daemon" description:"Keep running and download the domains periodically" toml:"daemon"`
+	Interval             time.Duration     `long:"interval" description:"Default INTERVAL between two downloads of a domain in daemon mode" value-name:"INTERVAL" toml:"interval"`
+	Listen               string            `long:"listen" description:"ADDRESS of the health HTTP endpoint in daemon mode" value-name:"ADDRESS" toml:"listen"`
+	Hook                 string            `long:"hook" description:"COMMAND to run for each stored advisory" value-name:"COMMAND" toml:"hook"`
+	BatchHook            string            `long:"batch_hook" description:"COMMAND to run with the changed advisories at the end of a run" value-name:"COMMAND" toml:"batch_hook"`
+	HookWorker           int               `long:"hook_worker" description:"Maximal NUMber of concurrently running hooks" value-name:"NUM" toml:"hook_worker"`
+	HookTimeout          time.Duration     `long:"hook_timeout" description:"Maximal DURATION of a hook run" value-name:"DURATION" toml:"hook_timeout"`
+	Aggregator           string            `long:"aggregator" description:"URL or FILE of an aggregator.json to fall back to the mirrors of the providers" value-name:"URL|FILE" toml:"aggregator"`
+
+	EnumeratePMDOnly bool `long:"enumerate_pmd_only" description:"If this flag is set to true, the downloader will only enumerate valid provider metadata files, but not download documents" toml:"enumerate_pmd_only"`
+
+	RemoteValidator        string   `long:"validator" description:"URL to validate documents remotely" value-name:"URL" toml:"validator"`
+	RemoteValidatorCache   string   `long:"validator_cache" description:"FILE to cache remote validations" value-name:"FILE" toml:"validator_cache"`
+	RemoteValidatorPresets []string `long:"validator_preset" description:"One or more PRESETS to validate remotely" value-name:"PRESETS" toml:"validator_preset"`
+
+	//lint:ignore SA5008 We are using choice twice: strict, unsafe.
+	ValidationMode ValidationMode `long:"validation_mode" short:"m" choice:"strict" choice:"unsafe" value-name:"MODE" description:"MODE how strict the validation is" toml:"validation_mode"`
+
+	//lint:ignore SA5008 We are using choice more than once: optional, required, hashes
+	SignaturePolicy SignaturePolicy `long:"signature_policy" choice:"optional" choice:"required" choice:"hashes" value-name:"POLICY" description:"POLICY how the integrity of the advisories has to be proven" toml:"signature_policy"`
+
+	//lint:ignore SA5008 We are using choice more than once: dir, tar, zip, s3
+	Storage     StorageKind `long:"storage" choice:"dir" choice:"tar" choice:"zip" choice:"s3" value-name:"KIND" description:"KIND of storage to store the downloaded files in" toml:"storage"`
+	Archive     string      `long:"archive" description:"FILE of the tar or zip archive to store the downloaded files in" value-name:"FILE" toml:"archive"`
+	S3Endpoint  string      `long:"s3_endpoint" description:"URL of the S3 compatible object storage" value-name:"URL" toml:"s3_endpoint"`
+	S3Bucket    string      `long:"s3_bucket" description:"BUCKET of the S3 compatible object storage" value-name:"BUCKET" toml:"s3_bucket"`
+	S3Prefix    string      `long:"s3_prefix" description:"PREFIX of the object keys in the S3 compatible object storage" value-name:"PREFIX" toml:"s3_prefix"`
+	S3Region    string      `long:"s3_region" description:"REGION of the S3 compatible object storage" value-name:"REGION" toml:"s3_region"`
+	S3AccessKey string      `long:"s3_access_key" description:"KEY ID to access the S3 compatible object storage" value-name:"KEY" toml:"s3_access_key"`
+	S3SecretKey string      `long:"s3_secret_key" description:"SECRET key to access the S3 compatible object storage" value-name:"SECRET" toml:"s3_secret_key"`
+
+	//lint:ignore SA5008 We are using choice more than once: ignore, report, move, delete
+	RemovedMode RemovedMode `long:"removed" choice:"ignore" choice:"report" choice:"move" choice:"delete" value-name:"MODE" description:"MODE how to handle stored advisories no longer listed by their provider" toml:"removed"`
+
+	ProviderTree string `long:"provider_tree" description:"DIRectory to write a CSAF provider tree of the downloaded advisories to" value-name:"DIR" toml:"provider_tree"`
+	ProviderURL  string `long:"provider_url" description:"Base URL the provider tree is served under" value-name:"URL" toml:"provider_url"`
+	//lint:ignore SA5008 We are using choice more than once: rolie, directory, both
+	ProviderDistribution Distribution `long:"provider_distribution" choice:"rolie" choice:"directory" choice:"both" value-name:"KIND" description:"KIND of distribution of the provider tree" toml:"provider_distribution"`
+	ProviderKey          string       `long:"provider_key" description:"OpenPGP private KEY-FILE to sign the advisories without signature in the provider tree" value-name:"KEY-FILE" toml:"provider_key"`
+	ProviderPassphrase   *string      `long:"provider_passphrase" description:"PASSPHRASE of the OpenPGP private key of the provider tree" value-name:"PASSPHRASE" toml:"provider_passphrase"`
+
+	// ProviderPublisher is the publisher of the provider tree.
+	ProviderPublisher *csaf.Publisher `no-flag:"true" toml:"provider_publisher"`
+
+	Bundle           string  `long:"bundle" description:"FILE to write a signed bundle of the downloaded advisories to" value-name:"FILE" toml:"bundle"`
+	BundleKey        string  `long:"bundle_key" description:"OpenPGP private KEY-FILE to sign the bundle with" value-name:"KEY-FILE" toml:"bundle_key"`
+	BundlePassphrase *string `long:"bundle_passphrase" description:"PASSPHRASE of the OpenPGP private key of the bundle" value-name:"PASSPHRASE" toml:"bundle_passphrase"`
+	BundleKeyring    string  `long:"bundle_keyring" description:"FILE of public OpenPGP keys trusted to sign imported bundles" value-name:"FILE" toml:"bundle_keyring"`
+	ImportBundle     string  `long:"import_bundle" description:"Only import the advisories of the bundle FILE and exit" value-name:"FILE" toml:"-"`
+
+	ForwardURL      string      `long:"forward_url" description:"URL of HTTP endpoint to forward downloads to" value-name:"URL" toml:"forward_url"`
+	ForwardHeader   http.Header `long:"forward_header" description:"One or more extra HTTP header fields used by forwarding" toml:"forward_header"`
+	ForwardQueue    int         `long:"forward_queue" description:"Maximal queue LENGTH before forwarder" value-name:"LENGTH" toml:"forward_queue"`
+	ForwardInsecure bool        `long:"forward_insecure" description:"Do not check TLS certificates from forward endpoint" toml:"forward_insecure"`
+	ForwardReplay   bool        `long:"forward_replay" description:"Only forward the advisories which failed forwarding before and exit" toml:"-"`
+
+	// ForwardTargets are further HTTP endpoints to forward to.
+	ForwardTargets []*ForwardTarget `toml:"forward_targets"`
+
+	LogFile *string `long:"log_file" description:"FILE to log downloading to" value-name:"FILE" toml:"log_file"`
+	//lint:ignore SA5008 We are using choice or than once: debug, info, warn, error
+	LogLevel *options.LogLevel `long:"log_level" description:"LEVEL of logging details" value-name:"LEVEL" choice:"debug" choice:"info" choice:"warn" choice:"error" toml:"log_level"`
+
+	Config string `short:"c" long:"config" description:"Path to config TOML file" value-name:"TOML-FILE" toml:"-"`
+
+	// Domains are the domain specific settings.
+	Domains []*DomainConfig `toml:"domains"`
+
+	ClientCerts   []tls.Certificate
+	ignorePattern filter.PatternMatcher
+	pathTemplate  pathTemplate
+	docFilter     *filter.DocumentFilter
+	// forwardTargets are all the forward targets.
+	forwardTargets []*ForwardTarget
+	// hookCmd and batchHookCmd are the split hook commands.
+	hookCmd      []string
+	batchHookCmd []string
+	keyring      []*crypto.Key
+	// providerKey is the key to sign the advisories
+	// of the provider tree with.
+	providerKey *crypto.Key
+	// bundleKey is the key to sign bundles with and
+	// bundleKeyring are the keys to verify imported bundles.
+	bundleKey     *crypto.Key
+	bundleKeyring []*crypto.Key
+	//lint:ignore SA5008 We are using choice or than once: sha256, sha512
+	PreferredHash hashAlgorithm `long:"preferred_hash" choice:"sha256" choice:"sha512" value-name:"HASH" description:"HASH to prefer" toml:"preferred_hash"`
+
+	ForwardChannel bool // forward the csafs via a channel (is not meant to be set via command line)
+
+	// Logger is used for logging instead of a logger set up
+	// from the logging options. It is not meant to be set via
+	// command line.
+	Logger *slog.Logger `no-flag:"true" toml:"-"`
+}
+
+// DomainConfig holds the settings of a domain
+// which override the global ones.
+type DomainConfig struct {
+	Domain string `toml:"domain"`
+	// Rate gives the domain specific rate limiting (see overall Rate).
+	Rate   *float64 `toml:"rate"`
+	Worker *int     `toml:"worker"`
+
+	// ExtraHeader replaces the global extra HTTP header fields.
+	ExtraHeader http.Header `toml:"header"`
+
+	ClientCert       *string `toml:"client_cert"`
+	ClientKey        *string `toml:"client_key"`
+	ClientPassphrase *string `toml:"client_passphrase"`
+
+	// IgnorePattern is a list of patterns of advisory URLs to be
+	// ignored in addition to the global ones.
+	IgnorePattern []string `toml:"ignore_pattern"`
+
+	Range          *models.TimeRange `toml:"time_range"`
+	ValidationMode *ValidationMode   `toml:"validation_mode"`
+	Folder         *string           `toml:"folder"`
+
+	// TrustedKeys and Keyring replace the global ones.
+	TrustedKeys     []string         `toml:"trusted_keys"`
+	Keyring         *string          `toml:"keyring"`
+	SignaturePolicy *SignaturePolicy `toml:"signature_policy"`
+
+	// Interval replaces the update interval of the
+	// provider and the global one in daemon mode.
+	Interval *time.Duration `toml:"interval"`
+
+	clientCerts   []tls.Certificate
+	ignorePattern filter.PatternMatcher
+	keyring       []*crypto.Key
+}
+
+// configPaths are the potential file locations of the config file.
+var configPaths = []string{
+	"~/.config/csaf/downloader.toml",
+	"~/.csaf_downloader.toml",
+	"csaf_downloader.toml",
+}
+
+// ParseArgsConfig parses the command line and if need a config file.
+func ParseArgsConfig() ([]string, *Config, error) {
+	var (
+		logFile  = defaultLogFile
+		logLevel = &options.LogLevel{Level: defaultLogLevel}
+	)
+	p := options.Parser[Config]{
+		DefaultConfigLocations: configPaths,
+		ConfigLocation:         func(cfg *Config) string { return cfg.Config },
+		Usage:                  "[OPTIONS] domain...",
+		HasVersion:             func(cfg *Config) bool { return cfg.Version },
+		SetDefaults: func(cfg *Config) {
+			cfg.Worker = defaultWorker
+			cfg.Parallel = defaultParallel
+			cfg.Interval = defaultInterval
+			cfg.RemoteValidatorPresets = []string{defaultPreset}
+			cfg.ValidationMode = defaultValidationMode
+			cfg.SignaturePolicy = defaultSignature
+			cfg.RemovedMode = defaultRemovedMode
+			cfg.Storage = defaultStorage
+			cfg.ProviderDistribution = defaultDistribution
+			cfg.ForwardQueue = defaultForwardQueue
+			cfg.HookWorker = defaultHookWorker
+			cfg.HookTimeout = defaultHookTimeout
+			cfg.LogFile = &logFile
+			cfg.LogLevel = logLevel
+		},
+		// Re-establish default values if not set.
+		EnsureDefaults: func(cfg *Config) {
+			if cfg.Worker == 0 {
+				cfg.Worker = defaultWorker
+			}
+			if cfg.Parallel == 0 {
+				cfg.Parallel = defaultParallel
+			}
+			if cfg.Interval == 0 {
+				cfg.Interval = defaultInterval
+			}
+			if cfg.HookWorker == 0 {
+				cfg.HookWorker = defaultHookWorker
+			}
+			if cfg.HookTimeout == 0 {
+				cfg.HookTimeout = defaultHookTimeout
+			}
+			if cfg.RemoteValidatorPresets == nil {
+				cfg.RemoteValidatorPresets = []string{defaultPreset}
+			}
+			switch cfg.ValidationMode {
+			case ValidationStrict, ValidationUnsafe:
+			default:
+				cfg.ValidationMode = ValidationStrict
+			}
+			switch cfg.SignaturePolicy {
+			case SignatureOptional, SignatureRequired, SignatureHashes:
+			default:
+				cfg.SignaturePolicy = defaultSignature
+			}
+			switch cfg.RemovedMode {
+			case RemovedIgnore, RemovedReport, RemovedMove, RemovedDelete:
+			default:
+				cfg.RemovedMode = defaultRemovedMode
+			}
+			if cfg.Storage == "" {
+				cfg.Storage = defaultStorage
+			}
+			if cfg.ProviderDistribution == "" {
+				cfg.ProviderDistribution = defaultDistribution
+			}
+			if cfg.LogFile == nil {
+				cfg.LogFile = &logFile
+			}
+			if cfg.LogLevel == nil {
+				cfg.LogLevel = logLevel
+			}
+		},
+	}
+	return p.Parse()
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (vm *ValidationMode) UnmarshalText(text []byte) error {
+	switch m := ValidationMode(text); m {
+	case ValidationStrict, ValidationUnsafe:
+		*vm = m
+	default:
+		return fmt.Errorf(`invalid value %q (expected "strict" or "unsafe)"`, m)
+	}
+	return nil
+}
+
+// UnmarshalFlag implements [flags.UnmarshalFlag].
+func (vm *ValidationMode) UnmarshalFlag(value string) error {
+	var v ValidationMode
+	if err := v.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	*vm = v
+	return nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (sp *SignaturePolicy) UnmarshalText(text []byte) error {
+	switch p := SignaturePolicy(text); p {
+	case SignatureOptional, SignatureRequired, SignatureHashes:
+		*sp = p
+	default:
+		return fmt.Errorf(`invalid value %q (expected "optional", "required" or "hashes")`, p)
+	}
+	return nil
+}
+
+// UnmarshalFlag implements [flags.UnmarshalFlag].
+func (sp *SignaturePolicy) UnmarshalFlag(value string) error {
+	var p SignaturePolicy
+	if err := p.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	*sp = p
+	return nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (rm *RemovedMode) UnmarshalText(text []byte) error {
+	switch m := RemovedMode(text); m {
+	case RemovedIgnore, RemovedReport, RemovedMove, RemovedDelete:
+		*rm = m
+	default:
+		return fmt.Errorf(`invalid value %q (expected "ignore", "report", "move" or "delete")`, m)
+	}
+	return nil
+}
+
+// UnmarshalFlag implements [flags.UnmarshalFlag].
+func (rm *RemovedMode) UnmarshalFlag(value string) error {
+	var m RemovedMode
+	if err := m.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	*rm = m
+	return nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (sk *StorageKind) UnmarshalText(text []byte) error {
+	switch k := StorageKind(text); k {
+	case StorageDir, StorageTar, StorageZip, StorageS3:
+		*sk = k
+	default:
+		return fmt.Errorf(`invalid value %q (expected "dir", "tar", "zip" or "s3")`, k)
+	}
+	return nil
+}
+
+// UnmarshalFlag implements [flags.UnmarshalFlag].
+func (sk *StorageKind) UnmarshalFlag(value string) error {
+	var k StorageKind
+	if err := k.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	*sk = k
+	return nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (d *Distribution) UnmarshalText(text []byte) error {
+	switch k := Distribution(text); k {
+	case DistributionROLIE, DistributionDirectory, DistributionBoth:
+		*d = k
+	default:
+		return fmt.Errorf(`invalid value %q (expected "rolie", "directory" or "both")`, k)
+	}
+	return nil
+}
+
+// UnmarshalFlag implements [flags.UnmarshalFlag].
+func (d *Distribution) UnmarshalFlag(value string) error {
+	var k Distribution
+	if err := k.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	*d = k
+	return nil
+}
+
+// trackStored returns true if the stored advisories
+// have to be tracked to detect removed ones.
+func (cfg *Config) trackStored() bool {
+	return !cfg.NoStore && cfg.RemovedMode != "" && cfg.RemovedMode != RemovedIgnore
+}
+
+// ignoreFile returns true if the given URL should not be downloaded.
+func (cfg *Config) ignoreURL(u string) bool {
+	return cfg.ignorePattern.Matches(u)
+}
+
+// domainConfig returns the specific settings of the given domain.
+// Returns nil if there are none.
+func (cfg *Config) domainConfig(domain string) *DomainConfig {
+	for _, dc := range cfg.Domains {
+		if strings.EqualFold(dc.Domain, domain) {
+			return dc
+		}
+	}
+	return nil
+}
+
+// forDomain returns the effective configuration for
+// the given domain with the domain specific settings
+// applied. Returns the configuration itself if there
+// are no specific settings for the domain.
+func (cfg *Config) forDomain(domain string) *Config {
+	dc := cfg.domainConfig(domain)
+	if dc == nil {
+		return cfg
+	}
+	c := *cfg
+	if dc.Rate != nil {
+		c.Rate = dc.Rate
+	}
+	if dc.Worker != nil {
+		c.Worker = *dc.Worker
+	}
+	if len(dc.ExtraHeader) > 0 {
+		c.ExtraHeader = dc.ExtraHeader
+	}
+	if len(dc.clientCerts) != 0 {
+		c.ClientCerts = dc.clientCerts
+	}
+	if len(dc.ignorePattern) > 0 {
+		c.ignorePattern = slices.Concat(cfg.ignorePattern, dc.ignorePattern)
+	}
+	if dc.Range != nil {
+		c.Range = dc.Range
+	}
+	if dc.ValidationMode != nil {
+		c.ValidationMode = *dc.ValidationMode
+	}
+	if dc.Folder != nil {
+		// The domain specific folder has precedence
+		// over a global path template.
+		c.Folder = *dc.Folder
+		c.pathTemplate = nil
+	}
+	if len(dc.TrustedKeys) > 0 {
+		c.TrustedKeys = dc.TrustedKeys
+	}
+	if dc.Keyring != nil {
+		c.Keyring = *dc.Keyring
+		c.keyring = dc.keyring
+	}
+	if dc.SignaturePolicy != nil {
+		c.SignaturePolicy = *dc.SignaturePolicy
+	}
+	return &c
+}
+
+// logger returns the logger to be used.
+func (cfg *Config) logger() *slog.Logger {
+	if cfg.Logger != nil {
+		return cfg.Logger
+	}
+	return slog.Default()
+}
+
+// verbose is considered a log level equal or less debug.
+func (cfg *Config) verbose() bool {
+	if cfg.Logger != nil {
+		return cfg.Logger.Enabled(context.Background(), slog.LevelDebug)
+	}
+	return cfg.LogLevel.Level <= slog.LevelDebug
+}
+
+// prepareDirectory ensures that the working directory
+// exists and is setup properly.
+func (cfg *Config) prepareDirectory() error {
+	// If not given use current working directory.
+	if cfg.Directory == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		cfg.Directory = dir
+		return nil
+	}
+	// Use given directory
+	if _, err := os.Stat(cfg.Directory); err != nil {
+		// If it does not exist create it.
+		if os.IsNotExist(err) {
+			if err = os.MkdirAll(cfg.Directory, 0755); err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropSubSeconds drops all parts below resolution of seconds.
+func dropSubSeconds(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey {
+		t := a.Value.Time()
+		a.Value = slog.TimeValue(t.Truncate(time.Second))
+	}
+	return a
+}
+
+// PrepareLogging sets up the structured logging.
+// If a logger is given in the configuration it is used
+// and the default logger is left untouched.
+func (cfg *Config) PrepareLogging() error {
+	if cfg.Logger != nil {
+		return nil
+	}
+	var w io.Writer
+	if cfg.LogFile == nil || *cfg.LogFile == "" {
+		log.Println("using STDERR for logging")
+		w = os.Stderr
+	} else {
+		var fname string
+		// We put the log inside the download folder
+		// if it is not absolute.
+		if filepath.IsAbs(*cfg.LogFile) {
+			fname = *cfg.LogFile
+		} else {
+			fname = filepath.Join(cfg.Directory, *cfg.LogFile)
+		}
+		f, err := os.OpenFile(fname, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		log.Printf("using %q for logging\n", fname)
+		w = f
+	}
+	ho := slog.HandlerOptions{
+		// AddSource: true,
+		Level:       cfg.LogLevel.Level,
+		ReplaceAttr: dropSubSeconds,
+	}
+	handler := slog.NewJSONHandler(w, &ho)
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	return nil
+}
+
+// compileIgnorePatterns compiles the configured patterns to be ignored.
+func (dc *DomainConfig) compileIgnorePatterns() error {
+	pm, err := filter.NewPatternMatcher(dc.IgnorePattern)
+	if err != nil {
+		return fmt.Errorf("invalid ignore patterns for %q: %w", dc.Domain, err)
+	}
+	dc.ignorePattern = pm
+	return nil
+}
+
+// compileIgnorePatterns compiles the configure patterns to be ignored.
+func (cfg *Config) compileIgnorePatterns() error {
+	pm, err := filter.NewPatternMatcher(cfg.IgnorePattern)
+	if err != nil {
+		return err
+	}
+	cfg.ignorePattern = pm
+	// Compile the patterns of the domains.
+	for _, dc := range cfg.Domains {
+		if err := dc.compileIgnorePatterns(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileDocumentFilter compiles the filters on the content of the advisories.
+func (cfg *Config) compileDocumentFilter() error {
+	df, err := filter.NewDocumentFilter(cfg.Include, cfg.Exclude, cfg.MinSeverity)
+	if err != nil {
+		return err
+	}
+	cfg.docFilter = df
+	return nil
+}
+
+// compilePathTemplate compiles the configured path template.
+func (cfg *Config) compilePathTemplate() error {
+	if cfg.PathTemplate == "" {
+		return nil
+	}
+	if cfg.Folder != "" {
+		return errors.New("folder and path_template cannot be used together")
+	}
+	pt, err := parsePathTemplate(cfg.PathTemplate)
+	if err != nil {
+		return err
+	}
+	cfg.pathTemplate = pt
+	return nil
+}
+
+// prepareCertificates loads the domain specific client side certificates
+// used by the HTTP client.
+func (dc *DomainConfig) prepareCertificates() error {
+	cert, err := certs.LoadCertificate(
+		dc.ClientCert, dc.ClientKey, dc.ClientPassphrase)
+	if err != nil {
+		return fmt.Errorf("invalid certificates for %q: %w", dc.Domain, err)
+	}
+	dc.clientCerts = cert
+	return nil
+}
+
+// prepareCertificates loads the client side certificates used by the HTTP client.
+func (cfg *Config) prepareCertificates() error {
+	cert, err := certs.LoadCertificate(
+		cfg.ClientCert, cfg.ClientKey, cfg.ClientPassphrase)
+	if err != nil {
+		return err
+	}
+	cfg.ClientCerts = cert
+	// Domain certificates
+	for _, dc := range cfg.Domains {
+		if err := dc.prepareCertificates(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDomains checks the domain specific settings.
+func (cfg *Config) checkDomains() error {
+	already := util.Set[string]{}
+	for _, dc := range cfg.Domains {
+		if dc.Domain == "" {
+			return errors.New("no domain given for domain specific settings")
+		}
+		domain := strings.ToLower(dc.Domain)
+		if already.Contains(domain) {
+			return fmt.Errorf("domain %q is configured more than once", dc.Domain)
+		}
+		already.Add(domain)
+		if dc.Worker != nil && *dc.Worker < 1 {
+			return fmt.Errorf("invalid number of workers for %q", dc.Domain)
+		}
+	}
+	return nil
+}
+
+// archivePath returns the path of the archive to store the
+// downloaded files in. Relative paths are resolved against
+// the download directory.
+func (cfg *Config) archivePath() string {
+	fname := cfg.Archive
+	if fname == "" {
+		fname = "csaf_downloads." + string(cfg.Storage)
+	}
+	if filepath.IsAbs(fname) {
+		return fname
+	}
+	return filepath.Join(cfg.Directory, fname)
+}
+
+// prepareStorage checks if the configured storage
+// supports the requested features.
+func (cfg *Config) prepareStorage() error {
+	switch cfg.Storage {
+	case StorageTar, StorageZip:
+		if cfg.KeepRevisions {
+			return fmt.Errorf("keeping revisions is not supported by %s storage", cfg.Storage)
+		}
+		// The archive is created anew, so the advisories
+		// completed in the former run would be lost.
+		if cfg.Resume {
+			return fmt.Errorf("resume is not supported by %s storage", cfg.Storage)
+		}
+		switch cfg.RemovedMode {
+		case RemovedMove, RemovedDelete:
+			return fmt.Errorf("removed mode %q is not supported by %s storage",
+				cfg.RemovedMode, cfg.Storage)
+		}
+	case StorageS3:
+		if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+			return errors.New("s3 storage needs an endpoint and a bucket")
+		}
+		if cfg.S3AccessKey == "" {
+			cfg.S3AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		}
+		if cfg.S3SecretKey == "" {
+			cfg.S3SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		}
+	}
+	return nil
+}
+
+// openStorage opens the configured storage.
+func (cfg *Config) openStorage() (storage.Storage, error) {
+	switch cfg.Storage {
+	case StorageTar:
+		return storage.NewTar(cfg.archivePath())
+	case StorageZip:
+		return storage.NewZip(cfg.archivePath())
+	case StorageS3:
+		return storage.NewS3(storage.S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Prefix:    cfg.S3Prefix,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}, &http.Client{
+			Transport: &http.Transport{Proxy: proxyFromEnvironment},
+		})
+	default:
+		return storage.NewDir(cfg.Directory), nil
+	}
+}
+
+// checkResume checks if resuming is possible.
+func (cfg *Config) checkResume() error {
+	if cfg.Resume && cfg.NoStore {
+		return errors.New("resume cannot be used together with no_store")
+	}
+	return nil
+}
+
+// checkQuarantine checks that the quarantined
+// advisories can be stored.
+func (cfg *Config) checkQuarantine() error {
+	if cfg.Quarantine && cfg.NoStore {
+		return errors.New("quarantine cannot be used together with no_store")
+	}
+	return nil
+}
+
+// prepare prepares internal state of a loaded configuration.
+func (cfg *Config) Prepare() error {
+	for _, prepare := range []func(*Config) error{
+		(*Config).prepareDirectory,
+		(*Config).checkDomains,
+		(*Config).PrepareLogging,
+		(*Config).prepareCertificates,
+		(*Config).prepareTrust,
+		(*Config).compileIgnorePatterns,
+		(*Config).compilePathTemplate,
+		(*Config).compileDocumentFilter,
+		(*Config).prepareStorage,
+		(*Config).checkResume,
+		(*Config).checkQuarantine,
+		(*Config).prepareForward,
+		(*Config).checkHooks,
+		(*Config).prepareProviderTree,
+		(*Config).checkBundle,
+		(*Config).checkDaemon,
+	} {
+		if err := prepare(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/gocsaf/csaf/v3/pkg/options"
+)
+
+func TestDomainConfig(t *testing.T) {
+	const doc = `
+worker = 4
+rate = 10.0
+ignore_pattern = [".*white.*"]
+validation_mode = "strict"
+path_template = "{domain}/{tlp}"
+
+[header]
+X-Global = ["global"]
+
+[[domains]]
+domain = "Vendor.example.com"
+worker = 1
+rate = 0.5
+ignore_pattern = [".*red.*"]
+validation_mode = "unsafe"
+folder = "vendor"
+
+[domains.header]
+X-Vendor = ["vendor"]
+
+[[domains]]
+domain = "other.example.com"
+`
+	var cfg Config
+	if _, err := toml.Decode(doc, &cfg); err != nil {
+		t.Fatalf("decoding config failed: %v", err)
+	}
+	cfg.LogLevel = &options.LogLevel{Level: slog.LevelError}
+	for _, prepare := range []func(*Config) error{
+		(*Config).checkDomains,
+		(*Config).prepareCertificates,
+		(*Config).compileIgnorePatterns,
+		(*Config).compilePathTemplate,
+	} {
+		if err := prepare(&cfg); err != nil {
+			t.Fatalf("preparing config failed: %v", err)
+		}
+	}
+
+	if c := cfg.forDomain("unknown.example.com"); c != &cfg {
+		t.Error("expected global config for unknown domain")
+	}
+
+	c := cfg.forDomain("vendor.example.com")
+	if c == &cfg {
+		t.Fatal("expected domain specific config")
+	}
+	if c.Worker != 1 {
+		t.Errorf("worker: got %d, want 1", c.Worker)
+	}
+	if c.Rate == nil || *c.Rate != 0.5 {
+		t.Errorf("rate: got %v, want 0.5", c.Rate)
+	}
+	if c.ValidationMode != ValidationUnsafe {
+		t.Errorf("validation mode: got %q, want %q", c.ValidationMode, ValidationUnsafe)
+	}
+	if c.Folder != "vendor" || c.pathTemplate != nil {
+		t.Errorf("folder: got %q (template %v), want %q", c.Folder, c.pathTemplate, "vendor")
+	}
+	if got := c.ExtraHeader.Get("X-Vendor"); got != "vendor" {
+		t.Errorf("header: got %q, want %q", got, "vendor")
+	}
+	if c.ExtraHeader.Get("X-Global") != "" {
+		t.Error("global header not replaced")
+	}
+	for _, u := range []string{
+		"https://vendor.example.com/white/a.json",
+		"https://vendor.example.com/red/a.json",
+	} {
+		if !c.ignoreURL(u) {
+			t.Errorf("expected %q to be ignored", u)
+		}
+	}
+	if cfg.ignoreURL("https://vendor.example.com/red/a.json") {
+		t.Error("domain specific pattern leaked into global config")
+	}
+
+	// The global settings are kept if not overridden.
+	o := cfg.forDomain("other.example.com")
+	if o.Worker != 4 || o.Rate == nil || *o.Rate != 10 ||
+		o.ValidationMode != ValidationStrict || o.pathTemplate == nil ||
+		o.ExtraHeader.Get("X-Global") != "global" {
+		t.Error("global settings not kept for domain without overrides")
+	}
+}
+
+func TestCheckDomains(t *testing.T) {
+	zero := 0
+	for _, tc := range []struct {
+		name    string
+		domains []*DomainConfig
+		fail    bool
+	}{
+		{"none", nil, false},
+		{"valid", []*DomainConfig{{Domain: "a.example.com"}, {Domain: "b.example.com"}}, false},
+		{"missing domain", []*DomainConfig{{}}, true},
+		{"duplicate", []*DomainConfig{{Domain: "a.example.com"}, {Domain: "A.example.com"}}, true},
+		{"no workers", []*DomainConfig{{Domain: "a.example.com", Worker: &zero}}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{Domains: tc.domains}
+			if err := cfg.checkDomains(); (err != nil) != tc.fail {
+				t.Errorf("got error %v, want failure %t", err, tc.fail)
+			}
+		})
+	}
+}
+
+func TestPrepareStorage(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		fail bool
+	}{
+		{"dir resume", Config{Storage: StorageDir, Resume: true}, false},
+		{"tar", Config{Storage: StorageTar}, false},
+		{"tar resume", Config{Storage: StorageTar, Resume: true}, true},
+		{"zip resume", Config{Storage: StorageZip, Resume: true}, true},
+		{"zip revisions", Config{Storage: StorageZip, KeepRevisions: true}, true},
+		{"zip removed", Config{Storage: StorageZip, RemovedMode: RemovedDelete}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.cfg.prepareStorage(); (err != nil) != tc.fail {
+				t.Errorf("unexpected result: %v", err)
+			}
+		})
+	}
+}
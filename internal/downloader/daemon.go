@@ -0,0 +1,394 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minInterval is the minimal interval between two
+// downloads of a domain in daemon mode.
+const minInterval = time.Minute
+
+// checkDaemon checks the settings of the daemon mode.
+func (cfg *Config) checkDaemon() error {
+	if !cfg.Daemon {
+		return nil
+	}
+	if cfg.EnumeratePMDOnly {
+		return errors.New("daemon cannot be used together with enumerate_pmd_only")
+	}
+	if cfg.Interval < minInterval {
+		return fmt.Errorf("interval has to be at least %s", minInterval)
+	}
+	for _, dc := range cfg.Domains {
+		if dc.Interval != nil && *dc.Interval < minInterval {
+			return fmt.Errorf("interval of %q has to be at least %s", dc.Domain, minInterval)
+		}
+	}
+	return nil
+}
+
+// parseUpdateInterval parses the update interval of a
+// publisher in an aggregator. The CSAF standard does not
+// define a format so only some common phrases and Go
+// durations are understood.
+func parseUpdateInterval(s string) (time.Duration, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "hourly", "every hour":
+		return time.Hour, true
+	case "daily", "every day":
+		return 24 * time.Hour, true
+	case "weekly", "every week":
+		return 7 * 24 * time.Hour, true
+	case "monthly", "every month":
+		return 30 * 24 * time.Hour, true
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, true
+	}
+	return 0, false
+}
+
+// interval returns the interval between two downloads of a domain.
+// A domain specific setting has precedence over the update interval
+// given by the aggregator which has precedence over the global setting.
+func (d *Downloader) interval(domain string) time.Duration {
+	if dc := d.cfg.domainConfig(domain); dc != nil && dc.Interval != nil {
+		return *dc.Interval
+	}
+	if me := d.mirrors.find(domain); me != nil {
+		if ui, ok := parseUpdateInterval(me.updateInterval); ok {
+			return max(ui, minInterval)
+		}
+	}
+	return d.cfg.Interval
+}
+
+// domainStatus is the status of a domain in daemon mode.
+type domainStatus struct {
+	Interval    string     `json:"interval"`
+	Runs        int        `json:"runs"`
+	Failures    int        `json:"failures"`
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+}
+
+// reloadRequest is a request to replace the configuration of a daemon.
+type reloadRequest struct {
+	cfg     *Config
+	domains []string
+}
+
+// Daemon downloads the advisories of domains periodically.
+type Daemon struct {
+	// Setup is called with every downloader created by the daemon
+	// and its configuration. The returned function is called when
+	// the downloader is closed.
+	Setup func(*Config, *Downloader) func()
+
+	reload chan reloadRequest
+
+	mu         sync.Mutex
+	cfg        *Config
+	domains    []string
+	started    time.Time
+	downloader *Downloader
+	status     map[string]*domainStatus
+}
+
+// NewDaemon creates a daemon downloading from the given domains.
+func NewDaemon(cfg *Config, domains []string) *Daemon {
+	return &Daemon{
+		reload:  make(chan reloadRequest, 1),
+		cfg:     cfg,
+		domains: domains,
+	}
+}
+
+// Reload replaces the configuration and the domains of the daemon.
+// Running downloads are stopped and the domains are scheduled anew.
+func (dm *Daemon) Reload(cfg *Config, domains []string) {
+	// Only the last request is of interest.
+	select {
+	case <-dm.reload:
+	default:
+	}
+	dm.reload <- reloadRequest{cfg: cfg, domains: domains}
+}
+
+// Run downloads the advisories of the domains periodically
+// until the context is cancelled.
+func (dm *Daemon) Run(ctx context.Context) error {
+	dm.mu.Lock()
+	dm.started = time.Now().UTC()
+	listen := dm.cfg.Listen
+	dm.mu.Unlock()
+
+	if listen != "" {
+		srv, err := dm.serve(listen)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(sctx)
+		}()
+	}
+
+	for {
+		dm.mu.Lock()
+		cfg, domains := dm.cfg, dm.domains
+		dm.mu.Unlock()
+
+		d, err := NewDownloader(cfg)
+		if err != nil {
+			return err
+		}
+		cleanup := func() {}
+		if dm.Setup != nil {
+			cleanup = dm.Setup(cfg, d)
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- dm.schedule(runCtx, d, domains) }()
+
+		var (
+			req       reloadRequest
+			reloading bool
+		)
+		select {
+		case <-ctx.Done():
+		case req = <-dm.reload:
+			reloading = true
+		case err = <-done:
+		}
+		cancel()
+		if err == nil {
+			err = <-done
+		}
+		cleanup()
+		d.Close()
+
+		if !reloading {
+			cfg.logger().Info("Daemon stopped")
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		}
+		dm.mu.Lock()
+		dm.cfg, dm.domains = req.cfg, req.domains
+		dm.mu.Unlock()
+		req.cfg.logger().Info("Configuration reloaded")
+	}
+}
+
+// schedule downloads from every domain in its own interval
+// until the context is cancelled.
+func (dm *Daemon) schedule(ctx context.Context, d *Downloader, domains []string) error {
+	domains, err := d.prepareDomains(domains)
+	if err != nil {
+		return err
+	}
+	if len(domains) == 0 {
+		return errors.New("no domains given")
+	}
+
+	d.metrics.forwarders(d.Forwarders)
+
+	dm.mu.Lock()
+	dm.downloader = d
+	status := make(map[string]*domainStatus, len(domains))
+	for _, domain := range domains {
+		status[domain] = &domainStatus{Interval: d.interval(domain).String()}
+	}
+	dm.status = status
+	dm.mu.Unlock()
+
+	slots := make(chan struct{}, max(d.cfg.Parallel, 1))
+
+	var wg sync.WaitGroup
+	for _, domain := range domains {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dm.poll(ctx, d, slots, domain, d.interval(domain))
+		}()
+	}
+	wg.Wait()
+	return context.Cause(ctx)
+}
+
+// poll downloads from a domain in the given interval
+// until the context is cancelled.
+func (dm *Daemon) poll(
+	ctx context.Context,
+	d *Downloader,
+	slots chan struct{},
+	domain string,
+	interval time.Duration,
+) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case slots <- struct{}{}:
+		}
+		started := time.Now().UTC()
+		err := d.download(ctx, domain)
+		<-slots
+		if ctx.Err() != nil {
+			// Interrupted by shutdown or reload.
+			return
+		}
+		if err != nil {
+			d.cfg.logger().Error("Downloading from domain failed",
+				"domain", domain,
+				"error", err)
+		}
+		if err := d.writeProviderTree(); err != nil {
+			d.cfg.logger().Error("Writing provider tree failed", "error", err)
+		}
+		if err := d.hooks.batch(ctx, domain); err != nil {
+			d.cfg.logger().Error("Running batch hook failed",
+				"domain", domain,
+				"error", err)
+		}
+		d.logDomainStats(domain)
+		d.metrics.finished(domain, err)
+		if err := d.writeMetrics(); err != nil {
+			d.cfg.logger().Error("Writing metrics failed", "error", err)
+		}
+		next := time.Now().Add(interval).UTC()
+		dm.record(domain, started, next, err)
+		timer.Reset(interval)
+	}
+}
+
+// record records the outcome of a download of a domain.
+func (dm *Daemon) record(domain string, started, next time.Time, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	st := dm.status[domain]
+	if st == nil {
+		return
+	}
+	st.Runs++
+	st.LastRun = &started
+	st.NextRun = &next
+	if err != nil {
+		st.Failures++
+		st.LastError = err.Error()
+	} else {
+		st.LastSuccess = &started
+		st.LastError = ""
+	}
+}
+
+// logDomainStats logs the stats of a domain.
+func (d *Downloader) logDomainStats(domain string) {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+	if st := d.domainStats[domain]; st != nil {
+		st.logDomain(d.cfg.logger(), domain)
+	}
+}
+
+// health is the document served by the health endpoint.
+type health struct {
+	Status  string                   `json:"status"`
+	Started time.Time                `json:"started"`
+	Uptime  float64                  `json:"uptime_seconds"`
+	Counts  map[string]int           `json:"counts"`
+	Domains map[string]*domainStatus `json:"domains"`
+}
+
+// health returns the current health of the daemon.
+func (dm *Daemon) health() *health {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	h := &health{
+		Status:  "ok",
+		Started: dm.started,
+		Uptime:  time.Since(dm.started).Seconds(),
+		Domains: make(map[string]*domainStatus, len(dm.status)),
+	}
+	for domain, st := range dm.status {
+		c := *st
+		h.Domains[domain] = &c
+	}
+	if d := dm.downloader; d != nil {
+		d.statsMu.Lock()
+		h.Counts = d.stats.counts()
+		d.statsMu.Unlock()
+	}
+	return h
+}
+
+// serveHealth serves the health of the daemon as JSON.
+func (dm *Daemon) serveHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dm.health())
+}
+
+// serveMetrics serves the metrics of the current downloader
+// in the Prometheus text format.
+func (dm *Daemon) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	dm.mu.Lock()
+	d := dm.downloader
+	dm.mu.Unlock()
+	if d == nil || d.metrics == nil {
+		http.Error(w, "no metrics available", http.StatusServiceUnavailable)
+		return
+	}
+	d.metrics.registry.ServeHTTP(w, r)
+}
+
+// serve starts the HTTP server of the health endpoint.
+func (dm *Daemon) serve(addr string) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen on %q: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", dm.serveHealth)
+	mux.HandleFunc("GET /metrics", dm.serveMetrics)
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			dm.cfg.logger().Error("Health endpoint failed", "error", err)
+		}
+	}()
+	dm.cfg.logger().Info("Serving health endpoint", "address", ln.Addr().String())
+	return srv, nil
+}
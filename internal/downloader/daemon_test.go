@@ -0,0 +1,145 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestParseUpdateInterval(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"daily", 24 * time.Hour, true},
+		{" Hourly ", time.Hour, true},
+		{"every week", 7 * 24 * time.Hour, true},
+		{"90m", 90 * time.Minute, true},
+		{"on best effort", 0, false},
+		{"-1h", 0, false},
+		{"", 0, false},
+	} {
+		got, ok := parseUpdateInterval(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%q: got (%v, %t), want (%v, %t)",
+				tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestCheckDaemon(t *testing.T) {
+	short := 10 * time.Second
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		fail bool
+	}{
+		{"disabled", Config{Interval: short}, false},
+		{"valid", Config{Daemon: true, Interval: time.Hour}, false},
+		{"short interval", Config{Daemon: true, Interval: short}, true},
+		{"short domain interval", Config{
+			Daemon:   true,
+			Interval: time.Hour,
+			Domains:  []*DomainConfig{{Domain: "example.com", Interval: &short}},
+		}, true},
+	} {
+		if err := tc.cfg.checkDaemon(); (err != nil) != tc.fail {
+			t.Errorf("%s: unexpected result: %v", tc.name, err)
+		}
+	}
+}
+
+func TestDaemon(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client := util.Client(server.Client())
+	domain := server.URL + "/provider-metadata.json"
+
+	tempDir := t.TempDir()
+	cfg := Config{
+		LogLevel:  &options.LogLevel{Level: slog.LevelError},
+		Directory: tempDir,
+		Daemon:    true,
+		Interval:  time.Hour,
+		Listen:    "127.0.0.1:0",
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+
+	dm := NewDaemon(&cfg, []string{domain})
+	dm.Setup = func(_ *Config, d *Downloader) func() {
+		d.client = &client
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- dm.Run(ctx) }()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if h := dm.health(); h.Domains[domain] != nil && h.Domains[domain].Runs > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no download in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	dm.serveHealth(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var h health
+	if err := json.Unmarshal(rec.Body.Bytes(), &h); err != nil {
+		t.Fatal(err)
+	}
+	st := h.Domains[domain]
+	if st == nil || st.Runs != 1 || st.Failures != 0 || st.LastSuccess == nil ||
+		st.NextRun == nil || st.Interval != "1h0m0s" {
+		t.Errorf("unexpected status: %+v", st)
+	}
+	if h.Counts["succeeded"] != 1 {
+		t.Errorf("unexpected counts: %v", h.Counts)
+	}
+	rec = httptest.NewRecorder()
+	dm.serveMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := `csaf_downloader_advisories_total{domain="` + domain +
+		`",result="succeeded"} 1`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("missing %q in metrics:\n%s", want, rec.Body.String())
+	}
+	if !checkIfFileExists(tempDir+"/white/2020/avendor-advisory-0004.json", t) {
+		t.Error("advisory was not written")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+}
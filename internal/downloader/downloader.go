@@ -0,0 +1,1559 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2022, 2023 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2022, 2023 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/time/rate"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/httpext"
+	"github.com/gocsaf/csaf/v3/internal/misc"
+	"github.com/gocsaf/csaf/v3/internal/storage"
+	"github.com/gocsaf/csaf/v3/pkg/errs"
+	csafErrs "github.com/gocsaf/csaf/v3/pkg/errs"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+type hashFetchInfo struct {
+	url       string
+	preferred bool
+	warn      bool
+	hashType  hashAlgorithm
+}
+
+type Downloader struct {
+	cfg        *Config
+	client     *util.Client // Used for testing
+	validator  csaf.RemoteValidator
+	Forwarders []*Forwarder
+	// Handler is called with every advisory passing the checks
+	// and the filters. If it returns an error the download of
+	// the domain is stopped.
+	Handler func(*Advisory) error
+	storage storage.Storage
+	budget  *workerBudget
+	mirrors mirrorList
+	report  *runReport
+	metrics *downloaderMetrics
+	hooks   *hooks
+	bundle  *bundle
+	statsMu sync.Mutex
+	stats   stats
+	Csafs   chan []byte
+
+	// domainStats are the stats broken down by domain.
+	domainStats map[string]*stats
+
+	// revisionsMu serializes the updates of the revisions indices.
+	revisionsMu sync.Mutex
+	// treeMu serializes the writing of the provider tree.
+	treeMu sync.Mutex
+}
+
+// failedValidationDir is the name of the sub folder
+// where advisories are stored that fail validation in
+// unsafe mode.
+const failedValidationDir = "failed_validation"
+
+func NewDownloader(cfg *Config) (*Downloader, error) {
+	var validator csaf.RemoteValidator
+
+	if cfg.RemoteValidator != "" {
+		validatorOptions := csaf.RemoteValidatorOptions{
+			URL:     cfg.RemoteValidator,
+			Presets: cfg.RemoteValidatorPresets,
+			Cache:   cfg.RemoteValidatorCache,
+		}
+		var err error
+		if validator, err = validatorOptions.Open(); err != nil {
+			return nil, fmt.Errorf(
+				"preparing remote validator failed: %w", err)
+		}
+		validator = csaf.SynchronizedRemoteValidator(validator)
+	}
+
+	var store storage.Storage
+	if !cfg.NoStore {
+		var err error
+		if store, err = cfg.openStorage(); err != nil {
+			if validator != nil {
+				validator.Close()
+			}
+			return nil, fmt.Errorf(
+				"preparing storage failed: %w", err)
+		}
+	}
+
+	d := &Downloader{
+		cfg:       cfg,
+		validator: validator,
+		storage:   store,
+		budget:    newWorkerBudget(cfg.MaxWorker, cfg.HostWorker),
+		Csafs:     make(chan []byte),
+		hooks:     newHooks(cfg),
+	}
+	if cfg.metricsEnabled() {
+		d.metrics = newDownloaderMetrics(d)
+	}
+	return d, nil
+}
+
+func (d *Downloader) Close() {
+	if d.validator != nil {
+		d.validator.Close()
+		d.validator = nil
+	}
+	if d.storage != nil {
+		if err := d.storage.Close(); err != nil {
+			d.cfg.logger().Error("Closing storage failed", "error", err)
+		}
+		d.storage = nil
+	}
+	close(d.Csafs)
+}
+
+// addStats add stats of a domain to total stats
+func (d *Downloader) addStats(domain string, o *stats) {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+	d.stats.add(o)
+	if d.domainStats == nil {
+		d.domainStats = map[string]*stats{}
+	}
+	st := d.domainStats[domain]
+	if st == nil {
+		st = new(stats)
+		d.domainStats[domain] = st
+	}
+	st.add(o)
+}
+
+// logStats logs the stats of the domains and the total stats.
+func (d *Downloader) logStats() {
+	d.statsMu.Lock()
+	defer d.statsMu.Unlock()
+	for _, domain := range slices.Sorted(maps.Keys(d.domainStats)) {
+		d.domainStats[domain].logDomain(d.cfg.logger(), domain)
+	}
+	d.stats.log(d.cfg.logger())
+}
+
+// logRedirect returns a function logging the redirects of the http client.
+func logRedirect(logger *slog.Logger) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		vs := make([]string, len(via))
+		for i, v := range via {
+			vs[i] = v.URL.String()
+		}
+		logger.Debug("Redirecting",
+			"to", req.URL.String(),
+			"via", strings.Join(vs, " -> "))
+		return nil
+	}
+}
+
+// httpClient creates the HTTP client used with the given
+// configuration which may be specific for a domain.
+func (d *Downloader) httpClient(cfg *Config) util.Client {
+	hClient := http.Client{}
+
+	if cfg.verbose() {
+		hClient.CheckRedirect = logRedirect(cfg.logger())
+	}
+
+	var tlsConfig tls.Config
+	if cfg.Insecure {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if len(cfg.ClientCerts) != 0 {
+		tlsConfig.Certificates = cfg.ClientCerts
+	}
+
+	hClient.Transport = &http.Transport{
+		TLSClientConfig: &tlsConfig,
+		Proxy:           proxyFromEnvironment,
+	}
+
+	client := util.Client(&hClient)
+
+	// Overwrite for testing purposes
+	if d.client != nil {
+		client = *d.client
+	}
+
+	// Add extra headers.
+	client = &util.HeaderClient{
+		Client: client,
+		Header: cfg.ExtraHeader,
+	}
+
+	// Add optional URL logging.
+	if cfg.verbose() {
+		client = &util.LoggingClient{
+			Client: client,
+			Log:    httpLog(cfg.logger(), "downloader"),
+		}
+	}
+
+	// Add optional rate limiting.
+	if cfg.Rate != nil {
+		client = &util.LimitingClient{
+			Client:  client,
+			Limiter: rate.NewLimiter(rate.Limit(*cfg.Rate), 1),
+		}
+	}
+
+	return client
+}
+
+// httpLog does structured logging in a [util.LoggingClient].
+func httpLog(logger *slog.Logger, who string) func(string, string) {
+	return func(method, url string) {
+		logger.Debug("http",
+			"who", who,
+			"method", method,
+			"url", url)
+	}
+}
+
+func (d *Downloader) enumerate(domain string) error {
+	client := d.httpClient(d.cfg.forDomain(domain))
+
+	loader := csaf.NewProviderMetadataLoader(client)
+	loader.Logger = d.cfg.logger()
+	lpmd := loader.Enumerate(domain)
+
+	docs := []any{}
+
+	for _, pmd := range lpmd {
+		if d.cfg.verbose() {
+			for i := range pmd.Messages {
+				d.cfg.logger().Debug("Enumerating provider-metadata.json",
+					"domain", domain,
+					"message", pmd.Messages[i].Message)
+			}
+		}
+
+		docs = append(docs, pmd.Document)
+	}
+
+	// print the results
+	doc, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		d.cfg.logger().Error("Couldn't marshal PMD document json")
+	}
+	fmt.Println(string(doc))
+
+	return nil
+}
+
+func (d *Downloader) download(ctx context.Context, domain string) error {
+	cfg := d.cfg.forDomain(domain)
+	report := d.report.domain(domain)
+	client := report.client(d.metrics.client(d.httpClient(cfg)))
+
+	loader := csaf.NewProviderMetadataLoader(client)
+	loader.Logger = d.cfg.logger()
+
+	lpmd := loader.Load(domain)
+
+	// Fall back to the mirrors of the provider if needed.
+	var mirror string
+	if !lpmd.Valid() {
+		if me := d.mirrors.find(domain); me != nil {
+			for i := range lpmd.Messages {
+				d.cfg.logger().Warn("Loading provider-metadata.json",
+					"domain", domain,
+					"message", lpmd.Messages[i].Message)
+			}
+			if mlpmd := me.load(d.cfg.logger(), client); mlpmd != nil {
+				d.cfg.logger().Warn("Using mirror of provider",
+					"domain", domain,
+					"mirror", mlpmd.URL)
+				lpmd, mirror = mlpmd, mlpmd.URL
+			}
+		}
+	}
+
+	if !lpmd.Valid() {
+		for i := range lpmd.Messages {
+			d.cfg.logger().Error("Loading provider-metadata.json",
+				"domain", domain,
+				"message", lpmd.Messages[i].Message)
+		}
+		return errs.ErrCsafProviderIssue{Message: fmt.Sprintf("no valid provider-metadata.json found for '%s'", domain)}
+	} else if d.cfg.verbose() {
+		for i := range lpmd.Messages {
+			d.cfg.logger().Debug("Loading provider-metadata.json",
+				"domain", domain,
+				"message", lpmd.Messages[i].Message)
+		}
+	}
+
+	pmdURL, err := url.Parse(lpmd.URL)
+	if err != nil {
+		return errs.ErrCsafProviderIssue{Message: fmt.Sprintf("invalid URL '%s': %v", lpmd.URL, err)}
+	}
+
+	// Local providers are read from the file system.
+	local := pmdURL.Scheme == "file"
+	if local {
+		client = &util.FileClient{Client: client}
+	}
+
+	pc := &providerContext{
+		cfg:    cfg,
+		domain: domain,
+		local:  local,
+		mirror: mirror,
+		report: report,
+	}
+
+	// Only the keys of the original provider are trusted for mirrors.
+	keysFile := d.cfg.stateFile(domain, "keys")
+	if err := pc.pinKeys(func() (util.Set[string], error) {
+		return loadFingerprints(keysFile)
+	}); err != nil {
+		return errs.ErrCsafProviderIssue{Message: fmt.Sprintf("%s of '%s'", err, domain)}
+	}
+
+	expr := util.NewPathEval()
+
+	// A local keyring replaces the keys of the provider.
+	if cfg.keyring != nil {
+		for _, key := range cfg.keyring {
+			pc.addKey(key, cfg.Keyring)
+		}
+	} else if err := pc.loadOpenPGPKeys(
+		client,
+		lpmd.Document,
+		expr,
+	); err != nil {
+		return errs.ErrCsafProviderIssue{Message: err.Error()}
+	}
+
+	if pc.keys == nil && pc.needsKeys() {
+		return errs.ErrCsafProviderIssue{Message: fmt.Sprintf(
+			"no trusted OpenPGP keys to verify the signatures of '%s'", domain)}
+	}
+
+	if d.mirrors.find(domain) != nil {
+		switch {
+		case mirror != "" && pc.keys == nil:
+			return errs.ErrCsafProviderIssue{Message: fmt.Sprintf(
+				"mirror '%s' does not provide the OpenPGP keys of the original provider", mirror)}
+		case mirror == "" && pc.keys != nil:
+			// Remember the keys to be able to verify mirrors later.
+			if err := saveFingerprints(keysFile, pc.keys); err != nil {
+				d.cfg.logger().Error("Saving fingerprints failed",
+					"domain", domain,
+					"error", err)
+			}
+		}
+		if !d.cfg.NoStore {
+			if pc.sources, err = loadAdvisorySources(d.cfg.stateFile(domain, "sources")); err != nil {
+				return err
+			}
+			pc.source = lpmd.URL
+		}
+	}
+
+	// Remember the public keys to publish them with the provider tree.
+	if d.cfg.ProviderTree != "" && pc.keys != nil {
+		if err := savePublicKeys(d.cfg.stateFile(domain, "openpgp"), pc.keys); err != nil {
+			d.cfg.logger().Error("Saving public keys failed",
+				"domain", domain,
+				"error", err)
+		}
+	}
+
+	if err := d.bundle.provider(domain, lpmd, pc.keys); err != nil {
+		return fmt.Errorf("adding provider to bundle failed: %w", err)
+	}
+
+	afp := csaf.NewAdvisoryFileProcessor(
+		client,
+		expr,
+		lpmd.Document,
+		pmdURL)
+	afp.Log = func(level slog.Level, format string, args ...any) {
+		d.cfg.logger().Log(ctx, level, "AdvisoryFileProcessor.Process: "+format, args...)
+	}
+	afp.Incomplete = func(feed string, reason error) {
+		d.cfg.logger().Warn("Feed not listed completely",
+			"domain", domain,
+			"feed", feed,
+			"reason", reason)
+		pc.incomplete = true
+	}
+
+	// Do we need time range based filtering?
+	if cfg.Range != nil {
+		d.cfg.logger().Debug("Setting up filter to accept advisories within",
+			"timerange", cfg.Range)
+		afp.AgeAccept = cfg.Range.Contains
+	}
+
+	// Do we need to keep track of the stored advisories?
+	if d.cfg.trackStored() {
+		if pc.stored, err = loadStoredAdvisories(d.cfg.statePath(domain)); err != nil {
+			return err
+		}
+	}
+
+	// Keep track of the progress to be able to resume.
+	if !d.cfg.NoStore {
+		fname := d.cfg.checkpointPath(domain)
+		if d.cfg.Resume {
+			if pc.checkpoint, err = loadCheckpoint(fname, d.cfg.logger()); err != nil {
+				return err
+			}
+		} else {
+			pc.checkpoint = newCheckpoint(fname, d.cfg.logger())
+		}
+	}
+
+	err = afp.Process(func(label csaf.TLPLabel, files []csaf.AdvisoryFile) error {
+		if pc.stored != nil {
+			pc.stored.see(files)
+		}
+		return d.downloadFiles(ctx, pc, label, files)
+	})
+
+	if pc.stored != nil {
+		d.finishStored(ctx, pc, err)
+	}
+	if pc.checkpoint != nil {
+		pc.checkpoint.finish(err == nil && ctx.Err() == nil)
+	}
+	pc.usage.log(pc.cfg.logger(), domain)
+	report.provider(pc)
+	if pc.sources != nil {
+		if err := pc.sources.save(d.cfg.stateFile(domain, "sources")); err != nil {
+			d.cfg.logger().Error("Saving advisory sources failed",
+				"domain", domain,
+				"error", err)
+		}
+	}
+	return err
+}
+
+// providerContext holds the state of downloading from a single provider.
+type providerContext struct {
+	// cfg is the configuration with the domain specific settings applied.
+	cfg *Config
+	// domain is the domain as given by the user.
+	domain string
+	// local is true if the provider is read from the file system.
+	local bool
+	// incomplete is true if a feed was not listed completely.
+	incomplete bool
+	// stored keeps track of the stored advisories if needed.
+	stored *storedAdvisories
+	// keys are the public OpenPGP keys of the provider.
+	keys *crypto.KeyRing
+	// pinned are the fingerprints of the only keys accepted if not nil.
+	pinned util.Set[string]
+	// usage counts the advisories verified by the keys.
+	usage keyUsage
+	// mirror is the URL of the mirrored provider metadata
+	// if the provider is downloaded from a mirror.
+	mirror string
+	// source is the URL of the provider metadata the
+	// advisories are recorded to come from in sources.
+	source  string
+	sources *advisorySources
+	// checkpoint keeps track of the progress if needed.
+	checkpoint *checkpoint
+	// report is the report of the domain if needed.
+	report *domainReport
+}
+
+func (d *Downloader) downloadFiles(
+	ctx context.Context,
+	pc *providerContext,
+	label csaf.TLPLabel,
+	files []csaf.AdvisoryFile,
+) error {
+	var (
+		advisoryCh = make(chan csaf.AdvisoryFile)
+		errorCh    = make(chan error)
+		errDone    = make(chan struct{})
+		errs       []error
+		wg         sync.WaitGroup
+	)
+
+	// collect errors
+	go func() {
+		defer close(errDone)
+		for err := range errorCh {
+			errs = append(errs, err)
+		}
+	}()
+
+	var n int
+	if n = pc.cfg.Worker; n < 1 {
+		n = 1
+	}
+
+	var fc *feedCheckpoint
+	if pc.checkpoint != nil {
+		fc = pc.checkpoint.feed(label, files)
+	}
+	fr := pc.report.feed(label, len(files))
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go d.downloadWorker(ctx, &wg, pc, fc, fr, label, advisoryCh, errorCh)
+	}
+
+	// Skip the advisories completed in an interrupted run.
+	var skipped stats
+	if fc != nil && d.cfg.Resume {
+		skipped.skipped = pc.checkpoint.resumePosition(fc)
+		files = files[skipped.skipped:]
+	}
+	defer func() {
+		d.addStats(pc.domain, &skipped)
+		fr.add(&skipped)
+	}()
+
+allFiles:
+	for _, file := range files {
+		if fc != nil && d.cfg.Resume && pc.checkpoint.isCompleted(fc, file.URL()) {
+			pc.checkpoint.complete(fc, file.URL())
+			skipped.skipped++
+			continue
+		}
+		select {
+		case advisoryCh <- file:
+		case <-ctx.Done():
+			errorCh <- context.Cause(ctx)
+			break allFiles
+		}
+	}
+
+	close(advisoryCh)
+	wg.Wait()
+	close(errorCh)
+	<-errDone
+
+	if len(errs) > 0 {
+		return &csafErrs.CompositeErrCsafDownload{Errs: errs}
+	}
+	return nil
+}
+
+func (pc *providerContext) loadOpenPGPKeys(
+	client util.Client,
+	doc any,
+	expr *util.PathEval,
+) error {
+	src, err := expr.Eval("$.public_openpgp_keys", doc)
+	if err != nil {
+		// no keys.
+		return nil
+	}
+
+	var keys []csaf.PGPKey
+	if err := util.ReMarshalJSON(&keys, src); err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Try to load
+
+	for i := range keys {
+		key := &keys[i]
+		if key.URL == nil {
+			continue
+		}
+		u, err := url.Parse(*key.URL)
+		if err != nil {
+			pc.cfg.logger().Warn("Invalid URL",
+				"url", *key.URL,
+				"error", err)
+			continue
+		}
+
+		res, err := client.Get(u.String())
+		if err != nil {
+			pc.cfg.logger().Warn(
+				"Fetching public OpenPGP key failed",
+				"url", u,
+				"error", err)
+			continue
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			pc.cfg.logger().Warn(
+				"Fetching public OpenPGP key failed",
+				"url", u,
+				"status_code", res.StatusCode,
+				"status", res.Status)
+			continue
+		}
+
+		ckey, err := func() (*crypto.Key, error) {
+			defer res.Body.Close()
+			return crypto.NewKeyFromArmoredReader(res.Body)
+		}()
+		if err != nil {
+			pc.cfg.logger().Warn(
+				"Reading public OpenPGP key failed",
+				"url", u,
+				"error", err)
+			continue
+		}
+
+		if !strings.EqualFold(ckey.GetFingerprint(), string(key.Fingerprint)) {
+			pc.cfg.logger().Warn(
+				"Fingerprint of public OpenPGP key does not match remotely loaded",
+				"url", u, "fingerprint", key.Fingerprint, "remote-fingerprint", ckey.GetFingerprint())
+			continue
+		}
+		pc.addKey(ckey, u.String())
+	}
+	return nil
+}
+
+// logValidationIssues logs the issues reported by the advisory schema validation.
+func (d *Downloader) logValidationIssues(url string, errors []string, err error) {
+	if err != nil {
+		d.cfg.logger().Error("Failed to validate",
+			"url", url,
+			"error", err)
+		return
+	}
+	if len(errors) > 0 {
+		if d.cfg.verbose() {
+			d.cfg.logger().Error("CSAF file has validation errors",
+				"url", url,
+				"error", strings.Join(errors, ", "))
+		} else {
+			d.cfg.logger().Error("CSAF file has validation errors",
+				"url", url,
+				"count", len(errors))
+		}
+	}
+}
+
+// downloadContext stores the common context of a downloader.
+type downloadContext struct {
+	d                  *Downloader
+	client             util.Client
+	data               bytes.Buffer
+	initialReleaseDate time.Time
+	label              csaf.TLPLabel // TLP label of the feed
+	lower              string        // lower case TLP label of the advisory
+	stats              stats
+	expr               *util.PathEval
+	pc                 *providerContext
+	feed               *feedCheckpoint
+	hook               *hookAdvisory // stored advisory to run the hooks for
+}
+
+func newDownloadContext(
+	d *Downloader,
+	pc *providerContext,
+	feed *feedCheckpoint,
+	label csaf.TLPLabel,
+) *downloadContext {
+	client := pc.report.client(d.httpClient(pc.cfg))
+	if pc.local {
+		client = &util.FileClient{Client: client}
+	}
+	dc := &downloadContext{
+		d:      d,
+		client: client,
+		label:  label,
+		lower:  strings.ToLower(string(label)),
+		expr:   util.NewPathEval(),
+		pc:     pc,
+		feed:   feed,
+	}
+	return dc
+}
+
+// complete records that the advisory at the given URL needs
+// not to be downloaded again when resuming.
+func (dc *downloadContext) complete(url string) {
+	if dc.feed != nil {
+		dc.pc.checkpoint.complete(dc.feed, url)
+	}
+}
+
+func (dc *downloadContext) downloadAdvisory(
+	ctx context.Context,
+	file csaf.AdvisoryFile,
+	errorCh chan<- error,
+) error {
+	dc.lower = strings.ToLower(string(dc.label))
+
+	u, err := url.Parse(file.URL())
+	if err != nil {
+		dc.stats.downloadFailed++
+		dc.pc.report.failed(file.URL(), advisoryFailure{failureDownload, err.Error()})
+		dc.d.cfg.logger().Warn("Ignoring invalid URL",
+			"url", file.URL(),
+			"error", err)
+		return nil
+	}
+
+	if dc.pc.cfg.ignoreURL(file.URL()) {
+		dc.d.cfg.logger().Debug("Ignoring URL", "url", file.URL())
+		dc.complete(file.URL())
+		return nil
+	}
+
+	// Ignore not conforming filenames.
+	filename := filepath.Base(u.Path)
+	if !util.ConformingFileName(filename) {
+		dc.stats.filenameFailed++
+		errorCh <- csafErrs.ErrInvalidCsaf{Message: fmt.Sprintf("CSAF has non conforming filename %s", filename)}
+		dc.pc.report.failed(file.URL(), advisoryFailure{failureFilenameInvalid, "non conforming filename " + filename})
+		dc.d.cfg.logger().Warn("Ignoring none conforming filename",
+			"filename", filename)
+		return nil
+	}
+
+	resp, err := dc.client.Get(file.URL())
+	if err != nil {
+		dc.stats.downloadFailed++
+		errorCh <- csafErrs.ErrNetwork{Message: fmt.Sprintf("can't retrieve CSAF document %s from URL %s: %v", filename, file.URL(), err)}
+		dc.pc.report.failed(file.URL(), advisoryFailure{failureDownload, err.Error()})
+		dc.d.cfg.logger().Warn("Cannot GET",
+			"url", file.URL(),
+			"error", err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == httpext.StatusNGINXInvalidClientCert || resp.StatusCode == httpext.StatusNGINXNoClientCert:
+			errorCh <- csafErrs.ErrInvalidCredentials{Message: fmt.Sprintf("invalid credentials to retrieve CSAF document %s at URL %s: %s", filename, file.URL(), resp.Status)}
+		case resp.StatusCode == http.StatusForbidden:
+			// if we have access to the feed containing the document, we also must have access to itself, otherwise this indicates a problem with the provider
+			errorCh <- csafErrs.ErrCsafProviderIssue{Message: fmt.Sprintf("access denied to CSAF document %s at URL %s: %s", filename, file.URL(), resp.Status)}
+		case resp.StatusCode == http.StatusNotFound:
+			errorCh <- csafErrs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not find CSAF document %s listed in table of content at URL %s: %s ", filename, file.URL(), resp.Status)}
+		case resp.StatusCode >= 500:
+			providerErr := csafErrs.ErrCsafProviderIssue{Message: fmt.Sprintf("could not retrieve CSAF document %s at URL %s: %s", filename, file.URL(), resp.Status)}
+			errorCh <- fmt.Errorf("%w %w", providerErr, csafErrs.ErrRetryable) // mark error as retryable as failure for server side errors are often temporary
+		default: // client error or fringe case
+			errorCh <- fmt.Errorf("could not retrieve CSAF document %s at URL %s: %s", filename, file.URL(), resp.Status)
+		}
+		dc.stats.downloadFailed++
+		dc.pc.report.failed(file.URL(), advisoryFailure{failureDownload, resp.Status})
+		dc.d.cfg.logger().Warn("Cannot load",
+			"url", file.URL(),
+			"status", resp.Status,
+			"status_code", resp.StatusCode)
+		return nil
+	}
+
+	// Warn if we do not get JSON.
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		dc.d.cfg.logger().Warn("Content type is not 'application/json'",
+			"url", file.URL(),
+			"content_type", ct)
+	}
+
+	var (
+		writers                    []io.Writer
+		s256, s512                 hash.Hash
+		s256Data, s512Data         []byte
+		remoteSHA256, remoteSHA512 []byte
+		signData                   []byte
+	)
+
+	hashToFetch := []hashFetchInfo{}
+	if file.SHA512URL() != "" {
+		hashToFetch = append(hashToFetch, hashFetchInfo{
+			url:       file.SHA512URL(),
+			warn:      true,
+			hashType:  algSha512,
+			preferred: strings.EqualFold(string(dc.d.cfg.PreferredHash), string(algSha512)),
+		})
+	} else {
+		dc.d.cfg.logger().Debug("SHA512 not present")
+	}
+	if file.SHA256URL() != "" {
+		hashToFetch = append(hashToFetch, hashFetchInfo{
+			url:       file.SHA256URL(),
+			warn:      true,
+			hashType:  algSha256,
+			preferred: strings.EqualFold(string(dc.d.cfg.PreferredHash), string(algSha256)),
+		})
+	} else {
+		dc.d.cfg.logger().Debug("SHA256 not present")
+	}
+	if file.IsDirectory() {
+		for i := range hashToFetch {
+			hashToFetch[i].warn = false
+		}
+	}
+
+	remoteSHA256, s256Data, remoteSHA512, s512Data = loadHashes(dc.d.cfg.logger(), dc.client, hashToFetch)
+	if remoteSHA512 != nil {
+		s512 = sha512.New()
+		writers = append(writers, s512)
+	}
+	if remoteSHA256 != nil {
+		s256 = sha256.New()
+		writers = append(writers, s256)
+	}
+
+	// Remember the data as we need to store it to file later.
+	dc.data.Reset()
+	writers = append(writers, &dc.data)
+
+	// Download the advisory and hash it.
+	hasher := io.MultiWriter(writers...)
+
+	var doc any
+
+	tee := io.TeeReader(resp.Body, hasher)
+
+	if err := misc.StrictJSONParse(tee, &doc); err != nil {
+		dc.stats.downloadFailed++
+		errorCh <- csafErrs.ErrInvalidCsaf{Message: fmt.Sprintf("CSAF document %s at URL %s is not valid json: %v", filename, file.URL(), err)}
+		dc.d.cfg.logger().Warn("Downloading failed",
+			"url", file.URL(),
+			"error", err)
+		failures := []advisoryFailure{{Class: failureInvalidJSON, Message: err.Error()}}
+		dc.pc.report.failed(file.URL(), failures...)
+		if dc.d.cfg.Quarantine {
+			// Keep the complete data as evidence.
+			io.Copy(io.Discard, tee)
+			dc.quarantine(&quarantined{
+				file:     file,
+				filename: filename,
+				failures: failures,
+			})
+		}
+		return nil
+	}
+
+	// Advisories of directory based distributions
+	// are filed under the TLP labels of the documents.
+	label, ok := csaf.AdvisoryTLPLabel(dc.expr, file, dc.label, doc)
+	if !ok {
+		dc.d.cfg.logger().Warn("Advisory has no TLP label",
+			"url", file.URL(),
+			"label", label)
+	}
+	dc.lower = strings.ToLower(string(label))
+
+	// Compare the checksums.
+	s256Check := func() error {
+		if s256 != nil && !bytes.Equal(s256.Sum(nil), remoteSHA256) {
+			dc.stats.sha256Failed++
+			errorCh <- csafErrs.ErrCsafProviderIssue{Message: fmt.Sprintf("SHA256 checksum of CSAF document %s at URL %s does not match", filename, file.URL())}
+			return &checkError{failureSHA256, fmt.Errorf("SHA256 checksum of %s does not match", file.URL())}
+		}
+		return nil
+	}
+
+	s512Check := func() error {
+		if s512 != nil && !bytes.Equal(s512.Sum(nil), remoteSHA512) {
+			dc.stats.sha512Failed++
+			errorCh <- csafErrs.ErrCsafProviderIssue{Message: fmt.Sprintf("SHA512 checksum of CSAF document %s at URL %s does not match", filename, file.URL())}
+			return &checkError{failureSHA512, fmt.Errorf("SHA512 checksum of %s does not match", file.URL())}
+		}
+		return nil
+	}
+
+	// Check that there is a hash if only hashes prove the integrity.
+	hashCheck := func() error {
+		if !dc.pc.hashesOnly() || remoteSHA256 != nil || remoteSHA512 != nil {
+			return nil
+		}
+		dc.stats.hashMissing++
+		errorCh <- csafErrs.ErrCsafProviderIssue{Message: fmt.Sprintf("missing hash for CSAF document %s at URL %s", filename, file.URL())}
+		return &checkError{failureHashMissing, fmt.Errorf("missing hash for %s", file.URL())}
+	}
+
+	// Validate OpenPGP signature.
+	var signer string
+	keysCheck := func() error {
+		// Only check signature if we have loaded keys.
+		if dc.pc.hashesOnly() || dc.pc.keys == nil {
+			return nil
+		}
+		var sign *crypto.PGPSignature
+		sign, signData, err = loadSignature(dc.client, file.SignURL())
+		if err != nil {
+			dc.d.cfg.logger().Warn("Downloading signature failed",
+				"url", file.SignURL(),
+				"error", err)
+		}
+		if sign == nil {
+			if dc.pc.signatureRequired() {
+				dc.stats.signatureFailed++
+				errorCh <- csafErrs.ErrCsafProviderIssue{Message: fmt.Sprintf("missing signature for CSAF document %s at URL %s", filename, file.URL())}
+				return &checkError{failureSignatureMissing, fmt.Errorf("missing signature for %s", file.URL())}
+			}
+			return nil
+		}
+		fp, err := dc.pc.checkSignature(dc.data.Bytes(), sign)
+		if err != nil {
+			if !dc.d.cfg.IgnoreSignatureCheck || dc.pc.signatureRequired() {
+				dc.stats.signatureFailed++
+				errorCh <- csafErrs.ErrCsafProviderIssue{Message: fmt.Sprintf("cannot verify signature for CSAF document %s at URL %s: %v", filename, file.URL(), err)}
+				return &checkError{failureSignature, fmt.Errorf("cannot verify signature for %s: %v", file.URL(), err)}
+			}
+			return nil
+		}
+		signer = fp
+		dc.stats.signed++
+		dc.pc.usage.use(fp)
+		dc.d.cfg.logger().Debug("Signature verified",
+			"url", file.URL(),
+			"fingerprint", fp)
+		return nil
+	}
+
+	// Validate against CSAF schema.
+	schemaCheck := func() error {
+		if errors, err := csaf.ValidateCSAF(doc); err != nil || len(errors) > 0 {
+			dc.d.metrics.validated(dc.pc.domain, "schema", "invalid")
+			dc.stats.schemaFailed++
+			if err != nil {
+				errorCh <- fmt.Errorf("schema validation for CSAF document %s failed: %w", filename, err)
+			} else {
+				errorCh <- csafErrs.ErrInvalidCsaf{Message: fmt.Sprintf("CSAF document %s at URL %s does not conform to JSON schema: %v", filename, file.URL(), errors)}
+			}
+			dc.d.logValidationIssues(file.URL(), errors, err)
+			return &checkError{failureSchema, fmt.Errorf("schema validation for %q failed", file.URL())}
+		}
+		dc.d.metrics.validated(dc.pc.domain, "schema", "valid")
+		return nil
+	}
+
+	// Validate if filename is conforming.
+	filenameCheck := func() error {
+		if err := util.IDMatchesFilename(dc.expr, doc, filename); err != nil {
+			dc.stats.filenameFailed++
+			errorCh <- csafErrs.ErrInvalidCsaf{Message: fmt.Sprintf("invalid CSAF document %s at URL %s: %v", filename, file.URL(), err)}
+			return &checkError{failureFilename, fmt.Errorf("filename not conforming %s: %s", file.URL(), err)}
+		}
+		return nil
+	}
+
+	// Validate if the TLP label matches the one of the feed.
+	// This is only done when quarantining advisories.
+	// Directory based distributions have no TLP labels.
+	tlpCheck := func() error {
+		if !dc.d.cfg.Quarantine || file.IsDirectory() || dc.label == csaf.TLPLabelUnlabeled {
+			return nil
+		}
+		label := csaf.DocumentTLPLabel(dc.expr, doc)
+		if label == "" || label == csaf.NormalizeTLPLabel(string(dc.label)) {
+			return nil
+		}
+		dc.stats.tlpMismatch++
+		errorCh <- csafErrs.ErrCsafProviderIssue{Message: fmt.Sprintf("TLP:%s of CSAF document %s at URL %s does not match TLP:%s of feed", label, filename, file.URL(), strings.ToUpper(dc.lower))}
+		return &checkError{failureTLP, fmt.Errorf("TLP:%s of %s does not match TLP:%s of feed", label, file.URL(), strings.ToUpper(dc.lower))}
+	}
+
+	// Validate against remote validator.
+	remoteValidatorCheck := func() error {
+		if dc.d.validator == nil {
+			return nil
+		}
+		rvr, err := dc.d.validator.Validate(doc)
+		if err != nil {
+			dc.d.metrics.validated(dc.pc.domain, "remote", "error")
+			errorCh <- fmt.Errorf(
+				"calling remote validator on %q failed: %w",
+				file.URL(), err)
+			return nil
+		}
+		if !rvr.Valid {
+			dc.d.metrics.validated(dc.pc.domain, "remote", "invalid")
+			dc.stats.remoteFailed++
+			errorCh <- csafErrs.ErrInvalidCsaf{Message: fmt.Sprintf("remote validation of CSAF document %s at URL %s failed", filename, file.URL())}
+			return &checkError{failureRemote, fmt.Errorf("remote validation of %q failed", file.URL())}
+		}
+		dc.d.metrics.validated(dc.pc.domain, "remote", "valid")
+		return nil
+	}
+
+	// Run all the validations.
+	valStatus := notValidatedValidationStatus
+	var failures []advisoryFailure
+	for _, check := range []func() error{
+		s256Check,
+		s512Check,
+		hashCheck,
+		keysCheck,
+		schemaCheck,
+		filenameCheck,
+		tlpCheck,
+		remoteValidatorCheck,
+	} {
+		if err := check(); err != nil {
+			dc.d.cfg.logger().Error("Validation check failed", "error", err)
+			valStatus.update(invalidValidationStatus)
+			failures = append(failures, newAdvisoryFailure(err))
+			if dc.pc.cfg.ValidationMode == ValidationStrict {
+				break
+			}
+		}
+	}
+	if len(failures) > 0 {
+		dc.pc.report.failed(file.URL(), failures...)
+		dc.quarantine(&quarantined{
+			file:         file,
+			filename:     filename,
+			doc:          doc,
+			failures:     failures,
+			remoteSHA256: remoteSHA256,
+			remoteSHA512: remoteSHA512,
+			s256Data:     s256Data,
+			s512Data:     s512Data,
+			signData:     signData,
+		})
+		if dc.pc.cfg.ValidationMode == ValidationStrict {
+			return nil
+		}
+	}
+	valStatus.update(validValidationStatus)
+
+	// Apply the filters on the content.
+	if df := dc.d.cfg.docFilter; df != nil {
+		if accept, reason := df.Accept(dc.expr, doc); !accept {
+			dc.stats.filtered++
+			dc.d.cfg.logger().Debug("Advisory filtered",
+				"url", file.URL(),
+				"reason", reason)
+			dc.complete(file.URL())
+			return nil
+		}
+	}
+
+	// Send to forwarders
+	if len(dc.d.Forwarders) > 0 {
+		meta := &forwardMeta{
+			Domain:      dc.pc.domain,
+			URL:         file.URL(),
+			TLP:         csaf.TLPLabel(strings.ToUpper(dc.lower)),
+			Signature:   string(signatureStatusOf(signer, failures)),
+			Fingerprint: signer,
+		}
+		doc := dc.data.String()
+		for _, f := range dc.d.Forwarders {
+			f.forward(
+				filename, doc,
+				valStatus,
+				string(s256Data),
+				string(s512Data),
+				meta)
+		}
+	}
+
+	if err := dc.handle(file, filename, doc, signer, valStatus, failures); err != nil {
+		return err
+	}
+
+	if dc.d.cfg.ForwardChannel {
+		// the bytes slice is modified by the next buffer modification, so we need to copy it
+		dc.d.Csafs <- slices.Clone(dc.data.Bytes())
+	}
+
+	if dc.d.cfg.NoStore {
+		// Do not write locally.
+		if valStatus == validValidationStatus {
+			dc.stats.succeeded++
+		}
+		return nil
+	}
+
+	var initial string
+	if md, ok := csaf.DecodeDocumentMetadata(doc); ok {
+		initial = md.InitialReleaseDate
+	}
+	if dc.initialReleaseDate, err = time.Parse(time.RFC3339, initial); err != nil {
+		dc.d.cfg.logger().Warn("Cannot extract initial_release_date from advisory",
+			"url", file.URL())
+		dc.initialReleaseDate = time.Now()
+	}
+	dc.initialReleaseDate = dc.initialReleaseDate.UTC()
+
+	// Advisories that failed validation are stored in a special folder.
+	var newDir string
+	if valStatus != validValidationStatus {
+		newDir = failedValidationDir
+	}
+
+	// Do we have a configured destination folder or path template?
+	switch {
+	case dc.pc.cfg.pathTemplate != nil:
+		newDir = path.Join(newDir, dc.pc.cfg.pathTemplate.expand(dc.extractPathValues(doc)))
+	case dc.pc.cfg.Folder != "":
+		newDir = path.Join(newDir, dc.pc.cfg.Folder)
+	default:
+		newDir = path.Join(newDir, dc.lower, strconv.Itoa(dc.initialReleaseDate.Year()))
+	}
+
+	// Name of the advisory in the storage.
+	name := path.Join(newDir, filename)
+
+	ri, err := extractRevisionInfo(dc.expr, doc)
+	if err != nil {
+		dc.d.cfg.logger().Warn("Cannot extract tracking information from advisory",
+			"url", file.URL(),
+			"error", err)
+	}
+
+	// Keep all distinct revisions if requested.
+	if ri != nil && dc.d.cfg.KeepRevisions && valStatus == validValidationStatus {
+		if err := dc.d.storeRevision(
+			dc.pc.domain, filename, file.URL(), ri,
+			dc.data.Bytes(), s256Data, s512Data, signData,
+		); err != nil {
+			dc.d.cfg.logger().Error("Storing revision failed",
+				"url", file.URL(),
+				"error", err)
+		}
+	}
+
+	// Do not replace a newer local revision with an older one.
+	if ri != nil {
+		if local, newer := newerLocalRevision(dc.d.storage, name, ri.version); newer {
+			dc.stats.versionRegression++
+			dc.d.cfg.logger().Error("Refusing to overwrite newer local revision",
+				"url", file.URL(),
+				"path", dc.d.location(name),
+				"version", ri.version,
+				"local_version", local)
+			dc.complete(file.URL())
+			return nil
+		}
+	}
+
+	// The hooks are interested in changed advisories.
+	changed := true
+	if dc.d.hooks != nil {
+		if old, err := dc.d.storage.ReadFile(name); err == nil {
+			changed = !bytes.Equal(old, dc.data.Bytes())
+		}
+	}
+
+	// Write data to storage.
+	for _, x := range []struct {
+		n string
+		d []byte
+	}{
+		{name, dc.data.Bytes()},
+		{name + ".sha256", s256Data},
+		{name + ".sha512", s512Data},
+		{name + ".asc", signData},
+	} {
+		if x.d != nil {
+			if err := dc.d.storage.WriteFile(x.n, x.d); err != nil {
+				errorCh <- err
+				return nil
+			}
+		}
+	}
+
+	if dc.pc.stored != nil {
+		dc.pc.stored.store(file.URL(), name)
+	}
+	if dc.pc.sources != nil {
+		dc.pc.sources.record(name, dc.pc.source)
+	}
+
+	dc.stats.succeeded++
+	dc.complete(file.URL())
+	attrs := []any{"path", dc.d.location(name)}
+	if dc.pc.mirror != "" {
+		attrs = append(attrs, "mirror", dc.pc.mirror)
+	}
+	if signer != "" {
+		attrs = append(attrs, "fingerprint", signer)
+	}
+	dc.d.cfg.logger().Info("Written advisory", attrs...)
+
+	if err := dc.d.bundle.advisory(&bundleAdvisory{
+		Name:        name,
+		Domain:      dc.pc.domain,
+		URL:         file.URL(),
+		TLP:         csaf.TLPLabel(strings.ToUpper(dc.lower)),
+		Fingerprint: signer,
+	}, dc.data.Bytes(), s256Data, s512Data, signData); err != nil {
+		errorCh <- fmt.Errorf("adding advisory to bundle failed: %w", err)
+		return nil
+	}
+
+	if dc.d.hooks != nil {
+		dc.hook = &hookAdvisory{
+			Path:             dc.d.location(name),
+			Name:             name,
+			Filename:         filename,
+			Domain:           dc.pc.domain,
+			URL:              file.URL(),
+			TLP:              csaf.TLPLabel(strings.ToUpper(dc.lower)),
+			ValidationStatus: valStatus,
+			Signature:        signatureStatusOf(signer, failures),
+			Fingerprint:      signer,
+			Changed:          changed,
+		}
+	}
+	return nil
+}
+
+// runHook runs the hooks for the advisory stored last. It is
+// called after the slot of the worker budget is released so
+// that slow hooks do not hold up the downloads.
+func (dc *downloadContext) runHook(ctx context.Context) {
+	adv := dc.hook
+	if adv == nil {
+		return
+	}
+	dc.hook = nil
+	if err := dc.d.hooks.stored(ctx, adv); err != nil {
+		dc.stats.hookFailed++
+		dc.d.cfg.logger().Error("Running hook failed",
+			"path", adv.Path,
+			"error", err)
+	}
+}
+
+func (d *Downloader) downloadWorker(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	pc *providerContext,
+	feed *feedCheckpoint,
+	report *feedReport,
+	label csaf.TLPLabel,
+	files <-chan csaf.AdvisoryFile,
+	errorCh chan<- error,
+) {
+	defer wg.Done()
+
+	dc := newDownloadContext(d, pc, feed, label)
+
+	// Add collected stats back to total.
+	defer func() {
+		d.addStats(pc.domain, &dc.stats)
+		report.add(&dc.stats)
+	}()
+
+	for {
+		var file csaf.AdvisoryFile
+		var ok bool
+		select {
+		case file, ok = <-files:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			errorCh <- context.Cause(ctx)
+			return
+		}
+		release, err := d.budget.acquire(ctx, file.URL())
+		if err != nil {
+			errorCh <- err
+			return
+		}
+		err = dc.downloadAdvisory(ctx, file, errorCh)
+		release()
+		dc.runHook(ctx)
+		if err != nil {
+			d.cfg.logger().Error("download terminated", "error", err)
+			return
+		}
+	}
+}
+
+// location returns a description of the place where the
+// file with the given name is stored for logging.
+func (d *Downloader) location(name string) string {
+	if dir, ok := d.storage.(*storage.Dir); ok {
+		return dir.Path(name)
+	}
+	return name
+}
+
+func loadSignature(client util.Client, p string) (*crypto.PGPSignature, []byte, error) {
+	resp, err := client.Get(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf(
+			"fetching signature from '%s' failed: %s (%d)", p, resp.Status, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	sign, err := crypto.NewPGPSignatureFromArmored(string(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	return sign, data, nil
+}
+
+func loadHashes(
+	logger *slog.Logger,
+	client util.Client,
+	hashes []hashFetchInfo,
+) ([]byte, []byte, []byte, []byte) {
+	var remoteSha256, remoteSha512, sha256Data, sha512Data []byte
+
+	// Load preferred hashes first
+	slices.SortStableFunc(hashes, func(a, b hashFetchInfo) int {
+		if a.preferred == b.preferred {
+			return 0
+		}
+		if a.preferred && !b.preferred {
+			return -1
+		}
+		return 1
+	})
+	for _, h := range hashes {
+		if remote, data, err := loadHash(client, h.url); err != nil {
+			if h.warn {
+				logger.Warn("Cannot fetch hash",
+					"hash", h.hashType,
+					"url", h.url,
+					"error", err)
+			} else {
+				logger.Info("Hash not present", "hash", h.hashType, "file", h.url)
+			}
+		} else {
+			switch h.hashType {
+			case algSha512:
+				{
+					remoteSha512 = remote
+					sha512Data = data
+				}
+			case algSha256:
+				{
+					remoteSha256 = remote
+					sha256Data = data
+				}
+			}
+			if h.preferred {
+				break
+			}
+		}
+	}
+	return remoteSha256, sha256Data, remoteSha512, sha512Data
+}
+
+func loadHash(client util.Client, p string) ([]byte, []byte, error) {
+	resp, err := client.Get(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf(
+			"fetching hash from '%s' failed: %s (%d)", p, resp.Status, resp.StatusCode)
+	}
+	var data bytes.Buffer
+	tee := io.TeeReader(resp.Body, &data)
+	hash, err := util.HashFromReader(tee)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hash, data.Bytes(), nil
+}
+
+// DomainError is the error which occurred while downloading
+// the advisories of a domain.
+type DomainError struct {
+	Domain string
+	Err    error
+}
+
+func (de *DomainError) Error() string {
+	return fmt.Sprintf("domain %q: %v", de.Domain, de.Err)
+}
+
+func (de *DomainError) Unwrap() error {
+	return de.Err
+}
+
+// Run performs the downloads for all the given domains.
+// The domains are processed concurrently. A failing domain
+// does not stop the processing of the others. The errors
+// of the domains are returned joined as [DomainError]s.
+func (d *Downloader) Run(ctx context.Context, domains []string) (err error) {
+	defer d.logStats()
+
+	if d.cfg.Report != "" {
+		d.report = newRunReport()
+		defer func() {
+			d.report.fail(err)
+			if rerr := d.writeReport(); rerr != nil {
+				err = errors.Join(err, fmt.Errorf("writing report failed: %w", rerr))
+			}
+		}()
+	}
+
+	if d.cfg.MetricsFile != "" {
+		defer func() {
+			if merr := d.writeMetrics(); merr != nil {
+				err = errors.Join(err, fmt.Errorf("writing metrics failed: %w", merr))
+			}
+		}()
+	}
+	d.metrics.forwarders(d.Forwarders)
+
+	if domains, err = d.prepareDomains(domains); err != nil {
+		return err
+	}
+
+	if d.bundle, err = newBundle(d.cfg); err != nil {
+		return fmt.Errorf("preparing bundle failed: %w", err)
+	}
+
+	var (
+		domainErrs = make([]error, len(domains), len(domains)+1)
+		indices    = make(chan int)
+		wg         sync.WaitGroup
+	)
+
+	n := min(max(d.cfg.Parallel, 1), len(domains))
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				err := d.download(ctx, domains[i])
+				d.report.domain(domains[i]).finish(err)
+				d.metrics.finished(domains[i], err)
+				if err != nil {
+					d.cfg.logger().Error("Downloading from domain failed",
+						"domain", domains[i],
+						"error", err)
+					domainErrs[i] = &DomainError{Domain: domains[i], Err: err}
+				}
+			}
+		}()
+	}
+
+allDomains:
+	for i := range domains {
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			domainErrs = append(domainErrs, context.Cause(ctx))
+			break allDomains
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	// An interrupted run leaves no incomplete bundle behind.
+	if ctx.Err() != nil {
+		d.bundle.abort()
+	} else if err := d.bundle.finish(); err != nil {
+		d.cfg.logger().Error("Writing bundle failed", "error", err)
+		domainErrs = append(domainErrs, fmt.Errorf("writing bundle failed: %w", err))
+	}
+
+	if err := d.writeProviderTree(); err != nil {
+		d.cfg.logger().Error("Writing provider tree failed", "error", err)
+		domainErrs = append(domainErrs, err)
+	}
+
+	if err := d.hooks.batch(ctx, ""); err != nil {
+		d.cfg.logger().Error("Running batch hook failed", "error", err)
+		domainErrs = append(domainErrs, err)
+	}
+
+	return errors.Join(domainErrs...)
+}
+
+// prepareDomains loads the configured aggregator and
+// returns the domains to download from.
+func (d *Downloader) prepareDomains(domains []string) ([]string, error) {
+	if d.cfg.Aggregator == "" {
+		return domains, nil
+	}
+	var err error
+	if d.mirrors, err = d.loadAggregator(); err != nil {
+		return nil, err
+	}
+	// Download all listed providers if no domains are given.
+	if len(domains) == 0 {
+		domains = d.mirrors.domains()
+	}
+	return domains, nil
+}
+
+// runEnumerate performs the enumeration of PMDs for all the given domains.
+func (d *Downloader) RunEnumerate(domains []string) error {
+	defer d.stats.log(d.cfg.logger())
+	for _, domain := range domains {
+		if err := d.enumerate(domain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// proxyFromEnvironment sets the proxy from the environment variables.
+// The proxy is set as documented in [http.ProxyFromEnvironment],
+// However the http(s) proxy can be also set via env vars `CSAF_DL_HTTP_PROXY`
+// and `CSAF_DL_HTTPS_PROXY`. If set to a non empty string, they will take precedence
+// over `http_proxy` and `https_proxy` and also their upper case versions.
+// The purpose is to allow setting the proxy specifically for the CSAF downloader.
+func proxyFromEnvironment(req *http.Request) (*url.URL, error) {
+	cfg := httpproxy.FromEnvironment()
+
+	csafHTTPProxy := os.Getenv("CSAF_DL_HTTP_PROXY")
+	if csafHTTPProxy != "" {
+		cfg.HTTPProxy = csafHTTPProxy
+	}
+	csafHTTPSProxy := os.Getenv("CSAF_DL_HTTPS_PROXY")
+	if csafHTTPSProxy != "" {
+		cfg.HTTPSProxy = csafHTTPSProxy
+	}
+
+	return cfg.ProxyFunc()(req.URL)
+}
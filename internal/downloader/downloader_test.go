@@ -6,9 +6,10 @@
 // SPDX-FileCopyrightText: 2023 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
 // Software-Engineering: 2023 Intevation GmbH <https://intevation.de>
 
-package csaf_downloader
+package downloader
 
 import (
+	"archive/zip"
 	"context"
 	"errors"
 	"log/slog"
@@ -161,6 +162,183 @@ func TestShaMarking(t *testing.T) {
 	}
 }
 
+func TestDownloadToArchive(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client := util.Client(server.Client())
+
+	tempDir := t.TempDir()
+	cfg := Config{
+		LogLevel:  &options.LogLevel{Level: slog.LevelError},
+		Directory: tempDir,
+		Storage:   StorageZip,
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+	d, err := NewDownloader(&cfg)
+	if err != nil {
+		t.Fatalf("could not init downloader: %v", err)
+	}
+	d.client = &client
+
+	if err := d.Run(context.Background(), []string{server.URL + "/provider-metadata.json"}); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	d.Close()
+
+	zr, err := zip.OpenReader(cfg.archivePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, "white/2020/avendor-advisory-0004.json")
+	assert.Contains(t, names, "white/2020/avendor-advisory-0004.json.asc")
+
+	if checkIfFileExists(tempDir+"/white/2020/avendor-advisory-0004.json", t) {
+		t.Error("advisory was written to directory")
+	}
+}
+
+func TestDownloadDomainFolder(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client := util.Client(server.Client())
+	domain := server.URL + "/provider-metadata.json"
+
+	tempDir := t.TempDir()
+	cfg := Config{
+		LogLevel:  &options.LogLevel{Level: slog.LevelError},
+		Directory: tempDir,
+		Domains: []*DomainConfig{
+			{Domain: domain, Folder: toPtr("vendor"), Worker: toPtr(1)},
+		},
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+	d, err := NewDownloader(&cfg)
+	if err != nil {
+		t.Fatalf("could not init downloader: %v", err)
+	}
+	defer d.Close()
+	d.client = &client
+
+	if err := d.Run(context.Background(), []string{domain}); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if !checkIfFileExists(tempDir+"/vendor/avendor-advisory-0004.json", t) {
+		t.Error("advisory was not written to domain specific folder")
+	}
+}
+
+func TestRunContinuesAfterFailingDomain(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client := util.Client(server.Client())
+	failing := server.URL + "/missing/provider-metadata.json"
+	working := server.URL + "/provider-metadata.json"
+
+	tempDir := t.TempDir()
+	cfg := Config{
+		LogLevel:  &options.LogLevel{Level: slog.LevelError},
+		Directory: tempDir,
+		Parallel:  2,
+		MaxWorker: 1,
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+	d, err := NewDownloader(&cfg)
+	if err != nil {
+		t.Fatalf("could not init downloader: %v", err)
+	}
+	defer d.Close()
+	d.client = &client
+
+	err = d.Run(context.Background(), []string{failing, working})
+	var de *DomainError
+	if !errors.As(err, &de) {
+		t.Fatalf("expected domain error, got: %v", err)
+	}
+	if de.Domain != failing {
+		t.Errorf("got error for domain %q, want %q", de.Domain, failing)
+	}
+	if !checkIfFileExists(tempDir+"/white/2020/avendor-advisory-0004.json", t) {
+		t.Error("advisory of working domain was not written")
+	}
+	if st := d.domainStats[working]; st == nil || st.succeeded == 0 {
+		t.Error("no stats for working domain")
+	}
+}
+
+func TestDownloadLocalProvider(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		directoryProvider bool
+		fileURL           bool
+	}{
+		{"rolie path", false, false},
+		{"rolie file URL", false, true},
+		{"directory path", true, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			providerDir := t.TempDir()
+			base, err := util.FileURL(providerDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			params := testutil.ProviderParams{URL: base, EnableSha256: true, EnableSha512: true}
+			if err := testutil.WriteProvider(providerDir, &params, tc.directoryProvider); err != nil {
+				t.Fatal(err)
+			}
+			domain := providerDir
+			if tc.fileURL {
+				domain = base + "/provider-metadata.json"
+			}
+
+			tempDir := t.TempDir()
+			cfg := Config{
+				LogLevel:  &options.LogLevel{Level: slog.LevelError},
+				Directory: tempDir,
+			}
+			if err := cfg.Prepare(); err != nil {
+				t.Fatalf("config failed: %v", err)
+			}
+			d, err := NewDownloader(&cfg)
+			if err != nil {
+				t.Fatalf("could not init downloader: %v", err)
+			}
+			defer d.Close()
+
+			if err := d.Run(context.Background(), []string{domain}); err != nil {
+				t.Errorf("expected no error, got: %v", err)
+			}
+			for _, name := range []string{
+				"/white/2020/avendor-advisory-0004.json",
+				"/white/2020/avendor-advisory-0004.json.asc",
+			} {
+				if !checkIfFileExists(tempDir+name, t) {
+					t.Errorf("%s was not written", name)
+				}
+			}
+		})
+	}
+}
+
 func toPtr[T any](v T) *T {
 	return &v
 }
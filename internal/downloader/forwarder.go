@@ -0,0 +1,405 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2023 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2023 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/internal/metrics"
+	"github.com/gocsaf/csaf/v3/internal/misc"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// failedForwardDir is the name of the special sub folder
+// where advisories get queued which fail forwarding.
+const failedForwardDir = "failed_forward"
+
+// validationStatus represents the validation status
+// known to the HTTP endpoint.
+type validationStatus string
+
+const (
+	validValidationStatus        = validationStatus("valid")
+	invalidValidationStatus      = validationStatus("invalid")
+	notValidatedValidationStatus = validationStatus("not_validated")
+)
+
+func (vs *validationStatus) update(status validationStatus) {
+	// Cannot heal after it fails at least once.
+	if *vs != invalidValidationStatus {
+		*vs = status
+	}
+}
+
+// Forwarder forwards downloaded advisories to a given
+// HTTP endpoint.
+type Forwarder struct {
+	cfg    *Config
+	target *ForwardTarget
+	cmds   chan func(*Forwarder)
+	client util.Client
+
+	failed    int
+	succeeded int
+
+	// queue are the retry states of the advisories
+	// failed forwarding by their file names.
+	queue map[string]*forwardItem
+	// pending is the length of the queue.
+	pending atomic.Int64
+
+	// forwarded records the forwarded advisories if not nil.
+	forwarded *metrics.Vec
+}
+
+// NewForwarder creates a new forwarder to the given target.
+func NewForwarder(cfg *Config, target *ForwardTarget) *Forwarder {
+	queue := cfg.ForwardQueue
+	if queue < 1 {
+		queue = 1
+	}
+	return &Forwarder{
+		cfg:    cfg,
+		target: target,
+		cmds:   make(chan func(*Forwarder), queue),
+	}
+}
+
+// NewForwarders creates a forwarder for each of the configured targets.
+func NewForwarders(cfg *Config) []*Forwarder {
+	fs := make([]*Forwarder, 0, len(cfg.forwardTargets))
+	for _, target := range cfg.forwardTargets {
+		fs = append(fs, NewForwarder(cfg, target))
+	}
+	return fs
+}
+
+// Run runs the forwarder. Meant to be used in a Go routine.
+// The advisories failed forwarding in previous runs are
+// retried first. The ones failing again are retried with
+// an increasing delay while the forwarder runs.
+func (f *Forwarder) Run() {
+	defer f.cfg.logger().Debug("forwarder done")
+
+	if err := f.loadQueue(); err != nil {
+		f.cfg.logger().Error("Loading forward queue failed", "error", err)
+	}
+	f.retryDue(time.Time{})
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	reset := func() {
+		timer.Stop()
+		if next, ok := f.nextRetry(); ok {
+			timer.Reset(max(time.Until(next), 0))
+		}
+	}
+	reset()
+
+	for {
+		select {
+		case cmd, ok := <-f.cmds:
+			if !ok {
+				return
+			}
+			cmd(f)
+		case <-timer.C:
+			f.retryDue(time.Now())
+		}
+		reset()
+	}
+}
+
+// close terminates the forwarder.
+func (f *Forwarder) Close() {
+	close(f.cmds)
+}
+
+// log logs the current statistics.
+func (f *Forwarder) Log() {
+	f.cmds <- (*Forwarder).logStats
+}
+
+// logStats logs the current statistics.
+func (f *Forwarder) logStats() {
+	f.cfg.logger().Info("Forward statistics",
+		"target", f.target.Name,
+		"succeeded", f.succeeded,
+		"failed", f.failed,
+		"queued", len(f.queue))
+}
+
+// httpClient returns a cached HTTP client used for uploading
+// the advisories to the configured HTTP endpoint.
+func (f *Forwarder) httpClient() util.Client {
+	if f.client != nil {
+		return f.client
+	}
+
+	hClient := http.Client{}
+
+	var tlsConfig tls.Config
+	if f.target.Insecure {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if len(f.target.clientCerts) != 0 {
+		tlsConfig.Certificates = f.target.clientCerts
+	}
+
+	hClient.Transport = &http.Transport{
+		TLSClientConfig: &tlsConfig,
+		Proxy:           http.ProxyFromEnvironment,
+	}
+
+	client := util.Client(&hClient)
+
+	// Add extra headers.
+	client = &util.HeaderClient{
+		Client: client,
+		Header: f.target.Header,
+	}
+
+	// Add optional URL logging.
+	if f.cfg.verbose() {
+		client = &util.LoggingClient{
+			Client: client,
+			Log:    httpLog(f.cfg.logger(), "forwarder"),
+		}
+	}
+
+	f.client = client
+	return f.client
+}
+
+// replaceExt replaces the extension of a given filename.
+func replaceExt(fname, nExt string) string {
+	ext := filepath.Ext(fname)
+	return fname[:len(fname)-len(ext)] + nExt
+}
+
+// buildRequest creates an HTTP request suited to forward
+// the given advisory in the format of the target.
+func (f *Forwarder) buildRequest(
+	filename, doc string,
+	status validationStatus,
+	sha256, sha512 string,
+	meta *forwardMeta,
+) (*http.Request, error) {
+	var (
+		body        []byte
+		contentType = "application/json"
+		err         error
+	)
+	switch f.target.Format {
+	case ForwardJSON:
+		body, err = buildJSON(filename, doc, status, sha256, sha512, meta)
+	case ForwardRaw:
+		body = []byte(doc)
+	default:
+		body, contentType, err = buildMultipart(filename, doc, status, sha256, sha512)
+	}
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, f.target.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
+
+// buildMultipart creates the multipart form of the given advisory.
+// Returns the form and its content type.
+func buildMultipart(
+	filename, doc string,
+	status validationStatus,
+	sha256, sha512 string,
+) ([]byte, string, error) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+
+	var err error
+	part := func(name, fname, mimeType, content string) {
+		if err != nil {
+			return
+		}
+		if fname == "" {
+			err = writer.WriteField(name, content)
+			return
+		}
+		var w io.Writer
+		if w, err = misc.CreateFormFile(writer, name, fname, mimeType); err == nil {
+			_, err = w.Write([]byte(content))
+		}
+	}
+
+	base := filepath.Base(filename)
+	part("advisory", base, "application/json", doc)
+	part("validation_status", "", "text/plain", string(status))
+	if sha256 != "" {
+		part("hash-256", replaceExt(base, ".sha256"), "text/plain", sha256)
+	}
+	if sha512 != "" {
+		part("hash-512", replaceExt(base, ".sha512"), "text/plain", sha512)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// storeFailedAdvisory stores an advisory in a special folder
+// in case the forwarding failed.
+func (f *Forwarder) storeFailedAdvisory(filename, doc, sha256, sha512 string) error {
+	// Create special folder if it does not exist.
+	dir := f.queueDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// Store parts which are not empty.
+	for _, x := range []struct {
+		p string
+		d string
+	}{
+		{filename, doc},
+		{filename + ".sha256", sha256},
+		{filename + ".sha512", sha512},
+	} {
+		if len(x.d) != 0 {
+			path := filepath.Join(dir, x.p)
+			if err := os.WriteFile(path, []byte(x.d), 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// storeFailed is a logging wrapper around storeFailedAdvisory.
+// The advisory is queued to be retried later.
+func (f *Forwarder) storeFailed(
+	filename, doc string,
+	status validationStatus,
+	sha256, sha512 string,
+	meta *forwardMeta,
+	reason error,
+) {
+	f.failed++
+	f.forwarded.Inc(f.target.Name, "failed")
+	if err := f.storeFailedAdvisory(filename, doc, sha256, sha512); err != nil {
+		f.cfg.logger().Error("Storing advisory failed forwarding failed",
+			"error", err)
+		return
+	}
+	item := &forwardItem{Status: status, Meta: meta}
+	item.failed(reason, time.Now())
+	if err := f.enqueue(filename, item); err != nil {
+		f.cfg.logger().Error("Storing forward state failed",
+			"filename", filename,
+			"error", err)
+	}
+}
+
+// limitedString reads max bytes from reader and returns it as a string.
+// Longer strings are indicated by "..." as a suffix.
+func limitedString(r io.Reader, maxLength int) (string, error) {
+	var msg strings.Builder
+	if _, err := io.Copy(&msg, io.LimitReader(r, int64(maxLength))); err != nil {
+		return "", err
+	}
+	if msg.Len() >= maxLength {
+		msg.WriteString("...")
+	}
+	return msg.String(), nil
+}
+
+// send sends a given document with filename, status
+// and checksums to the HTTP endpoint.
+func (f *Forwarder) send(
+	filename, doc string,
+	status validationStatus,
+	sha256, sha512 string,
+	meta *forwardMeta,
+) error {
+	req, err := f.buildRequest(filename, doc, status, sha256, sha512, meta)
+	if err != nil {
+		return fmt.Errorf("building forward request failed: %w", err)
+	}
+	res, err := f.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("sending forward request failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		msg, err := limitedString(res.Body, 512)
+		if err != nil {
+			return fmt.Errorf("reading forward result failed: %w", err)
+		}
+		return fmt.Errorf("forwarding failed with status code %d: %s",
+			res.StatusCode, msg)
+	}
+	return nil
+}
+
+// forward sends a given document with filename, status,
+// checksums and metadata to the forwarder if the target
+// accepts it. This is async to the degree till the
+// configured queue size is filled.
+func (f *Forwarder) forward(
+	filename, doc string,
+	status validationStatus,
+	sha256, sha512 string,
+	meta *forwardMeta,
+) {
+	if !f.target.accepts(status, meta) {
+		f.cfg.logger().Debug("forwarding skipped by target filter",
+			"target", f.target.Name,
+			"filename", filename)
+		return
+	}
+	// Run this in the main loop of the forwarder.
+	f.cmds <- func(f *Forwarder) {
+		if err := f.send(filename, doc, status, sha256, sha512, meta); err != nil {
+			f.cfg.logger().Error("forwarding failed",
+				"target", f.target.Name,
+				"filename", filename,
+				"error", err)
+			f.storeFailed(filename, doc, status, sha256, sha512, meta, err)
+			return
+		}
+		f.succeeded++
+		f.forwarded.Inc(f.target.Name, "succeeded")
+		f.cfg.logger().Debug(
+			"forwarding succeeded",
+			"target", f.target.Name,
+			"filename", filename)
+		// An older version queued before is outdated now.
+		if err := f.dequeue(filename); err != nil {
+			f.cfg.logger().Error("Removing advisory from forward queue failed",
+				"filename", filename,
+				"error", err)
+		}
+	}
+}
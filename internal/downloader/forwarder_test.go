@@ -6,7 +6,7 @@
 // SPDX-FileCopyrightText: 2023 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
 // Software-Engineering: 2023 Intevation GmbH <https://intevation.de>
 
-package csaf_downloader
+package downloader
 
 import (
 	"bufio"
@@ -18,11 +18,16 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/gocsaf/csaf/v3/csaf"
 	"github.com/gocsaf/csaf/v3/pkg/options"
 	"github.com/gocsaf/csaf/v3/util"
 )
@@ -54,7 +59,7 @@ func TestForwarderLogStats(t *testing.T) {
 	slog.SetDefault(lg)
 
 	cfg := &Config{}
-	fw := NewForwarder(cfg)
+	fw := NewForwarder(cfg, &ForwardTarget{})
 	fw.failed = 11
 	fw.succeeded = 13
 
@@ -96,13 +101,14 @@ func TestForwarderLogStats(t *testing.T) {
 
 func TestForwarderHTTPClient(t *testing.T) {
 	cfg := &Config{
-		ForwardInsecure: true,
-		ForwardHeader: http.Header{
-			"User-Agent": []string{"curl/7.55.1"},
-		},
 		LogLevel: &options.LogLevel{Level: slog.LevelDebug},
 	}
-	fw := NewForwarder(cfg)
+	fw := NewForwarder(cfg, &ForwardTarget{
+		Insecure: true,
+		Header: http.Header{
+			"User-Agent": []string{"curl/7.55.1"},
+		},
+	})
 	if c1, c2 := fw.httpClient(), fw.httpClient(); c1 != c2 {
 		t.Fatal("expected to return same client twice")
 	}
@@ -124,16 +130,17 @@ func TestForwarderReplaceExtension(t *testing.T) {
 func TestForwarderBuildRequest(t *testing.T) {
 
 	// Good case ...
-	cfg := &Config{
-		ForwardURL: "https://example.com",
+	target := &ForwardTarget{
+		URL: "https://example.com",
 	}
-	fw := NewForwarder(cfg)
+	fw := NewForwarder(&Config{}, target)
 
 	req, err := fw.buildRequest(
 		"test.json", "{}",
 		invalidValidationStatus,
 		"256",
-		"512")
+		"512",
+		&forwardMeta{})
 
 	if err != nil {
 		t.Fatalf("buildRequest failed: %v", err)
@@ -205,13 +212,14 @@ func TestForwarderBuildRequest(t *testing.T) {
 	}
 
 	// Bad case ...
-	cfg.ForwardURL = "%"
+	target.URL = "%"
 
 	if _, err := fw.buildRequest(
 		"test.json", "{}",
 		invalidValidationStatus,
 		"256",
 		"512",
+		&forwardMeta{},
 	); err == nil {
 		t.Fatal("bad forward URL should result in an error")
 	}
@@ -249,7 +257,7 @@ func TestStoreFailedAdvisory(t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	cfg := &Config{Directory: dir}
-	fw := NewForwarder(cfg)
+	fw := NewForwarder(cfg, &ForwardTarget{})
 
 	badDir := filepath.Join(dir, failedForwardDir)
 	if err := os.WriteFile(badDir, []byte("test"), 0664); err != nil {
@@ -302,10 +310,10 @@ func TestStoredFailed(t *testing.T) {
 	slog.SetDefault(lg)
 
 	cfg := &Config{Directory: dir}
-	fw := NewForwarder(cfg)
+	fw := NewForwarder(cfg, &ForwardTarget{})
 
 	// An empty filename should lead to an error.
-	fw.storeFailed("", "{}", "256", "512")
+	fw.storeFailed("", "{}", invalidValidationStatus, "256", "512", &forwardMeta{}, errors.New("test"))
 
 	if fw.failed != 1 {
 		t.Fatalf("got %d expected 1", fw.failed)
@@ -387,10 +395,9 @@ func TestForwarderForward(t *testing.T) {
 	slog.SetDefault(lg)
 
 	cfg := &Config{
-		ForwardURL: "http://example.com",
-		Directory:  dir,
+		Directory: dir,
 	}
-	fw := NewForwarder(cfg)
+	fw := NewForwarder(cfg, &ForwardTarget{URL: "http://example.com"})
 
 	// Use the fact that http client is cached.
 	fw.client = &fakeClient{}
@@ -408,13 +415,14 @@ func TestForwarderForward(t *testing.T) {
 			"test.json", "{}",
 			invalidValidationStatus,
 			"256",
-			"512")
+			"512",
+			&forwardMeta{})
 	}
 
 	// Make buildRequest fail.
 	wait := make(chan struct{})
 	fw.cmds <- func(f *Forwarder) {
-		f.cfg.ForwardURL = "%"
+		f.target.URL = "%"
 		close(wait)
 	}
 	<-wait
@@ -422,9 +430,260 @@ func TestForwarderForward(t *testing.T) {
 		"test.json", "{}",
 		invalidValidationStatus,
 		"256",
-		"512")
+		"512",
+		&forwardMeta{})
 
 	fw.Close()
 
 	<-done
 }
+
+func TestForwardBackoff(t *testing.T) {
+	for _, x := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, forwardRetryMin},
+		{2, 2 * forwardRetryMin},
+		{3, 4 * forwardRetryMin},
+		{100, forwardRetryMax},
+	} {
+		if got := forwardBackoff(x.attempts); got != x.want {
+			t.Errorf("%d attempts: got %v expected %v", x.attempts, got, x.want)
+		}
+	}
+}
+
+func TestForwardQueue(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		fail     = true
+		statuses []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		statuses = append(statuses, r.FormValue("validation_status"))
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cfg := &Config{
+		Directory: dir,
+		Logger:    slog.New(slog.DiscardHandler),
+	}
+	target := &ForwardTarget{URL: server.URL}
+
+	// Forwarding fails and the advisory is queued.
+	fw := NewForwarder(cfg, target)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fw.Run()
+	}()
+	fw.forward("a.json", "{}", validValidationStatus, "256", "", &forwardMeta{})
+	fw.Close()
+	<-done
+
+	if fw.failed != 1 || fw.pending.Load() != 1 {
+		t.Fatalf("expected 1 failed and queued, got %d and %d",
+			fw.failed, fw.pending.Load())
+	}
+	queueDir := filepath.Join(dir, failedForwardDir)
+	data, err := os.ReadFile(filepath.Join(queueDir, "a.json"+forwardStateExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var item forwardItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Attempts != 1 || item.Status != validValidationStatus || item.LastError == "" {
+		t.Errorf("unexpected forward state: %+v", item)
+	}
+
+	// An advisory failed by older versions has no state.
+	if err := os.WriteFile(filepath.Join(queueDir, "b.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replaying fails again.
+	fw = NewForwarder(cfg, target)
+	if err := fw.Replay(); err == nil {
+		t.Fatal("expected replay to fail")
+	}
+	if n := len(fw.queue); n != 2 {
+		t.Fatalf("expected 2 queued advisories, got %d", n)
+	}
+	if a := fw.queue["a.json"].Attempts; a != 2 {
+		t.Errorf("expected 2 attempts, got %d", a)
+	}
+
+	// Replaying succeeds.
+	mu.Lock()
+	fail = false
+	statuses = nil
+	mu.Unlock()
+	fw = NewForwarder(cfg, target)
+	if err := fw.Replay(); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if fw.succeeded != 2 {
+		t.Errorf("expected 2 succeeded, got %d", fw.succeeded)
+	}
+	slices.Sort(statuses)
+	if want := []string{"not_validated", "valid"}; !slices.Equal(statuses, want) {
+		t.Errorf("got validation statuses %q expected %q", statuses, want)
+	}
+	entries, err := os.ReadDir(queueDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected empty queue directory, got %d files", len(entries))
+	}
+}
+
+func TestPrepareForward(t *testing.T) {
+	cfg := &Config{
+		ForwardURL: "https://example.com/api/upload",
+		ForwardTargets: []*ForwardTarget{
+			{URL: "https://other.example.com", Format: ForwardJSON, TLP: []string{"clear", "Green"}},
+			{Name: "raw", URL: "https://example.org", Format: ForwardRaw},
+		},
+	}
+	if err := cfg.prepareForward(); err != nil {
+		t.Fatalf("prepare failed: %v", err)
+	}
+	var names, queues []string
+	for _, ft := range cfg.forwardTargets {
+		names = append(names, ft.Name)
+		queues = append(queues, ft.queue)
+	}
+	if want := []string{"example.com_api_upload", "other.example.com", "raw"}; !slices.Equal(names, want) {
+		t.Errorf("got names %q expected %q", names, want)
+	}
+	if want := []string{"", "other.example.com", "raw"}; !slices.Equal(queues, want) {
+		t.Errorf("got queues %q expected %q", queues, want)
+	}
+	if cfg.forwardTargets[0].Format != ForwardMultipart {
+		t.Errorf("expected multipart format, got %q", cfg.forwardTargets[0].Format)
+	}
+	if tlp := cfg.forwardTargets[1].tlp; !slices.Equal(tlp, []csaf.TLPLabel{csaf.TLPLabelWhite, csaf.TLPLabelGreen}) {
+		t.Errorf("expected normalized TLP labels, got %q", tlp)
+	}
+
+	for _, cfg := range []*Config{
+		{ForwardTargets: []*ForwardTarget{{}}},
+		{ForwardTargets: []*ForwardTarget{{URL: "https://example.com", Format: "xml"}}},
+		{ForwardTargets: []*ForwardTarget{{URL: "https://example.com", Name: "../x"}}},
+		{ForwardTargets: []*ForwardTarget{{URL: "https://example.com", TLP: []string{"PURPLE"}}}},
+		{ForwardTargets: []*ForwardTarget{
+			{URL: "https://example.com"},
+			{URL: "https://example.com"},
+		}},
+		{ForwardReplay: true},
+		{ForwardReplay: true, Daemon: true, ForwardURL: "https://example.com"},
+	} {
+		if err := cfg.prepareForward(); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestForwardTargets(t *testing.T) {
+	type request struct {
+		contentType string
+		body        []byte
+	}
+	var (
+		mu       sync.Mutex
+		requests = map[string][]request{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests[r.URL.Path] = append(requests[r.URL.Path], request{
+			contentType: r.Header.Get("Content-Type"),
+			body:        body,
+		})
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := &Config{
+		Directory: t.TempDir(),
+		Logger:    slog.New(slog.DiscardHandler),
+		ForwardTargets: []*ForwardTarget{
+			{URL: server.URL + "/json", Format: ForwardJSON},
+			{URL: server.URL + "/raw", Format: ForwardRaw, ValidOnly: true},
+			{URL: server.URL + "/white", TLP: []string{csaf.TLPLabelWhite}},
+		},
+	}
+	if err := cfg.prepareForward(); err != nil {
+		t.Fatalf("prepare failed: %v", err)
+	}
+
+	fs := NewForwarders(cfg)
+	var wg sync.WaitGroup
+	for _, f := range fs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.Run()
+		}()
+	}
+	for _, f := range fs {
+		f.forward("a.json", `{"a":1}`, validValidationStatus,
+			"abc  a.json\n", "", &forwardMeta{
+				Domain:    "example.com",
+				TLP:       csaf.TLPLabelWhite,
+				Signature: string(SignatureVerified),
+			})
+		f.forward("b.json", `{"b":2}`, invalidValidationStatus,
+			"", "", &forwardMeta{TLP: csaf.TLPLabelGreen})
+		f.Close()
+	}
+	wg.Wait()
+
+	if n := len(requests["/json"]); n != 2 {
+		t.Fatalf("expected 2 JSON requests, got %d", n)
+	}
+	req := requests["/json"][0]
+	if req.contentType != "application/json" {
+		t.Errorf("unexpected content type %q", req.contentType)
+	}
+	var env struct {
+		Filename         string          `json:"filename"`
+		ValidationStatus string          `json:"validation_status"`
+		SHA256           string          `json:"sha256"`
+		Domain           string          `json:"domain"`
+		TLP              string          `json:"tlp"`
+		Signature        string          `json:"signature"`
+		Advisory         json.RawMessage `json:"advisory"`
+	}
+	if err := json.Unmarshal(req.body, &env); err != nil {
+		t.Fatalf("invalid JSON envelope: %v", err)
+	}
+	if env.Filename != "a.json" || env.ValidationStatus != "valid" ||
+		env.SHA256 != "abc" || env.Domain != "example.com" ||
+		env.TLP != "WHITE" || env.Signature != "verified" ||
+		string(env.Advisory) != `{"a":1}` {
+		t.Errorf("unexpected JSON envelope: %s", req.body)
+	}
+
+	if raw := requests["/raw"]; len(raw) != 1 || string(raw[0].body) != `{"a":1}` {
+		t.Errorf("expected only the valid advisory raw, got %v", raw)
+	}
+
+	white := requests["/white"]
+	if len(white) != 1 || !strings.HasPrefix(white[0].contentType, "multipart/form-data") {
+		t.Errorf("expected only the white advisory as multipart, got %v", white)
+	}
+}
@@ -0,0 +1,245 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// forwardStateExt is the extension of the files storing
+	// the retry state of the advisories failed forwarding.
+	forwardStateExt = ".forward"
+	// forwardRetryMin is the delay before the first retry.
+	forwardRetryMin = 30 * time.Second
+	// forwardRetryMax is the maximal delay between two retries.
+	forwardRetryMax = time.Hour
+)
+
+// forwardItem is the retry state of an advisory failed forwarding.
+type forwardItem struct {
+	Status      validationStatus `json:"validation_status"`
+	Attempts    int              `json:"attempts"`
+	NextAttempt time.Time        `json:"next_attempt"`
+	LastError   string           `json:"last_error,omitempty"`
+	// Meta are the metadata of the advisory. Missing
+	// for advisories queued by older versions.
+	Meta *forwardMeta `json:"meta,omitempty"`
+}
+
+// forwardBackoff returns the delay before the next retry
+// after the given number of failed attempts.
+func forwardBackoff(attempts int) time.Duration {
+	delay := forwardRetryMin
+	for i := 1; i < attempts && delay < forwardRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, forwardRetryMax)
+}
+
+// failed records a failed attempt.
+func (fi *forwardItem) failed(err error, now time.Time) {
+	fi.Attempts++
+	fi.NextAttempt = now.Add(forwardBackoff(fi.Attempts))
+	fi.LastError = err.Error()
+}
+
+// queueDir returns the directory of the advisories failed forwarding.
+func (f *Forwarder) queueDir() string {
+	return filepath.Join(f.cfg.Directory, failedForwardDir, f.target.queue)
+}
+
+// loadQueue loads the advisories failed forwarding in
+// previous runs. Advisories without a retry state are
+// queued as not validated.
+func (f *Forwarder) loadQueue() error {
+	f.queue = map[string]*forwardItem{}
+	defer f.updatePending()
+	dir := f.queueDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		item := &forwardItem{Status: notValidatedValidationStatus}
+		data, err := os.ReadFile(filepath.Join(dir, name+forwardStateExt))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return err
+		default:
+			if err := json.Unmarshal(data, item); err != nil {
+				return fmt.Errorf("invalid forward state of %q: %w", name, err)
+			}
+		}
+		f.queue[name] = item
+	}
+	return nil
+}
+
+// updatePending updates the number of queued advisories
+// visible to the metrics.
+func (f *Forwarder) updatePending() {
+	f.pending.Store(int64(len(f.queue)))
+}
+
+// enqueue stores the retry state of an advisory.
+func (f *Forwarder) enqueue(filename string, item *forwardItem) error {
+	if f.queue == nil {
+		f.queue = map[string]*forwardItem{}
+	}
+	f.queue[filename] = item
+	f.updatePending()
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(f.queueDir(), filename+forwardStateExt)
+	return os.WriteFile(path, data, 0644)
+}
+
+// dequeue removes an advisory from the queue.
+func (f *Forwarder) dequeue(filename string) error {
+	if _, ok := f.queue[filename]; !ok {
+		return nil
+	}
+	delete(f.queue, filename)
+	f.updatePending()
+	dir := f.queueDir()
+	var errs []error
+	for _, ext := range []string{"", ".sha256", ".sha512", forwardStateExt} {
+		err := os.Remove(filepath.Join(dir, filename+ext))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readOptional returns the content of the given file.
+// A missing file results in an empty string.
+func readOptional(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+// retry tries to forward a queued advisory again.
+func (f *Forwarder) retry(filename string, item *forwardItem, now time.Time) {
+	dir := f.queueDir()
+	doc, err := readOptional(filepath.Join(dir, filename))
+	if err == nil && doc == "" {
+		err = os.ErrNotExist
+	}
+	if err != nil {
+		f.cfg.logger().Error("Loading advisory to forward failed",
+			"filename", filename,
+			"error", err)
+		if err := f.dequeue(filename); err != nil {
+			f.cfg.logger().Error("Removing advisory from forward queue failed",
+				"filename", filename,
+				"error", err)
+		}
+		return
+	}
+	var sha256, sha512 string
+	if sha256, err = readOptional(filepath.Join(dir, filename+".sha256")); err == nil {
+		sha512, err = readOptional(filepath.Join(dir, filename+".sha512"))
+	}
+	if err == nil {
+		meta := item.Meta
+		if meta == nil {
+			meta = &forwardMeta{}
+		}
+		err = f.send(filename, doc, item.Status, sha256, sha512, meta)
+	}
+	if err != nil {
+		item.failed(err, now)
+		f.cfg.logger().Warn("Retrying to forward failed",
+			"target", f.target.Name,
+			"filename", filename,
+			"attempts", item.Attempts,
+			"next_attempt", item.NextAttempt,
+			"error", err)
+		if err := f.enqueue(filename, item); err != nil {
+			f.cfg.logger().Error("Storing forward state failed",
+				"filename", filename,
+				"error", err)
+		}
+		return
+	}
+	f.succeeded++
+	f.forwarded.Inc(f.target.Name, "retried")
+	f.cfg.logger().Info("Retrying to forward succeeded",
+		"target", f.target.Name,
+		"filename", filename,
+		"attempts", item.Attempts+1)
+	if err := f.dequeue(filename); err != nil {
+		f.cfg.logger().Error("Removing advisory from forward queue failed",
+			"filename", filename,
+			"error", err)
+	}
+}
+
+// retryDue retries the queued advisories whose next attempt
+// is due at the given time. A zero time retries all of them.
+func (f *Forwarder) retryDue(now time.Time) {
+	for filename, item := range f.queue {
+		if now.IsZero() || !item.NextAttempt.After(now) {
+			f.retry(filename, item, time.Now())
+		}
+	}
+}
+
+// nextRetry returns the time of the next due retry.
+// Returns false if the queue is empty.
+func (f *Forwarder) nextRetry() (time.Time, bool) {
+	var next time.Time
+	for _, item := range f.queue {
+		if next.IsZero() || item.NextAttempt.Before(next) {
+			next = item.NextAttempt
+		}
+	}
+	return next, len(f.queue) > 0
+}
+
+// Replay forwards the advisories which failed forwarding before
+// regardless of their retry state. It is not meant to be called
+// while the forwarder runs. Returns an error if any of them
+// still fails.
+func (f *Forwarder) Replay() error {
+	if err := f.loadQueue(); err != nil {
+		return fmt.Errorf("loading forward queue failed: %w", err)
+	}
+	f.cfg.logger().Info("Replaying failed forwards",
+		"target", f.target.Name,
+		"queued", len(f.queue))
+	f.retryDue(time.Time{})
+	f.logStats()
+	if n := len(f.queue); n > 0 {
+		return fmt.Errorf("%d advisories could not be forwarded to %q",
+			n, f.target.Name)
+	}
+	return nil
+}
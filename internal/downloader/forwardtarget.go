@@ -0,0 +1,219 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/certs"
+)
+
+// ForwardFormat is the format of the payload sent to a forward target.
+type ForwardFormat string
+
+const (
+	// ForwardMultipart is a multipart form with the advisory,
+	// its validation status and its hashes.
+	ForwardMultipart = ForwardFormat("multipart")
+	// ForwardJSON is a JSON envelope with the advisory and its metadata.
+	ForwardJSON = ForwardFormat("json")
+	// ForwardRaw is the advisory alone.
+	ForwardRaw = ForwardFormat("raw")
+)
+
+// ForwardTarget is an HTTP endpoint the advisories are forwarded to.
+type ForwardTarget struct {
+	// Name identifies the target in the logs, the metrics and
+	// the queue of the failed forwards. Derived from the URL
+	// if not given.
+	Name     string        `toml:"name"`
+	URL      string        `toml:"url"`
+	Header   http.Header   `toml:"header"`
+	Insecure bool          `toml:"insecure"`
+	Format   ForwardFormat `toml:"format"`
+
+	ClientCert       *string `toml:"client_cert"`
+	ClientKey        *string `toml:"client_key"`
+	ClientPassphrase *string `toml:"client_passphrase"`
+
+	// TLP restricts the forwarded advisories to the ones
+	// listed in feeds with the given TLP labels. The labels
+	// are case insensitive, CLEAR is the same as WHITE.
+	TLP []string `toml:"tlp"`
+	// ValidOnly restricts the forwarded advisories to the
+	// ones which passed all the checks.
+	ValidOnly bool `toml:"valid_only"`
+
+	clientCerts []tls.Certificate
+	// tlp are the normalized labels of TLP.
+	tlp []csaf.TLPLabel
+	// queue is the sub folder of the failed forward folder
+	// to queue the failed forwards in.
+	queue string
+}
+
+// forwardMeta are the metadata of a forwarded advisory.
+type forwardMeta struct {
+	Domain      string        `json:"domain,omitempty"`
+	URL         string        `json:"url,omitempty"`
+	TLP         csaf.TLPLabel `json:"tlp,omitempty"`
+	Signature   string        `json:"signature,omitempty"`
+	Fingerprint string        `json:"fingerprint,omitempty"`
+}
+
+// forwardEnvelope is the payload of the JSON format.
+type forwardEnvelope struct {
+	Filename         string           `json:"filename"`
+	ValidationStatus validationStatus `json:"validation_status"`
+	SHA256           string           `json:"sha256,omitempty"`
+	SHA512           string           `json:"sha512,omitempty"`
+	forwardMeta
+	Advisory json.RawMessage `json:"advisory"`
+}
+
+// invalidNameChars are the characters replaced
+// in the names derived from the URLs.
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// prepare checks the settings of the target and loads its certificates.
+func (ft *ForwardTarget) prepare() error {
+	if ft.URL == "" {
+		return errors.New("forward target without URL")
+	}
+	u, err := url.Parse(ft.URL)
+	if err != nil {
+		return fmt.Errorf("invalid forward target URL %q: %w", ft.URL, err)
+	}
+	if ft.Name == "" {
+		ft.Name = strings.Trim(
+			invalidNameChars.ReplaceAllString(u.Host+u.Path, "_"), "_.")
+	}
+	if ft.Name == "" || invalidNameChars.MatchString(ft.Name) ||
+		strings.Trim(ft.Name, ".") == "" {
+		return fmt.Errorf("invalid forward target name %q", ft.Name)
+	}
+	switch ft.Format {
+	case "":
+		ft.Format = ForwardMultipart
+	case ForwardMultipart, ForwardJSON, ForwardRaw:
+	default:
+		return fmt.Errorf("unknown format %q of forward target %q", ft.Format, ft.Name)
+	}
+	ft.tlp = make([]csaf.TLPLabel, 0, len(ft.TLP))
+	for _, label := range ft.TLP {
+		switch tlp := csaf.NormalizeTLPLabel(label); tlp {
+		case csaf.TLPLabelUnlabeled, csaf.TLPLabelWhite, csaf.TLPLabelGreen,
+			csaf.TLPLabelAmber, csaf.TLPLabelRed:
+			ft.tlp = append(ft.tlp, tlp)
+		default:
+			return fmt.Errorf("invalid TLP label %q of forward target %q", label, ft.Name)
+		}
+	}
+	if ft.clientCerts, err = certs.LoadCertificate(
+		ft.ClientCert, ft.ClientKey, ft.ClientPassphrase,
+	); err != nil {
+		return fmt.Errorf("invalid certificates for forward target %q: %w", ft.Name, err)
+	}
+	return nil
+}
+
+// accepts tells if an advisory is forwarded to the target.
+func (ft *ForwardTarget) accepts(status validationStatus, meta *forwardMeta) bool {
+	if ft.ValidOnly && status != validValidationStatus {
+		return false
+	}
+	return len(ft.tlp) == 0 ||
+		slices.Contains(ft.tlp, csaf.NormalizeTLPLabel(string(meta.TLP)))
+}
+
+// prepareForward prepares the forward targets. The target given
+// by the command line options is placed first with the failed
+// forwards queued directly in the failed forward folder.
+func (cfg *Config) prepareForward() error {
+	var targets []*ForwardTarget
+	names := map[string]bool{}
+	if cfg.ForwardURL != "" {
+		ft := &ForwardTarget{
+			URL:      cfg.ForwardURL,
+			Header:   cfg.ForwardHeader,
+			Insecure: cfg.ForwardInsecure,
+		}
+		if err := ft.prepare(); err != nil {
+			return err
+		}
+		names[ft.Name] = true
+		targets = append(targets, ft)
+	}
+	for _, ft := range cfg.ForwardTargets {
+		if err := ft.prepare(); err != nil {
+			return err
+		}
+		if names[ft.Name] {
+			return fmt.Errorf("forward target %q is configured more than once", ft.Name)
+		}
+		names[ft.Name] = true
+		ft.queue = ft.Name
+		targets = append(targets, ft)
+	}
+	cfg.forwardTargets = targets
+
+	if cfg.ForwardReplay {
+		if len(targets) == 0 {
+			return errors.New("replaying forwards needs forward targets")
+		}
+		if cfg.Daemon {
+			return errors.New("replaying forwards cannot be used in daemon mode")
+		}
+	}
+	return nil
+}
+
+// hashValue extracts the hex encoded hash from
+// the content of a hash file.
+func hashValue(content string) string {
+	if fields := strings.Fields(content); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// buildJSON creates the JSON envelope of the given advisory.
+func buildJSON(
+	filename, doc string,
+	status validationStatus,
+	sha256, sha512 string,
+	meta *forwardMeta,
+) ([]byte, error) {
+	if !json.Valid([]byte(doc)) {
+		return nil, errors.New("advisory is not valid JSON")
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(&forwardEnvelope{
+		Filename:         filename,
+		ValidationStatus: status,
+		SHA256:           hashValue(sha256),
+		SHA512:           hashValue(sha512),
+		forwardMeta:      *meta,
+		Advisory:         json.RawMessage(doc),
+	}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
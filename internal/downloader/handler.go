@@ -0,0 +1,127 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"slices"
+	"strings"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+)
+
+// SignatureStatus is the result of the signature check of an advisory.
+type SignatureStatus string
+
+const (
+	// SignatureVerified means the signature was verified.
+	SignatureVerified = SignatureStatus("verified")
+	// SignatureInvalid means the signature is missing or
+	// could not be verified.
+	SignatureInvalid = SignatureStatus("invalid")
+	// SignatureUnchecked means the signature was not checked,
+	// e.g. because the provider has no OpenPGP keys.
+	SignatureUnchecked = SignatureStatus("unchecked")
+)
+
+// signatureStatusOf returns the signature status of an advisory
+// verified by the key with the given fingerprint.
+func signatureStatusOf(fingerprint string, failures []advisoryFailure) SignatureStatus {
+	for _, f := range failures {
+		switch f.Class {
+		case failureSignature, failureSignatureMissing:
+			return SignatureInvalid
+		}
+	}
+	if fingerprint != "" {
+		return SignatureVerified
+	}
+	return SignatureUnchecked
+}
+
+// ValidationFailure is a failed check of an advisory.
+type ValidationFailure struct {
+	// Class is the kind of the check which failed,
+	// e.g. "sha256_mismatch" or "schema".
+	Class string
+	// Message describes the failure.
+	Message string
+}
+
+// Advisory is a downloaded advisory passed to [Downloader.Handler].
+type Advisory struct {
+	// Domain is the domain the advisory was downloaded from.
+	Domain string
+	// Mirror is the URL of the provider metadata of the
+	// mirror the advisory was downloaded from if any.
+	Mirror string
+	// URL is the URL of the advisory.
+	URL string
+	// Filename is the file name of the advisory.
+	Filename string
+	// Label is the TLP label of the feed listing the advisory.
+	Label csaf.TLPLabel
+	// Data are the raw bytes of the advisory.
+	Data []byte
+	// Document is the parsed advisory.
+	Document any
+	// SHA256 and SHA512 are the hashes of Data.
+	SHA256 []byte
+	SHA512 []byte
+	// Signature is the result of the signature check.
+	Signature SignatureStatus
+	// Fingerprint is the fingerprint of the OpenPGP key which
+	// verified the signature. Empty if it was not verified.
+	Fingerprint string
+	// Valid tells if the advisory passed all the checks.
+	// Only in unsafe validation mode invalid advisories are passed.
+	Valid bool
+	// Failures are the failed checks.
+	Failures []ValidationFailure
+}
+
+// handle passes the advisory to the configured handler.
+func (dc *downloadContext) handle(
+	file csaf.AdvisoryFile,
+	filename string,
+	doc any,
+	fingerprint string,
+	status validationStatus,
+	failures []advisoryFailure,
+) error {
+	if dc.d.Handler == nil {
+		return nil
+	}
+	// The buffer is reused for the next advisory.
+	data := slices.Clone(dc.data.Bytes())
+	s256 := sha256.Sum256(data)
+	s512 := sha512.Sum512(data)
+	adv := &Advisory{
+		Domain:      dc.pc.domain,
+		Mirror:      dc.pc.mirror,
+		URL:         file.URL(),
+		Filename:    filename,
+		Label:       csaf.TLPLabel(strings.ToUpper(dc.lower)),
+		Data:        data,
+		Document:    doc,
+		SHA256:      s256[:],
+		SHA512:      s512[:],
+		Signature:   signatureStatusOf(fingerprint, failures),
+		Fingerprint: fingerprint,
+		Valid:       status == validValidationStatus,
+	}
+	for _, f := range failures {
+		adv.Failures = append(adv.Failures, ValidationFailure{
+			Class:   string(f.Class),
+			Message: f.Message,
+		})
+	}
+	return dc.d.Handler(adv)
+}
@@ -0,0 +1,200 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+)
+
+const (
+	defaultHookWorker  = 1
+	defaultHookTimeout = time.Minute
+	// hookOutputLength is the maximal length of
+	// the output of a failed hook in the log.
+	hookOutputLength = 512
+)
+
+// hookAdvisory is the input of the hooks about a stored advisory.
+type hookAdvisory struct {
+	Path             string           `json:"path"`
+	Name             string           `json:"name"`
+	Filename         string           `json:"filename"`
+	Domain           string           `json:"domain"`
+	URL              string           `json:"url"`
+	TLP              csaf.TLPLabel    `json:"tlp"`
+	ValidationStatus validationStatus `json:"validation_status"`
+	Signature        SignatureStatus  `json:"signature"`
+	Fingerprint      string           `json:"fingerprint,omitempty"`
+	Changed          bool             `json:"changed"`
+}
+
+// env returns the advisory as environment variables.
+func (ha *hookAdvisory) env() []string {
+	return []string{
+		"CSAF_PATH=" + ha.Path,
+		"CSAF_NAME=" + ha.Name,
+		"CSAF_FILENAME=" + ha.Filename,
+		"CSAF_DOMAIN=" + ha.Domain,
+		"CSAF_URL=" + ha.URL,
+		"CSAF_TLP=" + string(ha.TLP),
+		"CSAF_VALIDATION_STATUS=" + string(ha.ValidationStatus),
+		"CSAF_SIGNATURE=" + string(ha.Signature),
+		"CSAF_FINGERPRINT=" + ha.Fingerprint,
+		"CSAF_CHANGED=" + strconv.FormatBool(ha.Changed),
+	}
+}
+
+// hookBatch is the input of the batch hook.
+type hookBatch struct {
+	Advisories []*hookAdvisory `json:"advisories"`
+}
+
+// hooks runs the configured commands.
+type hooks struct {
+	cfg   *Config
+	slots chan struct{}
+
+	mu      sync.Mutex
+	changed []*hookAdvisory
+}
+
+// checkHooks checks the settings of the hooks.
+func (cfg *Config) checkHooks() error {
+	cfg.hookCmd = strings.Fields(cfg.Hook)
+	cfg.batchHookCmd = strings.Fields(cfg.BatchHook)
+	if len(cfg.hookCmd) == 0 && len(cfg.batchHookCmd) == 0 {
+		return nil
+	}
+	if cfg.NoStore {
+		return errors.New("hooks cannot be used together with no_store")
+	}
+	// The hooks get the path of the stored advisory.
+	switch cfg.Storage {
+	case StorageTar, StorageZip, StorageS3:
+		return fmt.Errorf("hooks are not supported by %s storage", cfg.Storage)
+	}
+	if cfg.HookWorker < 1 {
+		cfg.HookWorker = defaultHookWorker
+	}
+	if cfg.HookTimeout <= 0 {
+		cfg.HookTimeout = defaultHookTimeout
+	}
+	return nil
+}
+
+// newHooks returns the hooks of the configuration.
+// Returns nil if no hooks are configured.
+func newHooks(cfg *Config) *hooks {
+	if len(cfg.hookCmd) == 0 && len(cfg.batchHookCmd) == 0 {
+		return nil
+	}
+	return &hooks{
+		cfg:   cfg,
+		slots: make(chan struct{}, max(cfg.HookWorker, 1)),
+	}
+}
+
+// stored runs the advisory hook for a stored advisory and
+// remembers it for the batch hook if it has changed.
+func (h *hooks) stored(ctx context.Context, adv *hookAdvisory) error {
+	if h == nil {
+		return nil
+	}
+	if adv.Changed && len(h.cfg.batchHookCmd) > 0 {
+		h.mu.Lock()
+		h.changed = append(h.changed, adv)
+		h.mu.Unlock()
+	}
+	if len(h.cfg.hookCmd) == 0 {
+		return nil
+	}
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+	defer func() { <-h.slots }()
+	return h.run(ctx, h.cfg.hookCmd, adv.env(), adv)
+}
+
+// batch runs the batch hook with the changed advisories of the
+// given domain or of all domains if domain is empty. Nothing
+// is run if no advisory has changed.
+func (h *hooks) batch(ctx context.Context, domain string) error {
+	if h == nil || len(h.cfg.batchHookCmd) == 0 {
+		return nil
+	}
+	h.mu.Lock()
+	var advs []*hookAdvisory
+	if domain == "" {
+		advs, h.changed = h.changed, nil
+	} else {
+		var others []*hookAdvisory
+		for _, adv := range h.changed {
+			if adv.Domain == domain {
+				advs = append(advs, adv)
+			} else {
+				others = append(others, adv)
+			}
+		}
+		h.changed = others
+	}
+	h.mu.Unlock()
+	if len(advs) == 0 {
+		return nil
+	}
+	env := []string{"CSAF_COUNT=" + strconv.Itoa(len(advs))}
+	return h.run(ctx, h.cfg.batchHookCmd, env, &hookBatch{Advisories: advs})
+}
+
+// run runs a command with the given additional environment
+// and the input encoded as JSON on stdin.
+func (h *hooks) run(ctx context.Context, command, env []string, input any) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.HookTimeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	output, _ := limitedString(&out, hookOutputLength)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", h.cfg.HookTimeout)
+		}
+		return fmt.Errorf("hook %q failed: %w: %s", command[0], err, output)
+	}
+	h.cfg.logger().Debug("Hook finished",
+		"command", command[0],
+		"duration", time.Since(start),
+		"output", output)
+	return nil
+}
@@ -0,0 +1,171 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// writeScript writes a shell script to the given directory.
+func writeScript(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	script := filepath.Join(dir, name)
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"+content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+func TestCheckHooks(t *testing.T) {
+	cfg := &Config{Hook: "  /bin/hook  --flag ", BatchHook: "batch"}
+	if err := cfg.checkHooks(); err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	if len(cfg.hookCmd) != 2 || cfg.hookCmd[0] != "/bin/hook" || cfg.hookCmd[1] != "--flag" {
+		t.Errorf("unexpected hook command %q", cfg.hookCmd)
+	}
+	if cfg.HookWorker != defaultHookWorker || cfg.HookTimeout != defaultHookTimeout {
+		t.Error("expected the defaults of the hooks")
+	}
+	cfg = &Config{Hook: "hook", NoStore: true}
+	if err := cfg.checkHooks(); err == nil {
+		t.Error("expected an error using hooks without storing")
+	}
+	cfg = &Config{BatchHook: "batch", Storage: StorageTar}
+	if err := cfg.checkHooks(); err == nil {
+		t.Error("expected an error using hooks with an archive")
+	}
+}
+
+func TestHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts need a POSIX shell")
+	}
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client := util.Client(server.Client())
+	domain := server.URL + "/provider-metadata.json"
+
+	tempDir := t.TempDir()
+	outDir := filepath.Join(tempDir, "out")
+	if err := os.Mkdir(outDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	hook := writeScript(t, tempDir, "hook.sh",
+		`cat > "$OUT/$CSAF_FILENAME.json"; echo "$CSAF_TLP" > "$OUT/$CSAF_FILENAME.tlp"`)
+	batch := writeScript(t, tempDir, "batch.sh",
+		`cat > "$OUT/batch.json"; echo "$CSAF_COUNT" > "$OUT/batch.count"`)
+	t.Setenv("OUT", outDir)
+
+	run := func(hook string) *Downloader {
+		t.Helper()
+		cfg := Config{
+			LogLevel:  &options.LogLevel{Level: slog.LevelError},
+			Logger:    slog.New(slog.DiscardHandler),
+			Directory: filepath.Join(tempDir, "download"),
+			Hook:      hook,
+			BatchHook: batch,
+		}
+		if err := cfg.Prepare(); err != nil {
+			t.Fatalf("config failed: %v", err)
+		}
+		d, err := NewDownloader(&cfg)
+		if err != nil {
+			t.Fatalf("could not init downloader: %v", err)
+		}
+		defer d.Close()
+		d.client = &client
+		if err := d.Run(context.Background(), []string{domain}); err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+		return d
+	}
+
+	run(hook)
+
+	data, err := os.ReadFile(filepath.Join(outDir, "avendor-advisory-0004.json.json"))
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	var adv hookAdvisory
+	if err := json.Unmarshal(data, &adv); err != nil {
+		t.Fatalf("invalid hook input: %v", err)
+	}
+	if adv.Name != "white/2020/avendor-advisory-0004.json" ||
+		!strings.HasSuffix(adv.Path, filepath.FromSlash(adv.Name)) ||
+		adv.Domain != domain || adv.TLP != "WHITE" ||
+		adv.ValidationStatus != validValidationStatus ||
+		adv.Signature != SignatureVerified || !adv.Changed {
+		t.Errorf("unexpected hook input: %s", data)
+	}
+	if tlp, _ := os.ReadFile(filepath.Join(outDir, "avendor-advisory-0004.json.tlp")); strings.TrimSpace(string(tlp)) != "WHITE" {
+		t.Errorf("unexpected TLP in environment: %q", tlp)
+	}
+	var b hookBatch
+	if data, err = os.ReadFile(filepath.Join(outDir, "batch.json")); err != nil {
+		t.Fatalf("batch hook did not run: %v", err)
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatalf("invalid batch hook input: %v", err)
+	}
+	if len(b.Advisories) != 1 || b.Advisories[0].Name != adv.Name {
+		t.Errorf("unexpected batch hook input: %s", data)
+	}
+
+	// Nothing changed and the hook fails.
+	if err := os.Remove(filepath.Join(outDir, "batch.json")); err != nil {
+		t.Fatal(err)
+	}
+	d := run(writeScript(t, tempDir, "fail.sh", "exit 1"))
+	if d.stats.hookFailed != 1 {
+		t.Errorf("expected 1 failed hook, got %d", d.stats.hookFailed)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "batch.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("batch hook should not run without changes")
+	}
+}
+
+func TestHookTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts need a POSIX shell")
+	}
+	cfg := &Config{
+		Hook:        writeScript(t, t.TempDir(), "sleep.sh", "sleep 10"),
+		HookTimeout: 100 * time.Millisecond,
+		Logger:      slog.New(slog.DiscardHandler),
+	}
+	if err := cfg.checkHooks(); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	err := newHooks(cfg).stored(context.Background(), &hookAdvisory{})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("hook was not stopped in time")
+	}
+}
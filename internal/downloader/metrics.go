@@ -0,0 +1,135 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"time"
+
+	"github.com/gocsaf/csaf/v3/internal/metrics"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// downloaderMetrics are the metrics of a downloader.
+type downloaderMetrics struct {
+	registry     *metrics.Registry
+	http         *metrics.HTTP
+	advisories   *metrics.Vec
+	validations  *metrics.Vec
+	lastRun      *metrics.Vec
+	lastSuccess  *metrics.Vec
+	forwarded    *metrics.Vec
+	forwardQueue *metrics.Vec
+	forwardRetry *metrics.Vec
+}
+
+// metricsEnabled tells if metrics are exposed.
+func (cfg *Config) metricsEnabled() bool {
+	return cfg.MetricsFile != "" || cfg.Daemon && cfg.Listen != ""
+}
+
+// newDownloaderMetrics registers the metrics of the downloader d.
+func newDownloaderMetrics(d *Downloader) *downloaderMetrics {
+	r := metrics.NewRegistry()
+	m := &downloaderMetrics{
+		registry: r,
+		http:     metrics.NewHTTP(r, "csaf_downloader"),
+		advisories: r.Counter(
+			"csaf_downloader_advisories_total",
+			"Advisories by domain and result as in the download statistics.",
+			"domain", "result"),
+		validations: r.Counter(
+			"csaf_downloader_validations_total",
+			"Validations of advisories by domain, validator and result.",
+			"domain", "validator", "result"),
+		lastRun: r.Gauge(
+			"csaf_downloader_last_run_timestamp_seconds",
+			"Time of the end of the last download by domain.",
+			"domain"),
+		lastSuccess: r.Gauge(
+			"csaf_downloader_last_success_timestamp_seconds",
+			"Time of the end of the last successful download by domain.",
+			"domain"),
+		forwarded: r.Counter(
+			"csaf_downloader_forwarded_total",
+			"Advisories forwarded by target and result. Forwarded retries have the result \"retried\".",
+			"target", "result"),
+		forwardQueue: r.Gauge(
+			"csaf_downloader_forward_queue_length",
+			"Number of advisories waiting to be forwarded by target.",
+			"target"),
+		forwardRetry: r.Gauge(
+			"csaf_downloader_forward_retry_queue_length",
+			"Number of advisories failed forwarding waiting to be retried by target.",
+			"target"),
+	}
+	r.OnCollect(func() {
+		d.statsMu.Lock()
+		defer d.statsMu.Unlock()
+		for domain, st := range d.domainStats {
+			for result, n := range st.counts() {
+				// Derived from the others.
+				if result != "total_failed" {
+					m.advisories.Set(float64(n), domain, result)
+				}
+			}
+		}
+	})
+	return m
+}
+
+// client returns a client recording the metrics of its requests.
+func (m *downloaderMetrics) client(client util.Client) util.Client {
+	if m == nil {
+		return client
+	}
+	return m.http.Client(client)
+}
+
+// validated records the result of a validation of an advisory.
+func (m *downloaderMetrics) validated(domain, validator, result string) {
+	if m == nil {
+		return
+	}
+	m.validations.Inc(domain, validator, result)
+}
+
+// finished records the end of a download of a domain.
+func (m *downloaderMetrics) finished(domain string, err error) {
+	if m == nil {
+		return
+	}
+	now := float64(time.Now().Unix())
+	m.lastRun.Set(now, domain)
+	if err == nil {
+		m.lastSuccess.Set(now, domain)
+	}
+}
+
+// forwarders lets the metrics record the forwarded advisories.
+// It has to be called before any advisory is forwarded.
+func (m *downloaderMetrics) forwarders(fs []*Forwarder) {
+	if m == nil {
+		return
+	}
+	for _, f := range fs {
+		f.forwarded = m.forwarded
+		m.registry.OnCollect(func() {
+			m.forwardQueue.Set(float64(len(f.cmds)), f.target.Name)
+			m.forwardRetry.Set(float64(f.pending.Load()), f.target.Name)
+		})
+	}
+}
+
+// writeMetrics writes the metrics to the configured file.
+func (d *Downloader) writeMetrics() error {
+	if d.metrics == nil || d.cfg.MetricsFile == "" {
+		return nil
+	}
+	return d.metrics.registry.WriteFile(d.cfg.MetricsFile)
+}
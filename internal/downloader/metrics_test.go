@@ -0,0 +1,77 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestDownloadMetrics(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client := util.Client(server.Client())
+	domain := server.URL + "/provider-metadata.json"
+
+	tempDir := t.TempDir()
+	metricsFile := filepath.Join(tempDir, "metrics", "downloader.prom")
+	cfg := Config{
+		LogLevel:    &options.LogLevel{Level: slog.LevelError},
+		Directory:   tempDir,
+		MetricsFile: metricsFile,
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+	d, err := NewDownloader(&cfg)
+	if err != nil {
+		t.Fatalf("could not init downloader: %v", err)
+	}
+	defer d.Close()
+	d.client = &client
+
+	if err := d.Run(context.Background(), []string{domain}); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	data, err := os.ReadFile(metricsFile)
+	if err != nil {
+		t.Fatalf("metrics not written: %v", err)
+	}
+	u, _ := url.Parse(server.URL)
+	for _, want := range []string{
+		fmt.Sprintf(`csaf_downloader_advisories_total{domain=%q,result="succeeded"} 1`, domain),
+		fmt.Sprintf(`csaf_downloader_advisories_total{domain=%q,result="sha256_failed"} 0`, domain),
+		fmt.Sprintf(`csaf_downloader_validations_total{domain=%q,validator="schema",result="valid"} 1`, domain),
+		fmt.Sprintf(`csaf_downloader_http_responses_total{host=%q,code="200"}`, u.Host),
+		fmt.Sprintf(`csaf_downloader_http_request_duration_seconds_count{host=%q}`, u.Host),
+		fmt.Sprintf(`csaf_downloader_last_success_timestamp_seconds{domain=%q}`, domain),
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("missing %q in:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "total_failed") {
+		t.Error("derived total_failed should not be exported")
+	}
+}
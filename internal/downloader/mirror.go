@@ -0,0 +1,244 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// mirrorEntry is a provider listed in an aggregator.
+type mirrorEntry struct {
+	// url is the URL of the canonical provider metadata.
+	url string
+	// namespace is the namespace of the publisher.
+	namespace string
+	// mirrors are the URLs of the mirrored provider metadata.
+	mirrors []string
+	// updateInterval is the update interval of a publisher.
+	updateInterval string
+}
+
+// mirrorList are the providers listed in an aggregator.
+type mirrorList []*mirrorEntry
+
+// isURL checks if the given string is an HTTP(S) URL.
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// loadAggregator loads the configured aggregator.json
+// from an URL or a file and extracts the mirrors.
+func (d *Downloader) loadAggregator() (mirrorList, error) {
+	src := d.cfg.Aggregator
+	var r io.Reader
+	if isURL(src) {
+		resp, err := d.httpClient(d.cfg).Get(src)
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch aggregator %q: %w", src, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("cannot fetch aggregator %q: %s (%d)",
+				src, resp.Status, resp.StatusCode)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var agg csaf.Aggregator
+	if err := json.NewDecoder(r).Decode(&agg); err != nil {
+		return nil, fmt.Errorf("cannot load aggregator %q: %w", src, err)
+	}
+	if err := agg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid aggregator %q: %w", src, err)
+	}
+
+	var ml mirrorList
+	add := func(
+		md *csaf.AggregatorCSAFProviderMetadata,
+		mirrors []csaf.ProviderURL,
+		updateInterval string,
+	) {
+		if md == nil || md.URL == nil {
+			return
+		}
+		me := &mirrorEntry{url: string(*md.URL), updateInterval: updateInterval}
+		if md.Publisher != nil && md.Publisher.Namespace != nil {
+			me.namespace = *md.Publisher.Namespace
+		}
+		for _, m := range mirrors {
+			me.mirrors = append(me.mirrors, string(m))
+		}
+		ml = append(ml, me)
+	}
+	for _, p := range agg.CSAFProviders {
+		add(p.Metadata, p.Mirrors, "")
+	}
+	for _, p := range agg.CSAFPublishers {
+		add(p.Metadata, p.Mirrors, p.UpdateInterval)
+	}
+	return ml, nil
+}
+
+// find returns the entry of the given domain. The domain
+// either matches the URL of the provider metadata or its host.
+// Returns nil if the domain is not listed.
+func (ml mirrorList) find(domain string) *mirrorEntry {
+	for _, me := range ml {
+		if me.url == domain {
+			return me
+		}
+		if u, err := url.Parse(me.url); err == nil && strings.EqualFold(u.Hostname(), domain) {
+			return me
+		}
+	}
+	return nil
+}
+
+// domains returns the URLs of the provider metadata of all
+// listed providers.
+func (ml mirrorList) domains() []string {
+	domains := make([]string, len(ml))
+	for i, me := range ml {
+		domains[i] = me.url
+	}
+	return domains
+}
+
+// load tries to load the provider metadata from the mirrors.
+// Mirrors of another publisher are ignored.
+// Returns nil if no mirror could be loaded.
+func (me *mirrorEntry) load(logger *slog.Logger, client util.Client) *csaf.LoadedProviderMetadata {
+	expr := util.NewPathEval()
+	for _, m := range me.mirrors {
+		loader := csaf.NewProviderMetadataLoader(client)
+		loader.Logger = logger
+		lpmd := loader.Load(m)
+		if !lpmd.Valid() {
+			for i := range lpmd.Messages {
+				logger.Warn("Loading mirrored provider-metadata.json",
+					"mirror", m,
+					"message", lpmd.Messages[i].Message)
+			}
+			continue
+		}
+		var namespace string
+		if err := expr.Extract(
+			`$.publisher.namespace`, util.StringMatcher(&namespace), false, lpmd.Document,
+		); err != nil || (me.namespace != "" && namespace != me.namespace) {
+			logger.Warn("Ignoring mirror of another publisher",
+				"mirror", m,
+				"namespace", namespace,
+				"expected", me.namespace)
+			continue
+		}
+		return lpmd
+	}
+	return nil
+}
+
+// fingerprints are the fingerprints of the public OpenPGP
+// keys of a provider recorded from its canonical location.
+type fingerprints struct {
+	Fingerprints []string `json:"fingerprints"`
+}
+
+// loadFingerprints loads the recorded fingerprints of a provider.
+// A missing file results in an empty set.
+func loadFingerprints(fname string) (util.Set[string], error) {
+	fps := util.Set[string]{}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fps, nil
+		}
+		return nil, err
+	}
+	var f fingerprints
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("cannot load %q: %w", fname, err)
+	}
+	for _, fp := range f.Fingerprints {
+		fps.Add(strings.ToUpper(fp))
+	}
+	return fps, nil
+}
+
+// saveFingerprints records the fingerprints of the keys in a key ring.
+func saveFingerprints(fname string, keys *crypto.KeyRing) error {
+	var f fingerprints
+	for _, key := range keys.GetKeys() {
+		f.Fingerprints = append(f.Fingerprints, strings.ToUpper(key.GetFingerprint()))
+	}
+	slices.Sort(f.Fingerprints)
+	return writeStateFile(fname, &f)
+}
+
+// advisorySources records from which provider metadata
+// the stored advisories of a domain were downloaded.
+type advisorySources struct {
+	mu sync.Mutex
+	// Sources maps the names of the advisories in the storage
+	// to the URLs of the provider metadata.
+	Sources map[string]string `json:"sources"`
+}
+
+// loadAdvisorySources loads the recorded sources of a domain.
+// A missing file results in an empty record.
+func loadAdvisorySources(fname string) (*advisorySources, error) {
+	as := &advisorySources{Sources: map[string]string{}}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return as, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, as); err != nil {
+		return nil, fmt.Errorf("cannot load %q: %w", fname, err)
+	}
+	if as.Sources == nil {
+		as.Sources = map[string]string{}
+	}
+	return as, nil
+}
+
+// record records the source of an advisory.
+func (as *advisorySources) record(name, source string) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.Sources[name] = source
+}
+
+// save writes the recorded sources to the given file.
+func (as *advisorySources) save(fname string) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	return writeStateFile(fname, as)
+}
@@ -0,0 +1,190 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/pkg/errs"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+const testAggregator = `{
+  "aggregator": {
+    "category": "aggregator",
+    "name": "Test Aggregator",
+    "namespace": "https://aggregator.example.com"
+  },
+  "aggregator_version": "2.0",
+  "canonical_url": "https://aggregator.example.com/.well-known/csaf-aggregator/aggregator.json",
+  "csaf_providers": [
+    {
+      "metadata": {
+        "last_updated": "2020-01-01T00:00:00Z",
+        "publisher": {
+          "category": "vendor",
+          "name": "ACME Inc",
+          "namespace": "https://example.com"
+        },
+        "role": "csaf_trusted_provider",
+        "url": %q
+      },
+      "mirrors": [%q]
+    }
+  ],
+  "last_updated": "2020-01-01T00:00:00Z"
+}`
+
+func TestDownloadFromMirror(t *testing.T) {
+	const (
+		providerKey = "A8914CA2F11139C6A69A0018FB3CD9B15DE61596"
+		otherKey    = "0000000000000000000000000000000000000000"
+	)
+	for _, tc := range []struct {
+		name     string
+		recorded []string
+		trusted  []string
+		keyring  string
+		fail     bool
+	}{
+		{"unknown keys", nil, nil, "", true},
+		{"other keys", []string{otherKey}, nil, "", true},
+		{"original keys", []string{providerKey}, nil, "", false},
+		{"trusted keys", nil, []string{providerKey}, "", false},
+		{"other trusted keys", []string{providerKey}, []string{otherKey}, "", true},
+		{"keyring", nil, nil, "../../testdata/simple-rolie-provider/openpgp/pubkey.asc", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The test provider serves as mirror as the
+			// canonical provider metadata does not exist.
+			params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+			server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+			defer server.Close()
+			params.URL = server.URL
+
+			client := util.Client(server.Client())
+			canonical := server.URL + "/unreachable/provider-metadata.json"
+			mirror := server.URL + "/provider-metadata.json"
+
+			tempDir := t.TempDir()
+			aggregator := filepath.Join(tempDir, "aggregator.json")
+			if err := os.WriteFile(aggregator,
+				[]byte(fmt.Sprintf(testAggregator, canonical, mirror)), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := Config{
+				LogLevel:    &options.LogLevel{Level: slog.LevelError},
+				Directory:   tempDir,
+				Aggregator:  aggregator,
+				TrustedKeys: tc.trusted,
+				Keyring:     tc.keyring,
+			}
+			if err := cfg.Prepare(); err != nil {
+				t.Fatalf("config failed: %v", err)
+			}
+			if tc.recorded != nil {
+				if err := writeStateFile(cfg.stateFile(canonical, "keys"),
+					&fingerprints{Fingerprints: tc.recorded}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			d, err := NewDownloader(&cfg)
+			if err != nil {
+				t.Fatalf("could not init downloader: %v", err)
+			}
+			defer d.Close()
+			d.client = &client
+
+			// No domains given: the providers of the aggregator are used.
+			err = d.Run(context.Background(), nil)
+			written := checkIfFileExists(tempDir+"/white/2020/avendor-advisory-0004.json", t)
+
+			if tc.fail {
+				var pi errs.ErrCsafProviderIssue
+				if !errors.As(err, &pi) {
+					t.Errorf("expected provider issue, got: %v", err)
+				}
+				if written {
+					t.Error("advisory from untrusted mirror was written")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if !written {
+				t.Fatal("advisory from mirror was not written")
+			}
+			sources, err := loadAdvisorySources(cfg.stateFile(canonical, "sources"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sources.Sources["white/2020/avendor-advisory-0004.json"]; got != mirror {
+				t.Errorf("source: got %q, want %q", got, mirror)
+			}
+		})
+	}
+}
+
+func TestMirrorPathTemplate(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	// The canonical location has another host than the mirror.
+	client := util.Client(server.Client())
+	canonical := strings.Replace(server.URL, "127.0.0.1", "localhost", 1) +
+		"/unreachable/provider-metadata.json"
+	mirror := server.URL + "/provider-metadata.json"
+
+	tempDir := t.TempDir()
+	aggregator := filepath.Join(tempDir, "aggregator.json")
+	if err := os.WriteFile(aggregator,
+		[]byte(fmt.Sprintf(testAggregator, canonical, mirror)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{
+		LogLevel:     &options.LogLevel{Level: slog.LevelError},
+		Logger:       slog.New(slog.DiscardHandler),
+		Directory:    tempDir,
+		Aggregator:   aggregator,
+		PathTemplate: "{domain}/{tlp}",
+		TrustedKeys:  []string{"A8914CA2F11139C6A69A0018FB3CD9B15DE61596"},
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+	d, err := NewDownloader(&cfg)
+	if err != nil {
+		t.Fatalf("could not init downloader: %v", err)
+	}
+	defer d.Close()
+	d.client = &client
+
+	if err := d.Run(context.Background(), nil); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	// The folder is named after the provider, not the mirror.
+	if !checkIfFileExists(filepath.Join(tempDir, "localhost", "white", "avendor-advisory-0004.json"), t) {
+		t.Error("advisory not stored in the folder of the provider")
+	}
+}
@@ -0,0 +1,191 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// pathPlaceholders are the placeholders known in path templates.
+var pathPlaceholders = []string{
+	"domain",
+	"publisher",
+	"tlp",
+	"category",
+	"year",
+	"current_year",
+	"id",
+	"version",
+}
+
+// pathTemplate is a parsed template of the folder
+// the advisories are stored in. Odd elements are
+// placeholders, even elements are literal text.
+type pathTemplate []string
+
+// pathValues are the values filled into the placeholders
+// of a path template.
+type pathValues struct {
+	domain      string
+	publisher   string
+	tlp         string
+	category    string
+	initialYear int
+	currentYear int
+	id          string
+	version     string
+}
+
+// parsePathTemplate parses a path template. Placeholders
+// are given in curly braces like "{tlp}/{year}".
+func parsePathTemplate(s string) (pathTemplate, error) {
+	var pt pathTemplate
+	rest := s
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			if strings.IndexByte(rest, '}') >= 0 {
+				return nil, fmt.Errorf("path template %q: unmatched '}'", s)
+			}
+			pt = append(pt, rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("path template %q: unmatched '{'", s)
+		}
+		literal, name := rest[:start], rest[start+1:start+end]
+		if strings.IndexByte(literal, '}') >= 0 {
+			return nil, fmt.Errorf("path template %q: unmatched '}'", s)
+		}
+		if !isPathPlaceholder(name) {
+			return nil, fmt.Errorf("path template %q: unknown placeholder {%s} (expected one of %s)",
+				s, name, strings.Join(pathPlaceholders, ", "))
+		}
+		pt = append(pt, literal, name)
+		rest = rest[start+end+1:]
+	}
+	// Check that the result is a relative path inside the storage.
+	if dummy := pt.expand(&pathValues{}); !fs.ValidPath(dummy) || dummy == "." {
+		return nil, fmt.Errorf("path template %q: no valid relative path", s)
+	}
+	return pt, nil
+}
+
+// isPathPlaceholder checks if name is a known placeholder.
+func isPathPlaceholder(name string) bool {
+	for _, p := range pathPlaceholders {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanPathComponent sanitizes a value to be used in a path.
+func cleanPathComponent(s string) string {
+	s = strings.TrimSuffix(util.CleanFileName(s), ".json")
+	if s == "" || s == "_" {
+		return "unknown"
+	}
+	return s
+}
+
+// providerDomain returns the host name of a provider given by
+// the URL of its provider metadata and the domain otherwise.
+func providerDomain(domain string) string {
+	if u, err := url.Parse(domain); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	return domain
+}
+
+// value returns the sanitized value of a placeholder.
+func (pv *pathValues) value(name string) string {
+	year := func(y int) string {
+		if y == 0 {
+			return "unknown"
+		}
+		return strconv.Itoa(y)
+	}
+	switch name {
+	case "domain":
+		return cleanPathComponent(pv.domain)
+	case "publisher":
+		return cleanPathComponent(pv.publisher)
+	case "tlp":
+		return cleanPathComponent(pv.tlp)
+	case "category":
+		return cleanPathComponent(pv.category)
+	case "year":
+		return year(pv.initialYear)
+	case "current_year":
+		return year(pv.currentYear)
+	case "id":
+		return cleanPathComponent(pv.id)
+	case "version":
+		return cleanPathComponent(pv.version)
+	}
+	return ""
+}
+
+// expand fills the given values into the template.
+func (pt pathTemplate) expand(pv *pathValues) string {
+	var b strings.Builder
+	for i, part := range pt {
+		if i%2 == 0 {
+			b.WriteString(part)
+		} else {
+			b.WriteString(pv.value(part))
+		}
+	}
+	return path.Clean(b.String())
+}
+
+// extractPathValues extracts the values for the placeholders
+// of a path template from an advisory.
+func (dc *downloadContext) extractPathValues(doc any) *pathValues {
+	pv := pathValues{
+		domain:      providerDomain(dc.pc.domain),
+		tlp:         dc.lower,
+		initialYear: dc.initialReleaseDate.Year(),
+	}
+	var namespace string
+	var current time.Time
+	// All values are optional. Missing ones are filled with "unknown".
+	dc.expr.Match([]util.PathEvalMatcher{
+		{Expr: `$.document.publisher.namespace`, Action: util.StringMatcher(&namespace), Optional: true},
+		{Expr: `$.document.category`, Action: util.StringMatcher(&pv.category), Optional: true},
+		{Expr: `$.document.tracking.id`, Action: util.StringMatcher(&pv.id), Optional: true},
+		{Expr: `$.document.tracking.version`, Action: util.StringMatcher(&pv.version), Optional: true},
+		{
+			Expr:     `$.document.tracking.current_release_date`,
+			Action:   util.TimeMatcher(&current, time.RFC3339),
+			Optional: true,
+		},
+	}, doc)
+	// Use the host of the namespace URL if possible.
+	if u, err := url.Parse(namespace); err == nil && u.Host != "" {
+		pv.publisher = u.Hostname()
+	} else {
+		pv.publisher = namespace
+	}
+	if !current.IsZero() {
+		pv.currentYear = current.UTC().Year()
+	}
+	return &pv
+}
@@ -0,0 +1,77 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestParsePathTemplate(t *testing.T) {
+	for _, x := range []struct {
+		template string
+		valid    bool
+	}{
+		{"{tlp}/{year}", true},
+		{"archive/{domain}/{publisher}/{category}/{current_year}/{id}/{version}", true},
+		{"{tlp", false},
+		{"tlp}", false},
+		{"{unknown}", false},
+		{"/{tlp}", false},
+		{"../{tlp}", false},
+		{"", false},
+	} {
+		_, err := parsePathTemplate(x.template)
+		if valid := err == nil; valid != x.valid {
+			t.Errorf("%q: got valid %t expected %t: %v", x.template, valid, x.valid, err)
+		}
+	}
+}
+
+func TestExpandPathTemplate(t *testing.T) {
+	const doc = `{
+  "document": {
+    "category": "csaf_security_advisory",
+    "publisher": {"namespace": "https://Example.com/csaf"},
+    "tracking": {
+      "id": "EX-2024:0001",
+      "version": "1.0.0",
+      "current_release_date": "2025-01-02T00:00:00Z"
+    }
+  }
+}`
+	var parsed any
+	if err := json.Unmarshal([]byte(doc), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	dc := downloadContext{
+		pc:                 &providerContext{domain: "csaf.example.com"},
+		lower:              "white",
+		expr:               util.NewPathEval(),
+		initialReleaseDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	pt, err := parsePathTemplate(
+		"{domain}/{publisher}/{tlp}/{category}/{year}-{current_year}/{id}/{version}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "csaf_example_com/example_com/white/csaf_security_advisory/2024-2025/ex-2024_0001/1_0_0"
+	if got := pt.expand(dc.extractPathValues(parsed)); got != want {
+		t.Errorf("got %q expected %q", got, want)
+	}
+
+	// Missing values are filled with "unknown".
+	if got := pt.expand(dc.extractPathValues(map[string]any{})); got !=
+		"csaf_example_com/unknown/white/unknown/2024-unknown/unknown/unknown" {
+		t.Errorf("unexpected expansion of empty document: %q", got)
+	}
+}
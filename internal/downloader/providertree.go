@@ -0,0 +1,551 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/tree"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// skipTreeDirs are the folders of the download directory
+// which do not hold advisories to be published.
+var skipTreeDirs = []string{
+	failedValidationDir,
+	failedForwardDir,
+	quarantineDir,
+	stateDir,
+	removedDir,
+	revisionsDir,
+}
+
+// treeLabels are the TLP labels known as folder names.
+var treeLabels = []string{
+	strings.ToLower(csaf.TLPLabelUnlabeled),
+	strings.ToLower(csaf.TLPLabelWhite),
+	strings.ToLower(csaf.TLPLabelGreen),
+	strings.ToLower(csaf.TLPLabelAmber),
+	strings.ToLower(csaf.TLPLabelRed),
+}
+
+// treeAdvisory is an advisory published in the provider tree.
+type treeAdvisory struct {
+	path    string
+	label   string
+	summary *csaf.AdvisorySummary
+}
+
+// isWithin tells if path is the folder dir or inside of it.
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// prepareProviderTree checks the settings of the provider
+// tree and loads the key to sign the advisories with.
+func (cfg *Config) prepareProviderTree() error {
+	if cfg.ProviderTree == "" {
+		return nil
+	}
+	if cfg.NoStore {
+		return errors.New("provider tree cannot be used together with no_store")
+	}
+	if cfg.Storage != StorageDir {
+		return fmt.Errorf("provider tree is not supported by %s storage", cfg.Storage)
+	}
+	if cfg.ProviderURL == "" {
+		return errors.New("provider tree needs a provider_url")
+	}
+	if u, err := url.Parse(cfg.ProviderURL); err != nil || !u.IsAbs() {
+		return fmt.Errorf("invalid provider_url %q", cfg.ProviderURL)
+	}
+	treeDir, err := filepath.Abs(cfg.ProviderTree)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(cfg.Directory)
+	if err != nil {
+		return err
+	}
+	if isWithin(dir, treeDir) || isWithin(treeDir, dir) {
+		return errors.New("provider tree and download directory must not contain each other")
+	}
+	if cfg.ProviderPublisher != nil {
+		if err := cfg.ProviderPublisher.Validate(); err != nil {
+			return fmt.Errorf("invalid provider_publisher: %w", err)
+		}
+	}
+	if cfg.ProviderKey == "" {
+		return nil
+	}
+	cfg.providerKey, err = loadPrivateKey(cfg.ProviderKey, cfg.ProviderPassphrase)
+	return err
+}
+
+// writeProviderTree writes the provider tree if configured.
+func (d *Downloader) writeProviderTree() error {
+	if d.cfg.ProviderTree == "" {
+		return nil
+	}
+	d.treeMu.Lock()
+	defer d.treeMu.Unlock()
+	return WriteProviderTree(d.cfg)
+}
+
+// WriteProviderTree writes the advisories stored in the download
+// directory as a directory and/or ROLIE based CSAF provider tree.
+// The tree is build next to the configured folder and replaces
+// it when completed.
+func WriteProviderTree(cfg *Config) error {
+	advisories, err := collectTreeAdvisories(cfg)
+	if err != nil {
+		return err
+	}
+
+	parent, base := filepath.Split(filepath.Clean(cfg.ProviderTree))
+	if parent == "" {
+		parent = "."
+	}
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(parent, base+".tmp-")
+	if err != nil {
+		return err
+	}
+	if err := func() error {
+		if err := os.Chmod(tmp, 0755); err != nil {
+			return err
+		}
+		unsigned, err := buildProviderTree(cfg, tmp, advisories)
+		if err != nil {
+			return err
+		}
+		if err := replaceDir(cfg.ProviderTree, tmp); err != nil {
+			return err
+		}
+		cfg.logger().Info("Provider tree written",
+			"path", cfg.ProviderTree,
+			"advisories", len(advisories),
+			"unsigned", unsigned)
+		return nil
+	}(); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("writing provider tree failed: %w", err)
+	}
+	return nil
+}
+
+// replaceDir replaces the folder dst by src.
+func replaceDir(dst, src string) error {
+	old := dst + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	if err := os.Rename(dst, old); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// collectTreeAdvisories collects the advisories to publish
+// from the download directory. If the same advisory is stored
+// more than once the one with the latest release date wins.
+func collectTreeAdvisories(cfg *Config) ([]*treeAdvisory, error) {
+	expr := util.NewPathEval()
+	found := map[string]*treeAdvisory{}
+
+	root := cfg.Directory
+	if err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if path == root {
+				return nil
+			}
+			if strings.HasPrefix(name, ".") ||
+				(filepath.Dir(path) == root && slices.Contains(skipTreeDirs, name)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || !util.ConformingFileName(name) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		summary, err := csaf.ParseAdvisorySummary(expr, data)
+		if err != nil {
+			cfg.logger().Debug("Ignoring file in provider tree",
+				"path", path,
+				"error", err)
+			return nil
+		}
+		label := strings.ToLower(summary.TLPLabel)
+		if label == "" {
+			// Fall back to the folder of the default layout.
+			rel, _ := filepath.Rel(root, path)
+			first, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+			if !slices.Contains(treeLabels, first) {
+				cfg.logger().Warn("Ignoring advisory without TLP label in provider tree",
+					"path", path)
+				return nil
+			}
+			label = first
+		}
+		key := label + "/" +
+			strconv.Itoa(summary.InitialReleaseDate.Year()) + "/" + name
+		if other := found[key]; other != nil {
+			cfg.logger().Warn("Advisory stored more than once",
+				"path", path,
+				"other", other.path)
+			if !summary.CurrentReleaseDate.After(other.summary.CurrentReleaseDate) {
+				return nil
+			}
+		}
+		found[key] = &treeAdvisory{
+			path:    path,
+			label:   label,
+			summary: summary,
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	advisories := make([]*treeAdvisory, len(keys))
+	for i, key := range keys {
+		advisories[i] = found[key]
+	}
+	return advisories, nil
+}
+
+// publicKeys are the armored public OpenPGP keys of a provider
+// recorded to publish them together with its signatures.
+type publicKeys struct {
+	// Keys maps the fingerprints to the armored keys.
+	Keys map[string]string `json:"keys"`
+}
+
+// loadPublicKeys loads the recorded public keys of a provider.
+// A missing file results in an empty record.
+func loadPublicKeys(fname string) (*publicKeys, error) {
+	pk := &publicKeys{Keys: map[string]string{}}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return pk, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, pk); err != nil {
+		return nil, fmt.Errorf("cannot load %q: %w", fname, err)
+	}
+	if pk.Keys == nil {
+		pk.Keys = map[string]string{}
+	}
+	return pk, nil
+}
+
+// savePublicKeys adds the public keys of a key ring to the
+// recorded ones. Keys are never dropped as advisories stored
+// earlier may still be signed with them.
+func savePublicKeys(fname string, keys *crypto.KeyRing) error {
+	pk, err := loadPublicKeys(fname)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, key := range keys.GetKeys() {
+		fp := strings.ToUpper(key.GetFingerprint())
+		if _, ok := pk.Keys[fp]; ok {
+			continue
+		}
+		armored, err := key.GetArmoredPublicKey()
+		if err != nil {
+			return err
+		}
+		pk.Keys[fp] = armored
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return writeStateFile(fname, pk)
+}
+
+// loadTreeKeys loads the recorded public keys of all providers.
+func loadTreeKeys(cfg *Config) (map[string]*crypto.Key, error) {
+	files, err := filepath.Glob(
+		filepath.Join(cfg.Directory, stateDir, "*.openpgp.json"))
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]*crypto.Key{}
+	for _, fname := range files {
+		pk, err := loadPublicKeys(fname)
+		if err != nil {
+			return nil, err
+		}
+		for fp, armored := range pk.Keys {
+			key, err := crypto.NewKeyFromArmored(armored)
+			if err != nil {
+				cfg.logger().Warn("Ignoring invalid public key",
+					"file", fname,
+					"fingerprint", fp,
+					"error", err)
+				continue
+			}
+			keys[strings.ToUpper(key.GetFingerprint())] = key
+		}
+	}
+	return keys, nil
+}
+
+// verifyTreeSignature returns the fingerprint of the key which
+// made the armored signature of data or an error if no key did.
+func verifyTreeSignature(keys map[string]*crypto.Key, data, armored []byte) (string, error) {
+	sig, err := crypto.NewPGPSignatureFromArmored(string(armored))
+	if err != nil {
+		return "", err
+	}
+	pm := crypto.NewPlainMessage(data)
+	t := crypto.GetUnixTime()
+	for fp, key := range keys {
+		kr, err := crypto.NewKeyRing(key)
+		if err != nil {
+			continue
+		}
+		if kr.VerifyDetached(pm, sig, t) == nil {
+			return fp, nil
+		}
+	}
+	return "", errors.New("no known key made the signature")
+}
+
+// writeTreeKey writes an armored public key into the openpgp
+// folder of the tree and lists it in the provider metadata.
+func writeTreeKey(
+	dir string,
+	baseURL *url.URL,
+	pm *csaf.ProviderMetadata,
+	fingerprint, armored string,
+) error {
+	keysDir := filepath.Join(dir, "openpgp")
+	if err := os.MkdirAll(keysDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(
+		filepath.Join(keysDir, fingerprint+".asc"), []byte(armored), 0644,
+	); err != nil {
+		return err
+	}
+	pm.SetPGP(fingerprint,
+		baseURL.JoinPath("openpgp", fingerprint+".asc").String())
+	return nil
+}
+
+// buildProviderTree writes the given advisories with their hashes,
+// signatures and index files to dir. Only the downloaded signatures
+// made by the recorded keys of the providers are kept and these keys
+// are published with the tree. Returns the number of advisories
+// published without signature.
+func buildProviderTree(cfg *Config, dir string, advisories []*treeAdvisory) (int, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.ProviderURL, "/"))
+	if err != nil {
+		return 0, err
+	}
+
+	keys, err := loadTreeKeys(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		unsigned  int
+		used      = util.Set[string]{}
+		publisher *csaf.Publisher
+		mixed     bool
+		summaries = map[string][]tree.Summary{}
+	)
+
+	for _, adv := range advisories {
+		filename := filepath.Base(adv.path)
+		year := strconv.Itoa(adv.summary.InitialReleaseDate.Year())
+		yearDir := filepath.Join(dir, adv.label, year)
+		if err := os.MkdirAll(yearDir, 0755); err != nil {
+			return 0, err
+		}
+		data, err := os.ReadFile(adv.path)
+		if err != nil {
+			return 0, err
+		}
+		s256 := sha256.Sum256(data)
+		s512 := sha512.Sum512(data)
+		fname := filepath.Join(yearDir, filename)
+		if err := tree.WriteFileHashes(
+			fname, filename, data, s256[:], s512[:],
+		); err != nil {
+			return 0, err
+		}
+
+		// Keep the downloaded signature if it can be verified
+		// with a published key or sign it ourself.
+		sig, err := os.ReadFile(adv.path + ".asc")
+		switch {
+		case err == nil:
+			fp, err := verifyTreeSignature(keys, data, sig)
+			if err == nil {
+				used.Add(fp)
+				break
+			}
+			cfg.logger().Warn("Dropping unverifiable signature in provider tree",
+				"path", adv.path,
+				"error", err)
+			sig = nil
+		case !errors.Is(err, os.ErrNotExist):
+			return 0, err
+		}
+		switch {
+		case sig != nil:
+		case cfg.providerKey != nil:
+			armored, err := signDetached(cfg.providerKey, data)
+			if err != nil {
+				return 0, err
+			}
+			sig = []byte(armored)
+		default:
+			unsigned++
+		}
+		if sig != nil {
+			if err := os.WriteFile(fname+".asc", sig, 0644); err != nil {
+				return 0, err
+			}
+		}
+
+		switch {
+		case publisher == nil:
+			publisher = adv.summary.Publisher
+		case !publisher.Equals(adv.summary.Publisher):
+			mixed = true
+		}
+
+		summaries[adv.label] = append(summaries[adv.label], tree.Summary{
+			Filename: filename,
+			Summary:  adv.summary,
+			URL:      baseURL.JoinPath(adv.label, year, filename).String(),
+		})
+	}
+
+	rolie := cfg.ProviderDistribution != DistributionDirectory
+	tw := tree.Writer{
+		Dir:     dir,
+		BaseURL: baseURL,
+		Indices: cfg.ProviderDistribution != DistributionROLIE,
+		ROLIE:   rolie,
+		Service: rolie,
+		Log:     cfg.logger(),
+	}
+	if err := tw.WriteIndices(summaries, nil); err != nil {
+		return 0, err
+	}
+
+	// Write the provider metadata.
+	labels := make([]string, 0, len(summaries))
+	for label := range summaries {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+
+	var pm *csaf.ProviderMetadata
+	if rolie {
+		tlps := make([]csaf.TLPLabel, len(labels))
+		for i, label := range labels {
+			tlps[i] = csaf.TLPLabel(strings.ToUpper(label))
+		}
+		pm = csaf.NewProviderMetadataPrefix(baseURL.String(), tlps)
+	} else {
+		pm = csaf.NewProviderMetadata(
+			baseURL.JoinPath("provider-metadata.json").String())
+	}
+	if tw.Indices {
+		for _, label := range labels {
+			pm.AddDirectoryDistribution(baseURL.JoinPath(label).String())
+		}
+	}
+	role := csaf.MetadataRoleProvider
+	pm.Role = &role
+
+	switch {
+	case cfg.ProviderPublisher != nil:
+		pm.Publisher = cfg.ProviderPublisher
+	case publisher != nil && !mixed:
+		pm.Publisher = publisher
+	default:
+		return 0, errors.New(
+			"advisories of different publishers need a provider_publisher")
+	}
+
+	// Publish the keys of the kept signatures and our own.
+	fingerprints := slices.Sorted(maps.Keys(used))
+	for _, fp := range fingerprints {
+		armored, err := keys[fp].GetArmoredPublicKey()
+		if err != nil {
+			return 0, err
+		}
+		if err := writeTreeKey(dir, baseURL, pm, fp, armored); err != nil {
+			return 0, err
+		}
+	}
+	if cfg.providerKey != nil {
+		pub, err := cfg.providerKey.GetArmoredPublicKey()
+		if err != nil {
+			return 0, err
+		}
+		fingerprint := strings.ToUpper(cfg.providerKey.GetFingerprint())
+		if err := writeTreeKey(dir, baseURL, pm, fingerprint, pub); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := pm.Validate(); err != nil {
+		return 0, fmt.Errorf("invalid provider metadata: %w", err)
+	}
+	return unsigned, util.WriteToFile(
+		filepath.Join(dir, "provider-metadata.json"), pm)
+}
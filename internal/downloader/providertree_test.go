@@ -0,0 +1,268 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+)
+
+// copyFile copies a file into a folder which is created if needed.
+func copyFile(t *testing.T, src, dir, name string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrepareProviderTree(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		fail bool
+	}{
+		{"disabled", Config{}, false},
+		{"valid", Config{ProviderTree: filepath.Join(dir, "tree"), ProviderURL: "https://example.com/csaf"}, false},
+		{"no url", Config{ProviderTree: filepath.Join(dir, "tree")}, true},
+		{"relative url", Config{ProviderTree: filepath.Join(dir, "tree"), ProviderURL: "csaf"}, true},
+		{"no store", Config{ProviderTree: filepath.Join(dir, "tree"), ProviderURL: "https://example.com", NoStore: true}, true},
+		{"inside", Config{ProviderTree: filepath.Join(dir, "download", "tree"), ProviderURL: "https://example.com"}, true},
+		{"archive", Config{ProviderTree: filepath.Join(dir, "tree"), ProviderURL: "https://example.com", Storage: StorageZip}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.Directory = filepath.Join(dir, "download")
+			if cfg.Storage == "" {
+				cfg.Storage = StorageDir
+			}
+			if err := cfg.prepareProviderTree(); (err != nil) != tc.fail {
+				t.Errorf("unexpected result: %v", err)
+			}
+		})
+	}
+}
+
+func TestWriteProviderTree(t *testing.T) {
+	dir := t.TempDir()
+	download := filepath.Join(dir, "download")
+	src := "../../testdata/simple-rolie-provider/white/avendor-advisory-0004.json"
+
+	// A signed advisory in the default layout, a copy without
+	// signature in a quarantine folder and an unrelated file.
+	yearDir := filepath.Join(download, "white", "2020")
+	copyFile(t, src, yearDir, "avendor-advisory-0004.json")
+	copyFile(t, src+".asc", yearDir, "avendor-advisory-0004.json.asc")
+	copyFile(t, src, filepath.Join(download, quarantineDir), "avendor-advisory-0004.json")
+	if err := os.WriteFile(filepath.Join(download, "other.json"), []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	treeDir := filepath.Join(dir, "tree")
+	// Something left over from an old tree.
+	copyFile(t, src, treeDir, "stale.json")
+
+	cfg := &Config{
+		Directory:            download,
+		Storage:              StorageDir,
+		ProviderTree:         treeDir,
+		ProviderURL:          "https://example.com/.well-known/csaf/",
+		ProviderDistribution: DistributionBoth,
+		ProviderKey:          "../../testdata/simple-rolie-provider/openpgp/privkey.asc",
+		Logger:               slog.New(slog.DiscardHandler),
+	}
+	if err := cfg.prepareProviderTree(); err != nil {
+		t.Fatalf("preparing failed: %v", err)
+	}
+	if err := WriteProviderTree(cfg); err != nil {
+		t.Fatalf("writing tree failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(treeDir, "stale.json")); err == nil {
+		t.Error("old tree was not replaced")
+	}
+	for _, name := range []string{
+		"white/2020/avendor-advisory-0004.json",
+		"white/2020/avendor-advisory-0004.json.sha256",
+		"white/2020/avendor-advisory-0004.json.sha512",
+		"white/2020/avendor-advisory-0004.json.asc",
+		"white/index.txt",
+		"white/changes.csv",
+		"white/csaf-feed-tlp-white.json",
+		"service.json",
+	} {
+		if _, err := os.Stat(filepath.Join(treeDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("missing %s: %v", name, err)
+		}
+	}
+
+	index, err := os.ReadFile(filepath.Join(treeDir, "white", "index.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(index); got != "2020/avendor-advisory-0004.json\n" {
+		t.Errorf("unexpected index.txt %q", got)
+	}
+
+	f, err := os.Open(filepath.Join(treeDir, "provider-metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	pm, err := csaf.LoadProviderMetadata(f)
+	if err != nil {
+		t.Fatalf("invalid provider metadata: %v", err)
+	}
+	if pm.CanonicalURL == nil ||
+		*pm.CanonicalURL != "https://example.com/.well-known/csaf/provider-metadata.json" {
+		t.Errorf("unexpected canonical URL %v", pm.CanonicalURL)
+	}
+	if pm.Publisher == nil || pm.Publisher.Name == nil || *pm.Publisher.Name != "ACME Inc." {
+		t.Error("publisher not taken from the advisories")
+	}
+	if len(pm.Distributions) != 2 || pm.Distributions[0].Rolie == nil ||
+		pm.Distributions[1].DirectoryURL != "https://example.com/.well-known/csaf/white" {
+		data, _ := json.Marshal(pm.Distributions)
+		t.Errorf("unexpected distributions %s", data)
+	}
+	if len(pm.PGPKeys) != 1 || pm.PGPKeys[0].URL == nil ||
+		!strings.HasSuffix(*pm.PGPKeys[0].URL, ".asc") {
+		t.Error("missing OpenPGP key")
+	}
+	keyFile := filepath.Join(treeDir, "openpgp", string(pm.PGPKeys[0].Fingerprint)+".asc")
+	if _, err := os.Stat(keyFile); err != nil {
+		t.Errorf("missing public key: %v", err)
+	}
+
+	// Without signature the advisory is signed with the key.
+	if err := os.Remove(filepath.Join(yearDir, "avendor-advisory-0004.json.asc")); err != nil {
+		t.Fatal(err)
+	}
+	cfg.ProviderDistribution = DistributionDirectory
+	if err := WriteProviderTree(cfg); err != nil {
+		t.Fatalf("writing tree failed: %v", err)
+	}
+	sig, err := os.ReadFile(filepath.Join(treeDir, "white", "2020", "avendor-advisory-0004.json.asc"))
+	if err != nil || !strings.Contains(string(sig), "PGP SIGNATURE") {
+		t.Errorf("advisory not signed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(treeDir, "white", "csaf-feed-tlp-white.json")); err == nil {
+		t.Error("unexpected ROLIE feed in directory distribution")
+	}
+}
+
+func TestProviderTreeSignatures(t *testing.T) {
+	dir := t.TempDir()
+	download := filepath.Join(dir, "download")
+	src := "../../testdata/simple-rolie-provider/white/avendor-advisory-0004.json"
+	yearDir := filepath.Join(download, "white", "2020")
+	copyFile(t, src, yearDir, "avendor-advisory-0004.json")
+	copyFile(t, src+".asc", yearDir, "avendor-advisory-0004.json.asc")
+
+	original, err := os.ReadFile(src + ".asc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownKey, err := crypto.GenerateKey("tree", "tree@example.com", "x25519", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	treeDir := filepath.Join(dir, "tree")
+	cfg := &Config{
+		Directory:            download,
+		Storage:              StorageDir,
+		ProviderTree:         treeDir,
+		ProviderURL:          "https://example.com/.well-known/csaf/",
+		ProviderDistribution: DistributionDirectory,
+		Logger:               slog.New(slog.DiscardHandler),
+		providerKey:          ownKey,
+	}
+
+	write := func() (*csaf.ProviderMetadata, []byte) {
+		t.Helper()
+		if err := WriteProviderTree(cfg); err != nil {
+			t.Fatalf("writing tree failed: %v", err)
+		}
+		f, err := os.Open(filepath.Join(treeDir, "provider-metadata.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		pm, err := csaf.LoadProviderMetadata(f)
+		if err != nil {
+			t.Fatalf("invalid provider metadata: %v", err)
+		}
+		sig, err := os.ReadFile(filepath.Join(treeDir, "white", "2020", "avendor-advisory-0004.json.asc"))
+		if err != nil {
+			t.Fatalf("missing signature: %v", err)
+		}
+		return pm, sig
+	}
+
+	// Without the key of the provider its signature cannot be
+	// verified by clients and the advisory is signed again.
+	pm, sig := write()
+	if string(sig) == string(original) {
+		t.Error("unverifiable signature kept")
+	}
+	if len(pm.PGPKeys) != 1 ||
+		string(pm.PGPKeys[0].Fingerprint) != strings.ToUpper(ownKey.GetFingerprint()) {
+		t.Errorf("unexpected keys %+v", pm.PGPKeys)
+	}
+
+	// With the recorded key the signature is kept and the key published.
+	pub, err := os.ReadFile("../../testdata/simple-rolie-provider/openpgp/pubkey.asc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr, err := crypto.NewKeyRing(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.NewKeyFromArmored(string(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.AddKey(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := savePublicKeys(cfg.stateFile("example.com", "openpgp"), kr); err != nil {
+		t.Fatal(err)
+	}
+	pm, sig = write()
+	if string(sig) != string(original) {
+		t.Error("signature of provider not kept")
+	}
+	fp := strings.ToUpper(key.GetFingerprint())
+	if len(pm.PGPKeys) != 2 || !slices.ContainsFunc(pm.PGPKeys, func(k csaf.PGPKey) bool {
+		return string(k.Fingerprint) == fp
+	}) {
+		t.Errorf("key of provider not listed: %+v", pm.PGPKeys)
+	}
+	if _, err := os.Stat(filepath.Join(treeDir, "openpgp", fp+".asc")); err != nil {
+		t.Errorf("key of provider not published: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+const (
+	// quarantineDir is the name of the sub folder where
+	// advisories failing the checks are kept as evidence.
+	quarantineDir = "quarantine"
+	// quarantineExt is the extension of the sidecar files
+	// explaining why an advisory was quarantined.
+	quarantineExt = ".quarantine.json"
+)
+
+// failureClass is the class of a failed check of an advisory.
+type failureClass string
+
+const (
+	failureDownload         = failureClass("download_failed")
+	failureFilenameInvalid  = failureClass("filename_invalid")
+	failureInvalidJSON      = failureClass("invalid_json")
+	failureSHA256           = failureClass("sha256_mismatch")
+	failureSHA512           = failureClass("sha512_mismatch")
+	failureHashMissing      = failureClass("hash_missing")
+	failureSignature        = failureClass("bad_signature")
+	failureSignatureMissing = failureClass("signature_missing")
+	failureSchema           = failureClass("schema")
+	failureFilename         = failureClass("filename_mismatch")
+	failureRemote           = failureClass("remote_validation")
+	failureTLP              = failureClass("tlp_mismatch")
+)
+
+// checkError is the error of a failed check of an advisory.
+type checkError struct {
+	class failureClass
+	err   error
+}
+
+// Error implements [error].
+func (ce *checkError) Error() string {
+	return ce.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (ce *checkError) Unwrap() error {
+	return ce.err
+}
+
+// advisoryFailure is a failed check of an advisory.
+type advisoryFailure struct {
+	Class   failureClass `json:"class"`
+	Message string       `json:"message"`
+}
+
+// newAdvisoryFailure creates the failure of the given check error.
+func newAdvisoryFailure(err error) advisoryFailure {
+	var ce *checkError
+	if errors.As(err, &ce) {
+		return advisoryFailure{Class: ce.class, Message: ce.Error()}
+	}
+	return advisoryFailure{Class: "unknown", Message: err.Error()}
+}
+
+// quarantineRecord is the sidecar of a quarantined advisory.
+type quarantineRecord struct {
+	URL             string            `json:"url"`
+	Domain          string            `json:"domain"`
+	Mirror          string            `json:"mirror,omitempty"`
+	FeedTLP         string            `json:"feed_tlp,omitempty"`
+	DocumentTLP     string            `json:"document_tlp,omitempty"`
+	AdvisoryTLP     string            `json:"advisory_tlp"`
+	Quarantined     time.Time         `json:"quarantined"`
+	Failures        []advisoryFailure `json:"failures"`
+	SHA256          string            `json:"sha256"`
+	SHA512          string            `json:"sha512"`
+	RemoteSHA256    string            `json:"remote_sha256,omitempty"`
+	RemoteSHA512    string            `json:"remote_sha512,omitempty"`
+	SignatureKeyIDs []string          `json:"signature_key_ids,omitempty"`
+	TrustedKeys     []string          `json:"trusted_keys,omitempty"`
+}
+
+// quarantined is the evidence of an advisory failing the checks.
+type quarantined struct {
+	file         csaf.AdvisoryFile
+	filename     string
+	doc          any
+	failures     []advisoryFailure
+	remoteSHA256 []byte
+	remoteSHA512 []byte
+	s256Data     []byte
+	s512Data     []byte
+	signData     []byte
+}
+
+// quarantine stores the data of an advisory failing the checks
+// together with a sidecar explaining the failures. The advisories
+// are stored by domain and TLP label of the feed. Directory based
+// distributions have no feed labels. Their advisories are stored
+// by the labels they would be filed under.
+func (dc *downloadContext) quarantine(q *quarantined) {
+	if !dc.d.cfg.Quarantine {
+		return
+	}
+	data := dc.data.Bytes()
+	s256 := sha256.Sum256(data)
+	s512 := sha512.Sum512(data)
+
+	advisoryLabel, _ := csaf.AdvisoryTLPLabel(dc.expr, q.file, dc.label, q.doc)
+	var feedLabel csaf.TLPLabel
+	if !q.file.IsDirectory() {
+		feedLabel = dc.label
+	}
+
+	rec := quarantineRecord{
+		URL:         q.file.URL(),
+		Domain:      dc.pc.domain,
+		Mirror:      dc.pc.mirror,
+		FeedTLP:     string(feedLabel),
+		DocumentTLP: string(csaf.DocumentTLPLabel(dc.expr, q.doc)),
+		AdvisoryTLP: string(advisoryLabel),
+		Quarantined: time.Now().UTC(),
+		Failures:    q.failures,
+		SHA256:      hex.EncodeToString(s256[:]),
+		SHA512:      hex.EncodeToString(s512[:]),
+		TrustedKeys: dc.pc.fingerprints(),
+	}
+	if q.remoteSHA256 != nil {
+		rec.RemoteSHA256 = hex.EncodeToString(q.remoteSHA256)
+	}
+	if q.remoteSHA512 != nil {
+		rec.RemoteSHA512 = hex.EncodeToString(q.remoteSHA512)
+	}
+	if q.signData != nil {
+		if sign, err := crypto.NewPGPSignatureFromArmored(string(q.signData)); err == nil {
+			rec.SignatureKeyIDs, _ = sign.GetHexSignatureKeyIDs()
+		}
+	}
+	sidecar, err := json.MarshalIndent(&rec, "", "  ")
+	if err != nil {
+		dc.d.cfg.logger().Error("Quarantining advisory failed",
+			"url", q.file.URL(),
+			"error", err)
+		return
+	}
+
+	domain := strings.TrimSuffix(util.CleanFileName(dc.pc.domain), ".json")
+	dir := feedLabel
+	if dir == "" {
+		dir = advisoryLabel
+	}
+	name := path.Join(quarantineDir, domain, strings.ToLower(string(dir)), q.filename)
+	for _, x := range []struct {
+		n string
+		d []byte
+	}{
+		{name, data},
+		{name + ".sha256", q.s256Data},
+		{name + ".sha512", q.s512Data},
+		{name + ".asc", q.signData},
+		{name + quarantineExt, sidecar},
+	} {
+		if x.d != nil {
+			if err := dc.d.storage.WriteFile(x.n, x.d); err != nil {
+				dc.d.cfg.logger().Error("Quarantining advisory failed",
+					"url", q.file.URL(),
+					"error", err)
+				return
+			}
+		}
+	}
+	dc.d.cfg.logger().Warn("Quarantined advisory",
+		"url", q.file.URL(),
+		"path", dc.d.location(name))
+}
+
+// fingerprints returns the fingerprints of the keys
+// used to verify the signatures.
+func (pc *providerContext) fingerprints() []string {
+	if pc.keys == nil {
+		return nil
+	}
+	var fps []string
+	for _, key := range pc.keys.GetKeys() {
+		fps = append(fps, strings.ToUpper(key.GetFingerprint()))
+	}
+	return fps
+}
@@ -0,0 +1,180 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestDownloadQuarantine(t *testing.T) {
+	const badHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	for _, directoryProvider := range []bool{false, true} {
+		t.Run(fmt.Sprintf("directory=%t", directoryProvider), func(t *testing.T) {
+			params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+			provider := testutil.ProviderHandler(&params, directoryProvider)
+			// Serve a wrong SHA256 hash of the advisory.
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, ".json.sha256") {
+					w.Write([]byte(badHash + "  avendor-advisory-0004.json\n"))
+					return
+				}
+				provider(w, r)
+			}))
+			defer server.Close()
+			params.URL = server.URL
+
+			client := util.Client(server.Client())
+			domain := server.URL + "/provider-metadata.json"
+
+			tempDir := t.TempDir()
+			cfg := Config{
+				LogLevel:       &options.LogLevel{Level: slog.LevelError},
+				Directory:      tempDir,
+				Quarantine:     true,
+				PreferredHash:  algSha256,
+				ValidationMode: ValidationStrict,
+			}
+			if err := cfg.Prepare(); err != nil {
+				t.Fatalf("config failed: %v", err)
+			}
+			d, err := NewDownloader(&cfg)
+			if err != nil {
+				t.Fatalf("could not init downloader: %v", err)
+			}
+			defer d.Close()
+			d.client = &client
+
+			if err := d.Run(context.Background(), []string{domain}); err == nil {
+				t.Error("expected error")
+			}
+			if d.stats.sha256Failed != 1 {
+				t.Errorf("sha256 failed: got %d, want 1", d.stats.sha256Failed)
+			}
+
+			name := filepath.Join(tempDir, quarantineDir,
+				strings.TrimSuffix(util.CleanFileName(domain), ".json"),
+				"white", "avendor-advisory-0004.json")
+			for _, ext := range []string{"", ".sha256"} {
+				if !checkIfFileExists(name+ext, t) {
+					t.Errorf("%s not quarantined", name+ext)
+				}
+			}
+			if checkIfFileExists(filepath.Join(tempDir, failedValidationDir), t) {
+				t.Error("advisory failing in strict mode was stored")
+			}
+
+			data, err := os.ReadFile(name + quarantineExt)
+			if err != nil {
+				t.Fatalf("missing sidecar: %v", err)
+			}
+			var rec quarantineRecord
+			if err := json.Unmarshal(data, &rec); err != nil {
+				t.Fatal(err)
+			}
+			if len(rec.Failures) != 1 || rec.Failures[0].Class != failureSHA256 {
+				t.Errorf("failures: got %v, want a single %s", rec.Failures, failureSHA256)
+			}
+			if rec.RemoteSHA256 != badHash {
+				t.Errorf("remote sha256: got %q, want %q", rec.RemoteSHA256, badHash)
+			}
+			// Directory based distributions have no feed labels.
+			feedTLP := "WHITE"
+			if directoryProvider {
+				feedTLP = ""
+			}
+			if !strings.HasSuffix(rec.URL, "/avendor-advisory-0004.json") ||
+				rec.FeedTLP != feedTLP || rec.AdvisoryTLP != "WHITE" {
+				t.Errorf("unexpected record: %+v", rec)
+			}
+		})
+	}
+}
+
+func TestDownloadTLPMismatch(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	provider := testutil.ProviderHandler(&params, false)
+	// List the TLP:WHITE advisory in a TLP:GREEN feed.
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/provider-metadata.json" {
+			rec := httptest.NewRecorder()
+			provider(rec, r)
+			w.Write([]byte(strings.Replace(rec.Body.String(), `"tlp_label": "WHITE"`, `"tlp_label": "GREEN"`, 1)))
+			return
+		}
+		provider(w, r)
+	}))
+	defer server.Close()
+	params.URL = server.URL
+
+	for _, quarantine := range []bool{false, true} {
+		t.Run(fmt.Sprintf("quarantine=%t", quarantine), func(t *testing.T) {
+			client := util.Client(server.Client())
+			cfg := Config{
+				LogLevel:       &options.LogLevel{Level: slog.LevelError},
+				Directory:      t.TempDir(),
+				Quarantine:     quarantine,
+				ValidationMode: ValidationStrict,
+			}
+			if err := cfg.Prepare(); err != nil {
+				t.Fatalf("config failed: %v", err)
+			}
+			d, err := NewDownloader(&cfg)
+			if err != nil {
+				t.Fatalf("could not init downloader: %v", err)
+			}
+			defer d.Close()
+			d.client = &client
+
+			err = d.Run(context.Background(), []string{server.URL + "/provider-metadata.json"})
+			if (err != nil) != quarantine {
+				t.Errorf("unexpected result: %v", err)
+			}
+			want := 0
+			if quarantine {
+				want = 1
+			}
+			if d.stats.tlpMismatch != want || d.stats.succeeded != 1-want {
+				t.Errorf("tlp mismatch/succeeded: got %d/%d, want %d/%d",
+					d.stats.tlpMismatch, d.stats.succeeded, want, 1-want)
+			}
+			if !quarantine {
+				return
+			}
+			// The advisory is kept under the label of the feed.
+			data, err := os.ReadFile(filepath.Join(cfg.Directory, quarantineDir,
+				strings.TrimSuffix(util.CleanFileName(server.URL+"/provider-metadata.json"), ".json"),
+				"green", "avendor-advisory-0004.json"+quarantineExt))
+			if err != nil {
+				t.Fatalf("missing sidecar: %v", err)
+			}
+			var rec quarantineRecord
+			if err := json.Unmarshal(data, &rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec.FeedTLP != "GREEN" || rec.DocumentTLP != "WHITE" || rec.AdvisoryTLP != "GREEN" {
+				t.Errorf("unexpected labels: feed %q, document %q, advisory %q",
+					rec.FeedTLP, rec.DocumentTLP, rec.AdvisoryTLP)
+			}
+		})
+	}
+}
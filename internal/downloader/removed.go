@@ -0,0 +1,243 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/storage"
+	"github.com/gocsaf/csaf/v3/pkg/errs"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+const (
+	// stateDir is the name of the sub folder where the
+	// downloader keeps track of the stored advisories per domain.
+	stateDir = "state"
+	// removedDir is the name of the sub folder where advisories
+	// are moved to which are no longer listed by their provider.
+	removedDir = "removed"
+)
+
+// sidecarExts are the extensions of the files stored along
+// with an advisory.
+var sidecarExts = []string{"", ".sha256", ".sha512", ".asc"}
+
+// storedAdvisories keeps track of the advisories of a domain
+// which are stored locally. It is used to detect advisories
+// which were removed from the feeds of the provider.
+type storedAdvisories struct {
+	mu sync.Mutex
+	// Advisories maps the URLs of the advisories to
+	// their names in the storage.
+	Advisories map[string]string `json:"advisories"`
+	seen       util.Set[string]
+}
+
+// statePath returns the path of the file storing the
+// locally stored advisories of the given domain.
+func (cfg *Config) statePath(domain string) string {
+	return filepath.Join(cfg.Directory, stateDir, util.CleanFileName(domain))
+}
+
+// stateFile returns the path of the file storing
+// the state of the given kind of a domain.
+func (cfg *Config) stateFile(domain, kind string) string {
+	name := strings.TrimSuffix(util.CleanFileName(domain), ".json")
+	return filepath.Join(cfg.Directory, stateDir, name+"."+kind+".json")
+}
+
+// writeStateFile writes x as JSON into the given file.
+// The file is replaced atomically.
+func writeStateFile(fname string, x any) error {
+	if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := fname + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fname)
+}
+
+// loadStoredAdvisories loads the stored advisories of a domain.
+// A missing state file results in an empty list.
+func loadStoredAdvisories(fname string) (*storedAdvisories, error) {
+	sa := &storedAdvisories{
+		Advisories: map[string]string{},
+		seen:       util.Set[string]{},
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return sa, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, sa); err != nil {
+		return nil, fmt.Errorf("cannot load %q: %w", fname, err)
+	}
+	if sa.Advisories == nil {
+		sa.Advisories = map[string]string{}
+	}
+	return sa, nil
+}
+
+// save writes the stored advisories to the given file.
+func (sa *storedAdvisories) save(fname string) error {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	return writeStateFile(fname, sa)
+}
+
+// see marks the given advisory URLs as still listed by the provider.
+func (sa *storedAdvisories) see(files []csaf.AdvisoryFile) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	for _, f := range files {
+		sa.seen.Add(f.URL())
+	}
+}
+
+// store records that the advisory at url was stored
+// under the given name in the storage.
+func (sa *storedAdvisories) store(url, name string) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	sa.Advisories[url] = name
+}
+
+// removed returns the URLs of the stored advisories which
+// were not seen in the current run sorted alphabetically.
+func (sa *storedAdvisories) removed() []string {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	var urls []string
+	for url := range sa.Advisories {
+		if !sa.seen.Contains(url) {
+			urls = append(urls, url)
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// forget drops the advisory at url from the list of stored advisories.
+func (sa *storedAdvisories) forget(url string) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	delete(sa.Advisories, url)
+}
+
+// feedsComplete checks if the given error returned from processing
+// the feeds of a provider only stems from failed downloads of
+// single advisories. In this case all advisories listed by the
+// provider were seen.
+func feedsComplete(err error) bool {
+	if err == nil {
+		return true
+	}
+	var feedErrs *errs.CompositeErrFeed
+	if !errors.As(err, &feedErrs) {
+		return false
+	}
+	for _, e := range feedErrs.Errs {
+		var dlErrs *errs.CompositeErrCsafDownload
+		if !errors.As(e, &dlErrs) {
+			return false
+		}
+	}
+	return true
+}
+
+// handleRemoved applies the configured removal mode to a
+// stored advisory no longer listed by its provider.
+func (d *Downloader) handleRemoved(url, name string) error {
+	switch d.cfg.RemovedMode {
+	case RemovedMove:
+		dst := path.Join(removedDir, name)
+		for _, ext := range sidecarExts {
+			if err := storage.Move(d.storage, name+ext, dst+ext); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		d.cfg.logger().Info("Moved removed advisory", "url", url, "path", d.location(dst))
+	case RemovedDelete:
+		for _, ext := range sidecarExts {
+			if err := d.storage.Remove(name + ext); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		d.cfg.logger().Info("Deleted removed advisory", "url", url, "path", d.location(name))
+	default:
+		d.cfg.logger().Warn("Advisory was removed by provider", "url", url, "path", d.location(name))
+	}
+	return nil
+}
+
+// reconcile compares the advisories listed by the provider in this
+// run with the stored ones and handles the removed advisories.
+func (d *Downloader) reconcile(pc *providerContext) {
+	sa := pc.stored
+	var st stats
+	defer d.addStats(pc.domain, &st)
+	for _, url := range sa.removed() {
+		st.removed++
+		if err := d.handleRemoved(url, sa.Advisories[url]); err != nil {
+			d.cfg.logger().Error("Handling removed advisory failed",
+				"url", url,
+				"error", err)
+			continue
+		}
+		sa.forget(url)
+	}
+}
+
+// finishStored reconciles the stored advisories of a domain
+// with the advisories listed by its provider if the listing
+// is known to be complete and saves the result afterwards.
+func (d *Downloader) finishStored(
+	ctx context.Context,
+	pc *providerContext,
+	err error,
+) {
+	domain, sa := pc.domain, pc.stored
+	switch {
+	case pc.mirror != "":
+		d.cfg.logger().Debug("Not looking for removed advisories as a mirror was used",
+			"domain", domain)
+	case pc.cfg.Range != nil:
+		d.cfg.logger().Debug("Not looking for removed advisories as time range is set",
+			"domain", domain)
+	case ctx.Err() != nil || pc.incomplete || !feedsComplete(err):
+		d.cfg.logger().Warn("Not looking for removed advisories as feeds were not processed completely",
+			"domain", domain)
+	default:
+		d.reconcile(pc)
+	}
+	if err := sa.save(d.cfg.statePath(domain)); err != nil {
+		d.cfg.logger().Error("Saving stored advisories failed",
+			"domain", domain,
+			"error", err)
+	}
+}
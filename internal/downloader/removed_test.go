@@ -0,0 +1,141 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/storage"
+	"github.com/gocsaf/csaf/v3/pkg/errs"
+)
+
+func TestFeedsComplete(t *testing.T) {
+	for _, x := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, true},
+		{errors.New("failed"), false},
+		{&errs.CompositeErrFeed{Errs: []error{
+			&errs.CompositeErrCsafDownload{Errs: []error{errors.New("download")}},
+		}}, true},
+		{&errs.CompositeErrFeed{Errs: []error{
+			&errs.CompositeErrCsafDownload{Errs: []error{errors.New("download")}},
+			errs.ErrNetwork{Message: "feed"},
+		}}, false},
+	} {
+		if got := feedsComplete(x.err); got != x.want {
+			t.Errorf("feedsComplete(%v): got %t expected %t", x.err, got, x.want)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	for _, mode := range []RemovedMode{RemovedReport, RemovedMove, RemovedDelete} {
+		t.Run(string(mode), func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := Config{Directory: dir, RemovedMode: mode}
+			d := Downloader{cfg: &cfg, storage: storage.NewDir(dir)}
+
+			rel := "white/2020/a.json"
+			full := filepath.Join(dir, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, ext := range []string{"", ".sha256"} {
+				if err := os.WriteFile(full+ext, []byte("{}"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			state := cfg.statePath("example.com")
+			sa, err := loadStoredAdvisories(state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sa.store("https://example.com/a.json", rel)
+			sa.store("https://example.com/b.json", "white/2020/b.json")
+			sa.see([]csaf.AdvisoryFile{
+				csaf.PlainAdvisoryFile{Path: "https://example.com/b.json"},
+			})
+
+			d.reconcile(&providerContext{domain: "example.com", stored: sa})
+			if err := sa.save(state); err != nil {
+				t.Fatal(err)
+			}
+
+			if d.stats.removed != 1 {
+				t.Errorf("removed: got %d expected 1", d.stats.removed)
+			}
+
+			loaded, err := loadStoredAdvisories(state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, kept := loaded.Advisories["https://example.com/a.json"]
+			if kept {
+				t.Error("removed advisory is still tracked")
+			}
+			if _, ok := loaded.Advisories["https://example.com/b.json"]; !ok {
+				t.Error("listed advisory is not tracked any more")
+			}
+
+			_, statErr := os.Stat(full)
+			exists := statErr == nil
+			moved := filepath.Join(dir, removedDir, filepath.FromSlash(rel))
+			_, movedErr := os.Stat(moved + ".sha256")
+
+			switch mode {
+			case RemovedReport:
+				if !exists {
+					t.Error("reported advisory was removed")
+				}
+			case RemovedMove:
+				if exists || movedErr != nil {
+					t.Error("advisory was not moved")
+				}
+			case RemovedDelete:
+				if exists {
+					t.Error("advisory was not deleted")
+				}
+			}
+		})
+	}
+}
+
+func TestFinishStoredIncomplete(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{Directory: dir, RemovedMode: RemovedDelete}
+	d := Downloader{cfg: &cfg, storage: storage.NewDir(dir)}
+
+	full := filepath.Join(dir, "a.json")
+	if err := os.WriteFile(full, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sa, err := loadStoredAdvisories(cfg.statePath("example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sa.store("https://example.com/a.json", "a.json")
+
+	// A forbidden feed lists nothing without an error.
+	pc := &providerContext{cfg: &cfg, domain: "example.com", stored: sa, incomplete: true}
+	d.finishStored(t.Context(), pc, nil)
+
+	if d.stats.removed != 0 {
+		t.Errorf("removed: got %d expected 0", d.stats.removed)
+	}
+	if _, err := os.Stat(full); err != nil {
+		t.Errorf("advisory of incomplete feed was deleted: %v", err)
+	}
+}
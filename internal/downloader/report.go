@@ -0,0 +1,215 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// runReport is the machine readable report of a run.
+type runReport struct {
+	mu sync.Mutex
+
+	Started  time.Time       `json:"started"`
+	Finished time.Time       `json:"finished"`
+	Duration float64         `json:"duration_seconds"`
+	Counts   map[string]int  `json:"counts"`
+	Domains  []*domainReport `json:"domains"`
+	Error    string          `json:"error,omitempty"`
+	index    map[string]*domainReport
+}
+
+// domainReport is the report of a domain.
+type domainReport struct {
+	mu    sync.Mutex
+	bytes atomic.Int64
+
+	Domain   string          `json:"domain"`
+	Mirror   string          `json:"mirror,omitempty"`
+	Started  time.Time       `json:"started"`
+	Finished time.Time       `json:"finished"`
+	Duration float64         `json:"duration_seconds"`
+	Bytes    int64           `json:"bytes"`
+	Error    string          `json:"error,omitempty"`
+	Counts   map[string]int  `json:"counts"`
+	Feeds    []*feedReport   `json:"feeds"`
+	Failures []failureReport `json:"failures"`
+	Keys     map[string]int  `json:"keys,omitempty"`
+}
+
+// feedReport is the report of a feed of a domain.
+type feedReport struct {
+	dr    *domainReport
+	stats stats
+
+	Label      string         `json:"label"`
+	Advisories int            `json:"advisories"`
+	Counts     map[string]int `json:"counts"`
+}
+
+// failureReport is an advisory which failed.
+type failureReport struct {
+	URL string `json:"url"`
+	advisoryFailure
+}
+
+// newRunReport creates a new report of a run.
+func newRunReport() *runReport {
+	return &runReport{
+		Started: time.Now().UTC(),
+		index:   map[string]*domainReport{},
+	}
+}
+
+// domain returns the report of the given domain.
+// Returns nil if no report is written.
+func (rr *runReport) domain(domain string) *domainReport {
+	if rr == nil {
+		return nil
+	}
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	dr := rr.index[domain]
+	if dr == nil {
+		dr = &domainReport{
+			Domain:   domain,
+			Started:  time.Now().UTC(),
+			Feeds:    []*feedReport{},
+			Failures: []failureReport{},
+		}
+		rr.index[domain] = dr
+		rr.Domains = append(rr.Domains, dr)
+	}
+	return dr
+}
+
+// fail records the error of the run.
+func (rr *runReport) fail(err error) {
+	if rr == nil || err == nil {
+		return
+	}
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	rr.Error = err.Error()
+}
+
+// finish completes the report with the given stats.
+func (rr *runReport) finish(total *stats, domainStats map[string]*stats) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	rr.Finished = time.Now().UTC()
+	rr.Duration = rr.Finished.Sub(rr.Started).Seconds()
+	rr.Counts = total.counts()
+	for _, dr := range rr.Domains {
+		dr.mu.Lock()
+		st := domainStats[dr.Domain]
+		if st == nil {
+			st = new(stats)
+		}
+		dr.Counts = st.counts()
+		dr.Bytes = dr.bytes.Load()
+		for _, fr := range dr.Feeds {
+			fr.Counts = fr.stats.counts()
+		}
+		dr.mu.Unlock()
+	}
+}
+
+// client returns a client counting the transferred bytes
+// of the domain.
+func (dr *domainReport) client(client util.Client) util.Client {
+	if dr == nil {
+		return client
+	}
+	return &util.CountingClient{Client: client, Bytes: &dr.bytes}
+}
+
+// finish records the end of the download of the domain.
+func (dr *domainReport) finish(err error) {
+	if dr == nil {
+		return
+	}
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.Finished = time.Now().UTC()
+	dr.Duration = dr.Finished.Sub(dr.Started).Seconds()
+	if err != nil {
+		dr.Error = err.Error()
+	}
+}
+
+// provider records the mirror and the keys used
+// to download the advisories of the domain.
+func (dr *domainReport) provider(pc *providerContext) {
+	if dr == nil {
+		return
+	}
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.Mirror = pc.mirror
+	pc.usage.mu.Lock()
+	defer pc.usage.mu.Unlock()
+	if len(pc.usage.counts) > 0 {
+		dr.Keys = maps.Clone(pc.usage.counts)
+	}
+}
+
+// feed adds the report of a feed with the given
+// label and number of advisories.
+func (dr *domainReport) feed(label csaf.TLPLabel, advisories int) *feedReport {
+	if dr == nil {
+		return nil
+	}
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	fr := &feedReport{
+		dr:         dr,
+		Label:      strings.ToUpper(string(label)),
+		Advisories: advisories,
+	}
+	dr.Feeds = append(dr.Feeds, fr)
+	return fr
+}
+
+// failed records the failures of an advisory.
+func (dr *domainReport) failed(url string, failures ...advisoryFailure) {
+	if dr == nil {
+		return
+	}
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	for _, f := range failures {
+		dr.Failures = append(dr.Failures, failureReport{URL: url, advisoryFailure: f})
+	}
+}
+
+// add adds the stats of a worker to the feed.
+func (fr *feedReport) add(st *stats) {
+	if fr == nil {
+		return
+	}
+	fr.dr.mu.Lock()
+	defer fr.dr.mu.Unlock()
+	fr.stats.add(st)
+}
+
+// writeReport writes the report of the run to the configured file.
+func (d *Downloader) writeReport() error {
+	d.statsMu.Lock()
+	d.report.finish(&d.stats, d.domainStats)
+	d.statsMu.Unlock()
+	return writeStateFile(d.cfg.Report, d.report)
+}
@@ -0,0 +1,110 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestDownloadReport(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client := util.Client(server.Client())
+	good := server.URL + "/provider-metadata.json"
+	bad := server.URL + "/missing/provider-metadata.json"
+
+	tempDir := t.TempDir()
+	reportFile := filepath.Join(tempDir, "reports", "report.json")
+	cfg := Config{
+		LogLevel:  &options.LogLevel{Level: slog.LevelError},
+		Directory: tempDir,
+		Report:    reportFile,
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+	d, err := NewDownloader(&cfg)
+	if err != nil {
+		t.Fatalf("could not init downloader: %v", err)
+	}
+	defer d.Close()
+	d.client = &client
+
+	if err := d.Run(context.Background(), []string{good, bad}); err == nil {
+		t.Error("expected error")
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	var report struct {
+		Counts  map[string]int `json:"counts"`
+		Error   string         `json:"error"`
+		Domains []struct {
+			Domain   string         `json:"domain"`
+			Bytes    int64          `json:"bytes"`
+			Error    string         `json:"error"`
+			Counts   map[string]int `json:"counts"`
+			Keys     map[string]int `json:"keys"`
+			Failures []any          `json:"failures"`
+			Feeds    []struct {
+				Label      string         `json:"label"`
+				Advisories int            `json:"advisories"`
+				Counts     map[string]int `json:"counts"`
+			} `json:"feeds"`
+		} `json:"domains"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Counts["succeeded"] != 1 || report.Error == "" {
+		t.Errorf("unexpected totals: %v (%q)", report.Counts, report.Error)
+	}
+	if len(report.Domains) != 2 {
+		t.Fatalf("got %d domains, want 2", len(report.Domains))
+	}
+	for _, dr := range report.Domains {
+		switch dr.Domain {
+		case good:
+			if dr.Error != "" || dr.Counts["succeeded"] != 1 || dr.Bytes == 0 {
+				t.Errorf("unexpected report of %s: %+v", good, dr)
+			}
+			if len(dr.Feeds) != 1 || dr.Feeds[0].Label != "WHITE" ||
+				dr.Feeds[0].Advisories != 1 || dr.Feeds[0].Counts["succeeded"] != 1 {
+				t.Errorf("unexpected feeds: %+v", dr.Feeds)
+			}
+			if dr.Keys["A8914CA2F11139C6A69A0018FB3CD9B15DE61596"] != 1 {
+				t.Errorf("unexpected keys: %v", dr.Keys)
+			}
+			if len(dr.Failures) != 0 {
+				t.Errorf("unexpected failures: %v", dr.Failures)
+			}
+		case bad:
+			if dr.Error == "" {
+				t.Errorf("missing error of %s", bad)
+			}
+		default:
+			t.Errorf("unexpected domain %q", dr.Domain)
+		}
+	}
+}
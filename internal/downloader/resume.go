@@ -0,0 +1,211 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// checkpointInterval is the minimal time between
+// two saves of a checkpoint while downloading.
+const checkpointInterval = 5 * time.Second
+
+// checkpointPath returns the path of the file storing
+// the download progress of the given domain.
+func (cfg *Config) checkpointPath(domain string) string {
+	return cfg.stateFile(domain, "checkpoint")
+}
+
+// checkpoint is the download progress of a domain.
+type checkpoint struct {
+	mu     sync.Mutex
+	saveMu sync.Mutex
+	fname  string
+	saved  time.Time
+	logger *slog.Logger
+	// count is the number of feeds processed in this run.
+	count int
+
+	Feeds map[string]*feedCheckpoint `json:"feeds"`
+}
+
+// feedCheckpoint is the download progress of a feed.
+type feedCheckpoint struct {
+	// Position is the number of leading advisories of the
+	// feed which were completed.
+	Position int `json:"position"`
+	// Last is the URL of the last advisory before position.
+	Last string `json:"last,omitempty"`
+	// Completed are the URLs of the completed advisories.
+	Completed []string `json:"completed"`
+
+	completed util.Set[string]
+	// files are the advisories listed in the feed in this run.
+	files []csaf.AdvisoryFile
+	// indices maps the URLs to their positions in the feed
+	// and done tells which positions are completed.
+	indices map[string][]int
+	done    []bool
+}
+
+// newCheckpoint creates an empty checkpoint stored in the given file.
+func newCheckpoint(fname string, logger *slog.Logger) *checkpoint {
+	return &checkpoint{
+		fname:  fname,
+		logger: logger,
+		Feeds:  map[string]*feedCheckpoint{},
+	}
+}
+
+// loadCheckpoint loads the checkpoint of a domain.
+// A missing file results in an empty checkpoint.
+func loadCheckpoint(fname string, logger *slog.Logger) (*checkpoint, error) {
+	cp := newCheckpoint(fname, logger)
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cp, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("cannot load %q: %w", fname, err)
+	}
+	if cp.Feeds == nil {
+		cp.Feeds = map[string]*feedCheckpoint{}
+	}
+	for _, fc := range cp.Feeds {
+		fc.completed = util.Set[string]{}
+		for _, u := range fc.Completed {
+			fc.completed.Add(u)
+		}
+	}
+	return cp, nil
+}
+
+// feed returns the progress of the next feed of the domain
+// with the given label and advisories. Feeds are identified
+// by their order and their label.
+func (cp *checkpoint) feed(label csaf.TLPLabel, files []csaf.AdvisoryFile) *feedCheckpoint {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	key := fmt.Sprintf("%d:%s", cp.count, strings.ToLower(string(label)))
+	cp.count++
+	fc := cp.Feeds[key]
+	if fc == nil {
+		fc = &feedCheckpoint{completed: util.Set[string]{}}
+		cp.Feeds[key] = fc
+	}
+	fc.files = files
+	fc.indices = make(map[string][]int, len(files))
+	for i, f := range files {
+		fc.indices[f.URL()] = append(fc.indices[f.URL()], i)
+	}
+	fc.done = make([]bool, len(files))
+	return fc
+}
+
+// resumePosition returns the position in the feed to resume from.
+// The position is only used if the feed did not change before it.
+func (cp *checkpoint) resumePosition(fc *feedCheckpoint) int {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	pos := fc.Position
+	if pos <= 0 || pos > len(fc.files) || fc.files[pos-1].URL() != fc.Last {
+		return 0
+	}
+	for i := range pos {
+		fc.done[i] = true
+	}
+	return pos
+}
+
+// isCompleted checks if the advisory at the given URL was
+// completed in a former run.
+func (cp *checkpoint) isCompleted(fc *feedCheckpoint, url string) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return fc.completed.Contains(url)
+}
+
+// complete marks the advisory at the given URL as completed
+// and saves the checkpoint if the last save is long enough ago.
+func (cp *checkpoint) complete(fc *feedCheckpoint, url string) {
+	cp.mu.Lock()
+	fc.completed.Add(url)
+	for _, i := range fc.indices[url] {
+		fc.done[i] = true
+	}
+	for fc.Position < len(fc.done) && fc.done[fc.Position] {
+		fc.Position++
+	}
+	if fc.Position > 0 {
+		fc.Last = fc.files[fc.Position-1].URL()
+	}
+	due := time.Since(cp.saved) >= checkpointInterval
+	if due {
+		// Prevent concurrent saves of other workers.
+		cp.saved = time.Now()
+	}
+	cp.mu.Unlock()
+	if due {
+		cp.trySave()
+	}
+}
+
+// save writes the checkpoint to its file.
+func (cp *checkpoint) save() error {
+	cp.saveMu.Lock()
+	defer cp.saveMu.Unlock()
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	for _, fc := range cp.Feeds {
+		fc.Completed = slices.Sorted(maps.Keys(fc.completed))
+	}
+	cp.saved = time.Now()
+	return writeStateFile(cp.fname, cp)
+}
+
+// trySave saves the checkpoint and logs errors.
+func (cp *checkpoint) trySave() {
+	if err := cp.save(); err != nil {
+		cp.logger.Error("Saving checkpoint failed",
+			"file", cp.fname,
+			"error", err)
+	}
+}
+
+// finish saves the checkpoint of a domain. If all advisories
+// of the domain were processed without errors the checkpoint
+// is removed as there is nothing left to resume.
+func (cp *checkpoint) finish(complete bool) {
+	if !complete {
+		cp.trySave()
+		return
+	}
+	cp.saveMu.Lock()
+	defer cp.saveMu.Unlock()
+	if err := os.Remove(cp.fname); err != nil && !errors.Is(err, os.ErrNotExist) {
+		cp.logger.Error("Removing checkpoint failed",
+			"file", cp.fname,
+			"error", err)
+	}
+}
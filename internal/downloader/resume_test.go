@@ -0,0 +1,129 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestCheckpoint(t *testing.T) {
+	files := []csaf.AdvisoryFile{
+		csaf.PlainAdvisoryFile{Path: "https://example.com/a.json"},
+		csaf.PlainAdvisoryFile{Path: "https://example.com/b.json"},
+		csaf.PlainAdvisoryFile{Path: "https://example.com/c.json"},
+	}
+	fname := filepath.Join(t.TempDir(), "state", "example_com.checkpoint.json")
+
+	cp := newCheckpoint(fname, slog.Default())
+	fc := cp.feed(csaf.TLPLabelWhite, files)
+
+	// Completion out of order only advances the position
+	// over the leading completed advisories.
+	cp.complete(fc, files[1].URL())
+	if fc.Position != 0 {
+		t.Errorf("position: got %d, want 0", fc.Position)
+	}
+	cp.complete(fc, files[0].URL())
+	if fc.Position != 2 || fc.Last != files[1].URL() {
+		t.Errorf("position: got %d (%q), want 2 (%q)", fc.Position, fc.Last, files[1].URL())
+	}
+	if err := cp.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadCheckpoint(fname, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lfc := loaded.feed(csaf.TLPLabelWhite, files)
+	if pos := loaded.resumePosition(lfc); pos != 2 {
+		t.Errorf("resume position: got %d, want 2", pos)
+	}
+	if !loaded.isCompleted(lfc, files[1].URL()) || loaded.isCompleted(lfc, files[2].URL()) {
+		t.Error("completed advisories not restored")
+	}
+
+	// A changed feed is not resumed by position.
+	loaded, err = loadCheckpoint(fname, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := []csaf.AdvisoryFile{files[2], files[0], files[1]}
+	if pos := loaded.resumePosition(loaded.feed(csaf.TLPLabelWhite, changed)); pos != 0 {
+		t.Errorf("resume position of changed feed: got %d, want 0", pos)
+	}
+
+	// A completed run removes the checkpoint.
+	loaded.finish(true)
+	if _, err := os.Stat(fname); !os.IsNotExist(err) {
+		t.Errorf("checkpoint not removed: %v", err)
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client := util.Client(server.Client())
+	domain := server.URL + "/provider-metadata.json"
+	advisory := server.URL + "/white/avendor-advisory-0004.json"
+
+	tempDir := t.TempDir()
+	cfg := Config{
+		LogLevel:  &options.LogLevel{Level: slog.LevelError},
+		Directory: tempDir,
+		Resume:    true,
+	}
+	if err := cfg.Prepare(); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+
+	// Simulate an interrupted run which completed the advisory.
+	cp := newCheckpoint(cfg.checkpointPath(domain), slog.Default())
+	fc := cp.feed(csaf.TLPLabelWhite, []csaf.AdvisoryFile{
+		csaf.PlainAdvisoryFile{Path: advisory},
+	})
+	cp.complete(fc, advisory)
+	if err := cp.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := NewDownloader(&cfg)
+	if err != nil {
+		t.Fatalf("could not init downloader: %v", err)
+	}
+	defer d.Close()
+	d.client = &client
+
+	if err := d.Run(context.Background(), []string{domain}); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+	if d.stats.skipped != 1 || d.stats.succeeded != 0 {
+		t.Errorf("got %d skipped and %d succeeded, want 1 and 0",
+			d.stats.skipped, d.stats.succeeded)
+	}
+	if checkIfFileExists(tempDir+"/white/2020/avendor-advisory-0004.json", t) {
+		t.Error("completed advisory was downloaded again")
+	}
+	if checkIfFileExists(cfg.checkpointPath(domain), t) {
+		t.Error("checkpoint of completed run was not removed")
+	}
+}
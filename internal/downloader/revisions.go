@@ -0,0 +1,217 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/storage"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+const (
+	// revisionsDir is the name of the sub folder where
+	// all distinct revisions of the advisories are kept.
+	revisionsDir = "revisions"
+	// revisionsIndex is the name of the file listing
+	// the kept revisions of an advisory.
+	revisionsIndex = "revisions.json"
+)
+
+// revision is an entry in the revisions index of an advisory.
+type revision struct {
+	Version            string    `json:"version"`
+	CurrentReleaseDate time.Time `json:"current_release_date"`
+	File               string    `json:"file"`
+	SHA256             string    `json:"sha256"`
+	URL                string    `json:"url"`
+	Downloaded         time.Time `json:"downloaded"`
+}
+
+// revisions is the revisions index of an advisory.
+type revisions struct {
+	ID        string     `json:"id"`
+	Revisions []revision `json:"revisions"`
+}
+
+// loadRevisions loads a revisions index from a storage.
+// A missing file results in an empty index.
+func loadRevisions(s storage.Storage, name string) (*revisions, error) {
+	data, err := s.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &revisions{}, nil
+		}
+		return nil, err
+	}
+	var revs revisions
+	if err := json.Unmarshal(data, &revs); err != nil {
+		return nil, fmt.Errorf("cannot load %q: %w", name, err)
+	}
+	return &revs, nil
+}
+
+// save writes the revisions index to a storage.
+func (revs *revisions) save(s storage.Storage, name string) error {
+	data, err := json.MarshalIndent(revs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.WriteFile(name, data)
+}
+
+// find looks for a revision with the given hash.
+func (revs *revisions) find(sha256 string) *revision {
+	for i := range revs.Revisions {
+		if revs.Revisions[i].SHA256 == sha256 {
+			return &revs.Revisions[i]
+		}
+	}
+	return nil
+}
+
+// hasFile checks if a file name is already used by a revision.
+func (revs *revisions) hasFile(file string) bool {
+	for i := range revs.Revisions {
+		if revs.Revisions[i].File == file {
+			return true
+		}
+	}
+	return false
+}
+
+// revisionInfo contains the tracking information of a downloaded
+// advisory needed to handle its revisions.
+type revisionInfo struct {
+	version            string
+	currentReleaseDate time.Time
+}
+
+// extractRevisionInfo extracts the tracking information from an advisory.
+func extractRevisionInfo(expr *util.PathEval, doc any) (*revisionInfo, error) {
+	var ri revisionInfo
+	if err := expr.Match([]util.PathEvalMatcher{
+		{Expr: `$.document.tracking.version`, Action: util.StringMatcher(&ri.version)},
+		{
+			Expr:     `$.document.tracking.current_release_date`,
+			Action:   util.TimeMatcher(&ri.currentReleaseDate, time.RFC3339),
+			Optional: true,
+		},
+	}, doc); err != nil {
+		return nil, err
+	}
+	return &ri, nil
+}
+
+// storeRevision keeps the given data of an advisory as a distinct
+// revision in the revisions folder of its domain and updates the
+// revisions index. Revisions already known by their hash are not
+// stored again.
+func (d *Downloader) storeRevision(
+	domain, filename, url string,
+	ri *revisionInfo,
+	data, s256Data, s512Data, signData []byte,
+) error {
+	d.revisionsMu.Lock()
+	defer d.revisionsMu.Unlock()
+
+	id := strings.TrimSuffix(filename, ".json")
+	dir := path.Join(
+		revisionsDir, cleanPathComponent(providerDomain(domain)), id)
+
+	indexName := path.Join(dir, revisionsIndex)
+	revs, err := loadRevisions(d.storage, indexName)
+	if err != nil {
+		return err
+	}
+	revs.ID = id
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if revs.find(hash) != nil {
+		// Already known.
+		return nil
+	}
+
+	// The version is taken from the document and may be anything
+	// in unsafe validation mode.
+	version := cleanPathComponent(ri.version)
+	file := version + ".json"
+	if revs.hasFile(file) {
+		// Same version but different content.
+		d.cfg.logger().Warn("Content of advisory changed without new version",
+			"url", url,
+			"version", ri.version)
+		file = version + "+" + hash[:8] + ".json"
+	}
+
+	name := path.Join(dir, file)
+	for _, x := range []struct {
+		n string
+		d []byte
+	}{
+		{name, data},
+		{name + ".sha256", s256Data},
+		{name + ".sha512", s512Data},
+		{name + ".asc", signData},
+	} {
+		if x.d != nil {
+			if err := d.storage.WriteFile(x.n, x.d); err != nil {
+				return err
+			}
+		}
+	}
+
+	revs.Revisions = append(revs.Revisions, revision{
+		Version:            ri.version,
+		CurrentReleaseDate: ri.currentReleaseDate.UTC(),
+		File:               file,
+		SHA256:             hash,
+		URL:                url,
+		Downloaded:         time.Now().UTC(),
+	})
+
+	if err := revs.save(d.storage, indexName); err != nil {
+		return err
+	}
+	d.cfg.logger().Info("Stored revision", "path", d.location(name))
+	return nil
+}
+
+// newerLocalRevision checks if there is an advisory stored under
+// the given name which has a newer version than the given one.
+// Returns the version of the local advisory in this case.
+func newerLocalRevision(
+	s storage.Storage,
+	name string,
+	version string,
+) (string, bool) {
+	data, err := s.ReadFile(name)
+	if err != nil {
+		return "", false
+	}
+	md, err := csaf.ParseDocumentMetadata(data)
+	if err != nil || md.Version == "" {
+		return "", false
+	}
+	if csaf.RevisionNumber(md.Version).Compare(csaf.RevisionNumber(version)) > 0 {
+		return md.Version, true
+	}
+	return "", false
+}
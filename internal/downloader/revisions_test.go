@@ -0,0 +1,98 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/internal/storage"
+)
+
+func TestStoreRevision(t *testing.T) {
+	dir := t.TempDir()
+	d := Downloader{cfg: &Config{Directory: dir}, storage: storage.NewDir(dir)}
+
+	store := func(domain, version, content string) {
+		t.Helper()
+		if err := d.storeRevision(
+			domain, "a.json", "https://"+domain+"/a.json",
+			&revisionInfo{version: version},
+			[]byte(content), []byte("sha256"), nil, nil,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store("example.com", "1", `{"v":1}`)
+	store("example.com", "1", `{"v":1}`)
+	store("example.com", "2", `{"v":2}`)
+	store("example.com", "2", `{"v":3}`)
+	// Another provider with the same file name and a version
+	// which is not usable as file name.
+	store("example.org", "1/2", `{"v":4}`)
+
+	load := func(domain string) *revisions {
+		t.Helper()
+		revs, err := loadRevisions(d.storage, path.Join(revisionsDir, domain, "a", revisionsIndex))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rev := range revs.Revisions {
+			fname := filepath.Join(dir, revisionsDir, domain, "a", rev.File)
+			for _, p := range []string{fname, fname + ".sha256"} {
+				if _, err := os.Stat(p); err != nil {
+					t.Errorf("missing revision file: %v", err)
+				}
+			}
+		}
+		return revs
+	}
+
+	revs := load("example_com")
+	if len(revs.Revisions) != 3 {
+		t.Fatalf("got %d revisions, expected 3", len(revs.Revisions))
+	}
+	if f := revs.Revisions[2].File; f == "2.json" {
+		t.Errorf("changed content of same version overwrote %q", f)
+	}
+	revs = load("example_org")
+	if len(revs.Revisions) != 1 || revs.Revisions[0].File != "1_2.json" {
+		t.Errorf("unexpected revisions of other provider %+v", revs.Revisions)
+	}
+}
+
+func TestNewerLocalRevision(t *testing.T) {
+	s := storage.NewDir(t.TempDir())
+	doc := `{"document":{"tracking":{"version":"1.1.0"}}}`
+	if err := s.WriteFile("a.json", []byte(doc)); err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []struct {
+		version string
+		newer   bool
+	}{
+		{"1.0.0", true},
+		{"1.1.0", false},
+		{"1.2.0", false},
+	} {
+		local, newer := newerLocalRevision(s, "a.json", x.version)
+		if newer != x.newer {
+			t.Errorf("%s: got %t expected %t", x.version, newer, x.newer)
+		}
+		if newer && local != "1.1.0" {
+			t.Errorf("%s: got local version %q", x.version, local)
+		}
+	}
+	if _, newer := newerLocalRevision(s, "b.json", "1"); newer {
+		t.Error("missing file reported as newer")
+	}
+}
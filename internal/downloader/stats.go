@@ -0,0 +1,107 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2023 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2023 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import "log/slog"
+
+// stats contains counters of the downloads.
+type stats struct {
+	downloadFailed    int
+	filenameFailed    int
+	schemaFailed      int
+	remoteFailed      int
+	sha256Failed      int
+	sha512Failed      int
+	signatureFailed   int
+	hashMissing       int
+	tlpMismatch       int
+	signed            int
+	succeeded         int
+	removed           int
+	versionRegression int
+	filtered          int
+	skipped           int
+	hookFailed        int
+}
+
+// add adds other stats to this.
+func (st *stats) add(o *stats) {
+	st.downloadFailed += o.downloadFailed
+	st.filenameFailed += o.filenameFailed
+	st.schemaFailed += o.schemaFailed
+	st.remoteFailed += o.remoteFailed
+	st.sha256Failed += o.sha256Failed
+	st.sha512Failed += o.sha512Failed
+	st.signatureFailed += o.signatureFailed
+	st.hashMissing += o.hashMissing
+	st.tlpMismatch += o.tlpMismatch
+	st.signed += o.signed
+	st.succeeded += o.succeeded
+	st.removed += o.removed
+	st.versionRegression += o.versionRegression
+	st.filtered += o.filtered
+	st.skipped += o.skipped
+	st.hookFailed += o.hookFailed
+}
+
+func (st *stats) totalFailed() int {
+	return st.downloadFailed +
+		st.filenameFailed +
+		st.schemaFailed +
+		st.remoteFailed +
+		st.sha256Failed +
+		st.sha512Failed +
+		st.signatureFailed +
+		st.hashMissing +
+		st.tlpMismatch
+}
+
+// log logs the collected stats.
+func (st *stats) log(logger *slog.Logger) {
+	logger.Info("Download statistics", st.attrs()...)
+}
+
+// logDomain logs the collected stats of a domain.
+func (st *stats) logDomain(logger *slog.Logger, domain string) {
+	logger.Info("Download statistics of domain",
+		append([]any{"domain", domain}, st.attrs()...)...)
+}
+
+// attrs returns the stats as logging attributes.
+func (st *stats) attrs() []any {
+	return []any{
+		"succeeded", st.succeeded,
+		"total_failed", st.totalFailed(),
+		"filename_failed", st.filenameFailed,
+		"download_failed", st.downloadFailed,
+		"schema_failed", st.schemaFailed,
+		"remote_failed", st.remoteFailed,
+		"sha256_failed", st.sha256Failed,
+		"sha512_failed", st.sha512Failed,
+		"signature_failed", st.signatureFailed,
+		"hash_missing", st.hashMissing,
+		"tlp_mismatch", st.tlpMismatch,
+		"signed", st.signed,
+		"removed", st.removed,
+		"version_regression", st.versionRegression,
+		"filtered", st.filtered,
+		"skipped", st.skipped,
+		"hook_failed", st.hookFailed,
+	}
+}
+
+// counts returns the stats as a map for reporting.
+func (st *stats) counts() map[string]int {
+	attrs := st.attrs()
+	counts := make(map[string]int, len(attrs)/2)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		counts[attrs[i].(string)] = attrs[i+1].(int)
+	}
+	return counts
+}
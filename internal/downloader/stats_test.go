@@ -6,7 +6,7 @@
 // SPDX-FileCopyrightText: 2023 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
 // Software-Engineering: 2023 Intevation GmbH <https://intevation.de>
 
-package csaf_downloader
+package downloader
 
 import (
 	"bytes"
@@ -17,14 +17,22 @@ import (
 
 func TestStatsAdd(t *testing.T) {
 	a := stats{
-		downloadFailed:  2,
-		filenameFailed:  3,
-		schemaFailed:    5,
-		remoteFailed:    7,
-		sha256Failed:    11,
-		sha512Failed:    13,
-		signatureFailed: 17,
-		succeeded:       19,
+		downloadFailed:    2,
+		filenameFailed:    3,
+		schemaFailed:      5,
+		remoteFailed:      7,
+		sha256Failed:      11,
+		sha512Failed:      13,
+		signatureFailed:   17,
+		hashMissing:       41,
+		tlpMismatch:       47,
+		signed:            43,
+		succeeded:         19,
+		removed:           23,
+		versionRegression: 29,
+		filtered:          31,
+		skipped:           37,
+		hookFailed:        53,
 	}
 	b := a
 	a.add(&b)
@@ -35,7 +43,15 @@ func TestStatsAdd(t *testing.T) {
 	b.sha256Failed *= 2
 	b.sha512Failed *= 2
 	b.signatureFailed *= 2
+	b.hashMissing *= 2
+	b.tlpMismatch *= 2
+	b.signed *= 2
 	b.succeeded *= 2
+	b.removed *= 2
+	b.versionRegression *= 2
+	b.filtered *= 2
+	b.skipped *= 2
+	b.hookFailed *= 2
 	if a != b {
 		t.Fatalf("%v != %v", a, b)
 	}
@@ -50,6 +66,8 @@ func TestStatsTotalFailed(t *testing.T) {
 		sha256Failed:    11,
 		sha512Failed:    13,
 		signatureFailed: 17,
+		hashMissing:     41,
+		tlpMismatch:     47,
 	}
 	sum := a.downloadFailed +
 		a.filenameFailed +
@@ -57,7 +75,9 @@ func TestStatsTotalFailed(t *testing.T) {
 		a.remoteFailed +
 		a.sha256Failed +
 		a.sha512Failed +
-		a.signatureFailed
+		a.signatureFailed +
+		a.hashMissing +
+		a.tlpMismatch
 	if got := a.totalFailed(); got != sum {
 		t.Fatalf("got %d expected %d", got, sum)
 	}
@@ -66,20 +86,25 @@ func TestStatsTotalFailed(t *testing.T) {
 func TestStatsLog(t *testing.T) {
 	var out bytes.Buffer
 	h := slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo})
-	orig := slog.Default()
-	defer slog.SetDefault(orig)
-	slog.SetDefault(slog.New(h))
 	a := stats{
-		downloadFailed:  2,
-		filenameFailed:  3,
-		schemaFailed:    5,
-		remoteFailed:    7,
-		sha256Failed:    11,
-		sha512Failed:    13,
-		signatureFailed: 17,
-		succeeded:       19,
+		downloadFailed:    2,
+		filenameFailed:    3,
+		schemaFailed:      5,
+		remoteFailed:      7,
+		sha256Failed:      11,
+		sha512Failed:      13,
+		signatureFailed:   17,
+		hashMissing:       41,
+		tlpMismatch:       47,
+		signed:            43,
+		succeeded:         19,
+		removed:           23,
+		versionRegression: 29,
+		filtered:          31,
+		skipped:           37,
+		hookFailed:        53,
 	}
-	a.log()
+	a.log(slog.New(h))
 	type result struct {
 		Succeeded       int `json:"succeeded"`
 		TotalFailed     int `json:"total_failed"`
@@ -90,6 +115,14 @@ func TestStatsLog(t *testing.T) {
 		SHA256Failed    int `json:"sha256_failed"`
 		SHA512Failed    int `json:"sha512_failed"`
 		SignatureFailed int `json:"signature_failed"`
+		HashMissing     int `json:"hash_missing"`
+		TLPMismatch     int `json:"tlp_mismatch"`
+		Signed          int `json:"signed"`
+		Removed         int `json:"removed"`
+		Regression      int `json:"version_regression"`
+		Filtered        int `json:"filtered"`
+		Skipped         int `json:"skipped"`
+		HookFailed      int `json:"hook_failed"`
 	}
 	var got result
 	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
@@ -105,6 +138,14 @@ func TestStatsLog(t *testing.T) {
 		SHA256Failed:    a.sha256Failed,
 		SHA512Failed:    a.sha512Failed,
 		SignatureFailed: a.signatureFailed,
+		HashMissing:     a.hashMissing,
+		TLPMismatch:     a.tlpMismatch,
+		Signed:          a.signed,
+		Removed:         a.removed,
+		Regression:      a.versionRegression,
+		Filtered:        a.filtered,
+		Skipped:         a.skipped,
+		HookFailed:      a.hookFailed,
 	}
 	if got != want {
 		t.Fatalf("%v != %v", got, want)
@@ -0,0 +1,274 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/gopenpgp/v2/armor"
+	"github.com/ProtonMail/gopenpgp/v2/constants"
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// normalizeFingerprint returns the fingerprint in upper case
+// without white space. Only fingerprints of v4 (40 hex digits)
+// and v5/v6 keys (64 hex digits) are accepted.
+func normalizeFingerprint(fp string) (string, error) {
+	fp = strings.ToUpper(strings.Join(strings.Fields(fp), ""))
+	if _, err := hex.DecodeString(fp); err != nil || (len(fp) != 40 && len(fp) != 64) {
+		return "", fmt.Errorf("invalid OpenPGP fingerprint %q", fp)
+	}
+	return fp, nil
+}
+
+// normalizeFingerprints normalizes the given fingerprints in place.
+func normalizeFingerprints(fps []string) error {
+	for i, fp := range fps {
+		nfp, err := normalizeFingerprint(fp)
+		if err != nil {
+			return err
+		}
+		fps[i] = nfp
+	}
+	return nil
+}
+
+// loadKeyring loads the public OpenPGP keys from an
+// armored or binary keyring file.
+func loadKeyring(fname string) ([]*crypto.Key, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	if bin, err := armor.Unarmor(string(data)); err == nil {
+		data = bin
+	}
+	kr, err := crypto.NewKeyRingFromBinary(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load keyring %q: %w", fname, err)
+	}
+	keys := kr.GetKeys()
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring %q contains no keys", fname)
+	}
+	return keys, nil
+}
+
+// loadPrivateKey loads an armored private OpenPGP key
+// and unlocks it with the passphrase if given.
+func loadPrivateKey(fname string, passphrase *string) (*crypto.Key, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.NewKeyFromArmored(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot load key %q: %w", fname, err)
+	}
+	if passphrase != nil {
+		if key, err = key.Unlock([]byte(*passphrase)); err != nil {
+			return nil, fmt.Errorf("cannot unlock key %q: %w", fname, err)
+		}
+	}
+	return key, nil
+}
+
+// signDetached returns an armored detached signature of data.
+func signDetached(key *crypto.Key, data []byte) (string, error) {
+	kr, err := crypto.NewKeyRing(key)
+	if err != nil {
+		return "", err
+	}
+	sig, err := kr.SignDetached(crypto.NewPlainMessage(data))
+	if err != nil {
+		return "", err
+	}
+	return armor.ArmorWithTypeAndCustomHeaders(
+		sig.Data, constants.PGPSignatureHeader, "", "")
+}
+
+// prepareTrust checks the domain specific trusted fingerprints
+// and loads the domain specific keyring.
+func (dc *DomainConfig) prepareTrust() error {
+	if err := normalizeFingerprints(dc.TrustedKeys); err != nil {
+		return fmt.Errorf("%w for %q", err, dc.Domain)
+	}
+	if dc.Keyring != nil {
+		keys, err := loadKeyring(*dc.Keyring)
+		if err != nil {
+			return fmt.Errorf("%w for %q", err, dc.Domain)
+		}
+		dc.keyring = keys
+	}
+	return nil
+}
+
+// prepareTrust checks the trusted fingerprints and loads the keyring.
+func (cfg *Config) prepareTrust() error {
+	if err := normalizeFingerprints(cfg.TrustedKeys); err != nil {
+		return err
+	}
+	if cfg.Keyring != "" {
+		keys, err := loadKeyring(cfg.Keyring)
+		if err != nil {
+			return err
+		}
+		cfg.keyring = keys
+	}
+	for _, dc := range cfg.Domains {
+		if err := dc.prepareTrust(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// needsKeys returns true if the advisories of the provider
+// cannot be downloaded without trusted keys.
+func (pc *providerContext) needsKeys() bool {
+	return !pc.hashesOnly() && (pc.signatureRequired() ||
+		len(pc.cfg.TrustedKeys) > 0 || pc.cfg.keyring != nil)
+}
+
+// signatureRequired returns true if every advisory
+// has to carry a valid signature.
+func (pc *providerContext) signatureRequired() bool {
+	// Mirrored advisories have to carry a valid signature
+	// of the original provider in any case.
+	return pc.cfg.SignaturePolicy == SignatureRequired || pc.mirror != ""
+}
+
+// hashesOnly returns true if the integrity of the advisories
+// is proven by their hashes and signatures are ignored.
+func (pc *providerContext) hashesOnly() bool {
+	return pc.cfg.SignaturePolicy == SignatureHashes && pc.mirror == ""
+}
+
+// addKey adds a public OpenPGP key to the keys used to
+// verify the signatures if it is trusted and usable.
+func (pc *providerContext) addKey(key *crypto.Key, source string) {
+	fp := strings.ToUpper(key.GetFingerprint())
+	switch {
+	case pc.pinned != nil && !pc.pinned.Contains(fp):
+		pc.cfg.logger().Warn("Ignoring public OpenPGP key which is not trusted",
+			"source", source, "fingerprint", fp)
+		return
+	case key.IsRevoked():
+		pc.cfg.logger().Warn("Ignoring revoked public OpenPGP key",
+			"source", source, "fingerprint", fp)
+		return
+	case key.IsExpired():
+		pc.cfg.logger().Warn("Ignoring expired public OpenPGP key",
+			"source", source, "fingerprint", fp)
+		return
+	}
+	if pc.keys == nil {
+		keyring, err := crypto.NewKeyRing(key)
+		if err != nil {
+			pc.cfg.logger().Warn(
+				"Creating store for public OpenPGP key failed",
+				"source", source,
+				"error", err)
+			return
+		}
+		pc.keys = keyring
+		return
+	}
+	if err := pc.keys.AddKey(key); err != nil {
+		pc.cfg.logger().Warn("Adding public OpenPGP key failed",
+			"source", source,
+			"fingerprint", fp,
+			"error", err)
+	}
+}
+
+// pinKeys sets up the fingerprints of the keys to be trusted.
+// The configured fingerprints and keyring have precedence over
+// the recorded ones of the original provider of a mirror.
+func (pc *providerContext) pinKeys(recorded func() (util.Set[string], error)) error {
+	switch {
+	case len(pc.cfg.TrustedKeys) > 0:
+		pc.pinned = util.Set[string]{}
+		for _, fp := range pc.cfg.TrustedKeys {
+			pc.pinned.Add(fp)
+		}
+	case pc.cfg.keyring != nil:
+		// The keyring replaces the keys of the mirror.
+	case pc.mirror != "":
+		pinned, err := recorded()
+		if err != nil {
+			return err
+		}
+		if len(pinned) == 0 {
+			return errors.New("no known OpenPGP keys of the original provider to verify mirror " +
+				"(download from the canonical location once or configure trusted_keys or keyring)")
+		}
+		pc.pinned = pinned
+	}
+	return nil
+}
+
+// checkSignature verifies the signature of the given data.
+// Returns the fingerprint of the key which made the signature.
+func (pc *providerContext) checkSignature(data []byte, sign *crypto.PGPSignature) (string, error) {
+	pm := crypto.NewPlainMessage(data)
+	t := crypto.GetUnixTime()
+	var err error
+	for _, key := range pc.keys.GetKeys() {
+		var kr *crypto.KeyRing
+		if kr, err = crypto.NewKeyRing(key); err != nil {
+			continue
+		}
+		if err = kr.VerifyDetached(pm, sign, t); err == nil {
+			return strings.ToUpper(key.GetFingerprint()), nil
+		}
+	}
+	if err == nil {
+		err = errors.New("no keys to verify signature")
+	}
+	return "", err
+}
+
+// keyUsage counts the advisories verified by the keys of a provider.
+type keyUsage struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// use counts an advisory verified by the key with the given fingerprint.
+func (ku *keyUsage) use(fp string) {
+	ku.mu.Lock()
+	defer ku.mu.Unlock()
+	if ku.counts == nil {
+		ku.counts = map[string]int{}
+	}
+	ku.counts[fp]++
+}
+
+// log logs the number of advisories verified by each key.
+func (ku *keyUsage) log(logger *slog.Logger, domain string) {
+	ku.mu.Lock()
+	defer ku.mu.Unlock()
+	for _, fp := range slices.Sorted(maps.Keys(ku.counts)) {
+		logger.Info("Advisories verified by public OpenPGP key",
+			"domain", domain,
+			"fingerprint", fp,
+			"advisories", ku.counts[fp])
+	}
+}
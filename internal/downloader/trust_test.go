@@ -0,0 +1,128 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+	"github.com/gocsaf/csaf/v3/pkg/errs"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestNormalizeFingerprint(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+		fail     bool
+	}{
+		{in: "a8914ca2f11139c6a69a0018fb3cd9b15de61596", want: "A8914CA2F11139C6A69A0018FB3CD9B15DE61596"},
+		{in: "A891 4CA2 F111 39C6 A69A  0018 FB3C D9B1 5DE6 1596", want: "A8914CA2F11139C6A69A0018FB3CD9B15DE61596"},
+		{in: "A8914CA2F11139C6", fail: true},
+		{in: "X8914CA2F11139C6A69A0018FB3CD9B15DE61596", fail: true},
+	} {
+		got, err := normalizeFingerprint(tc.in)
+		if tc.fail {
+			if err == nil {
+				t.Errorf("%q: expected error", tc.in)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%q: got %q (%v), want %q", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestDownloadTrustPolicy(t *testing.T) {
+	const (
+		providerKey = "A8914CA2F11139C6A69A0018FB3CD9B15DE61596"
+		otherKey    = "0000000000000000000000000000000000000000"
+	)
+	for _, tc := range []struct {
+		name        string
+		trusted     []string
+		keyring     string
+		policy      SignaturePolicy
+		forbidHash  bool
+		fail        bool
+		signed      int
+		hashMissing int
+	}{
+		{name: "pinned key", trusted: []string{providerKey}, signed: 1},
+		{name: "other pinned key", trusted: []string{otherKey}, fail: true},
+		{
+			name:    "local keyring",
+			keyring: "../../testdata/simple-rolie-provider/openpgp/pubkey.asc",
+			policy:  SignatureRequired,
+			signed:  1,
+		},
+		{name: "required", policy: SignatureRequired, signed: 1},
+		{name: "hashes", policy: SignatureHashes},
+		{name: "missing hashes", policy: SignatureHashes, forbidHash: true, hashMissing: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := testutil.ProviderParams{
+				EnableSha256: true,
+				EnableSha512: true,
+				ForbidSha256: tc.forbidHash,
+				ForbidSha512: tc.forbidHash,
+			}
+			server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+			defer server.Close()
+			params.URL = server.URL
+
+			client := util.Client(server.Client())
+
+			tempDir := t.TempDir()
+			cfg := Config{
+				LogLevel:        &options.LogLevel{Level: slog.LevelError},
+				Directory:       tempDir,
+				TrustedKeys:     tc.trusted,
+				Keyring:         tc.keyring,
+				SignaturePolicy: tc.policy,
+			}
+			if err := cfg.Prepare(); err != nil {
+				t.Fatalf("config failed: %v", err)
+			}
+			d, err := NewDownloader(&cfg)
+			if err != nil {
+				t.Fatalf("could not init downloader: %v", err)
+			}
+			defer d.Close()
+			d.client = &client
+
+			err = d.Run(context.Background(), []string{server.URL + "/provider-metadata.json"})
+			if tc.fail {
+				var pi errs.ErrCsafProviderIssue
+				if !errors.As(err, &pi) {
+					t.Errorf("expected provider issue, got: %v", err)
+				}
+				return
+			}
+			if tc.hashMissing == 0 && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if d.stats.signed != tc.signed {
+				t.Errorf("signed: got %d, want %d", d.stats.signed, tc.signed)
+			}
+			if d.stats.hashMissing != tc.hashMissing {
+				t.Errorf("hash missing: got %d, want %d", d.stats.hashMissing, tc.hashMissing)
+			}
+			written := checkIfFileExists(tempDir+"/white/2020/avendor-advisory-0004.json", t)
+			if written != (tc.hashMissing == 0) {
+				t.Errorf("advisory written: got %t, want %t", written, tc.hashMissing == 0)
+			}
+		})
+	}
+}
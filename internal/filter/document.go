@@ -0,0 +1,272 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// DocumentRuleKind is the kind of a document rule.
+type DocumentRuleKind string
+
+const (
+	// RuleCVE matches the CVE IDs of the vulnerabilities.
+	RuleCVE = DocumentRuleKind("cve")
+	// RuleCategory matches the document category.
+	RuleCategory = DocumentRuleKind("category")
+	// RuleTLP matches the TLP label of the document.
+	RuleTLP = DocumentRuleKind("tlp")
+	// RulePublisher matches the prefix of the publisher namespace.
+	RulePublisher = DocumentRuleKind("publisher")
+	// RuleProduct matches the prefixes of the PURLs and CPEs of the products.
+	RuleProduct = DocumentRuleKind("product")
+	// RuleExpr matches if a JSONPath expression has a non-empty result.
+	RuleExpr = DocumentRuleKind("expr")
+)
+
+// documentRuleKinds are the known rule kinds.
+var documentRuleKinds = []DocumentRuleKind{
+	RuleCVE, RuleCategory, RuleTLP, RulePublisher, RuleProduct, RuleExpr,
+}
+
+const (
+	cveExpr       = `$.vulnerabilities[*].cve`
+	categoryExpr  = `$.document.category`
+	tlpExpr       = `$.document.distribution.tlp.label`
+	publisherExpr = `$.document.publisher.namespace`
+	purlExpr      = `$.product_tree..product_identification_helper.purl`
+	cpeExpr       = `$.product_tree..product_identification_helper.cpe`
+	cvssExpr      = `$.vulnerabilities[*].scores[*]..baseScore`
+)
+
+// DocumentRule matches a document by a given kind and value.
+type DocumentRule struct {
+	Kind  DocumentRuleKind
+	Value string
+}
+
+// ParseDocumentRule parses a rule given as "kind:value".
+func ParseDocumentRule(s string) (*DocumentRule, error) {
+	kind, value, ok := strings.Cut(s, ":")
+	if !ok || value == "" {
+		return nil, fmt.Errorf("invalid filter %q (expected KIND:VALUE)", s)
+	}
+	r := DocumentRule{Kind: DocumentRuleKind(strings.ToLower(kind)), Value: value}
+	switch r.Kind {
+	case RuleCVE, RuleCategory, RuleTLP, RulePublisher, RuleProduct:
+	case RuleExpr:
+		// Check if the expression compiles.
+		if _, err := util.NewPathEval().Compile(value); err != nil {
+			return nil, fmt.Errorf("invalid expression in filter %q: %w", s, err)
+		}
+	default:
+		kinds := make([]string, len(documentRuleKinds))
+		for i, k := range documentRuleKinds {
+			kinds[i] = string(k)
+		}
+		return nil, fmt.Errorf("unknown filter kind %q (expected one of %s)",
+			kind, strings.Join(kinds, ", "))
+	}
+	return &r, nil
+}
+
+// evalStrings evaluates an expression on a document and
+// returns the resulting strings.
+func evalStrings(expr *util.PathEval, path string, doc any) []string {
+	var strs []string
+	if err := expr.Extract(path, util.StringTreeMatcher(&strs), true, doc); err != nil {
+		return nil
+	}
+	return strs
+}
+
+// nonEmpty checks if the result of an expression is considered a match.
+func nonEmpty(x any) bool {
+	switch v := x.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	return true
+}
+
+// Matches checks if the rule matches the given document.
+func (r *DocumentRule) Matches(expr *util.PathEval, doc any) bool {
+	equalsAny := func(path string) bool {
+		for _, s := range evalStrings(expr, path, doc) {
+			if strings.EqualFold(s, r.Value) {
+				return true
+			}
+		}
+		return false
+	}
+	prefixOfAny := func(paths ...string) bool {
+		value := strings.ToLower(r.Value)
+		for _, path := range paths {
+			for _, s := range evalStrings(expr, path, doc) {
+				if strings.HasPrefix(strings.ToLower(s), value) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	switch r.Kind {
+	case RuleCVE:
+		return equalsAny(cveExpr)
+	case RuleCategory:
+		return equalsAny(categoryExpr)
+	case RuleTLP:
+		return equalsAny(tlpExpr)
+	case RulePublisher:
+		return prefixOfAny(publisherExpr)
+	case RuleProduct:
+		return prefixOfAny(purlExpr, cpeExpr)
+	case RuleExpr:
+		x, err := expr.Eval(r.Value, doc)
+		return err == nil && nonEmpty(x)
+	}
+	return false
+}
+
+// ParseSeverity parses a minimal CVSS severity. It is either
+// given as a base score or as one of the qualitative
+// ratings "low", "medium", "high" or "critical".
+func ParseSeverity(s string) (float64, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return 0, nil
+	case "low":
+		return 0.1, nil
+	case "medium":
+		return 4.0, nil
+	case "high":
+		return 7.0, nil
+	case "critical":
+		return 9.0, nil
+	}
+	score, err := strconv.ParseFloat(s, 64)
+	if err != nil || score < 0 || score > 10 {
+		return 0, fmt.Errorf(
+			"invalid severity %q (expected score from 0 to 10 or low, medium, high, critical)", s)
+	}
+	return score, nil
+}
+
+// maxCVSS returns the maximal CVSS base score of a document.
+func maxCVSS(expr *util.PathEval, doc any) (float64, bool) {
+	x, err := expr.Eval(cvssExpr, doc)
+	if err != nil {
+		return 0, false
+	}
+	scores, ok := x.([]any)
+	if !ok {
+		return 0, false
+	}
+	var (
+		maxScore float64
+		found    bool
+	)
+	for _, s := range scores {
+		if score, ok := s.(float64); ok {
+			if !found || score > maxScore {
+				maxScore = score
+			}
+			found = true
+		}
+	}
+	return maxScore, found
+}
+
+// DocumentFilter decides by their content if documents are accepted.
+// A document is accepted if it matches any of the include rules
+// (or there are none), none of the exclude rules and has at least
+// one CVSS base score not lower than the minimal score (if given).
+type DocumentFilter struct {
+	Include []*DocumentRule
+	Exclude []*DocumentRule
+	// MinCVSS is the optional minimal CVSS base score.
+	MinCVSS *float64
+}
+
+// NewDocumentFilter creates a document filter from the
+// given rules and an optional minimal severity.
+// It returns nil if no filtering is needed.
+func NewDocumentFilter(include, exclude []string, minSeverity string) (*DocumentFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 && minSeverity == "" {
+		return nil, nil
+	}
+	var df DocumentFilter
+	for _, x := range []struct {
+		rules []string
+		dst   *[]*DocumentRule
+	}{
+		{include, &df.Include},
+		{exclude, &df.Exclude},
+	} {
+		for _, s := range x.rules {
+			r, err := ParseDocumentRule(s)
+			if err != nil {
+				return nil, err
+			}
+			*x.dst = append(*x.dst, r)
+		}
+	}
+	if minSeverity != "" {
+		score, err := ParseSeverity(minSeverity)
+		if err != nil {
+			return nil, err
+		}
+		df.MinCVSS = &score
+	}
+	return &df, nil
+}
+
+// Accept checks if a document is accepted by the filter.
+// If not the reason is returned.
+func (df *DocumentFilter) Accept(expr *util.PathEval, doc any) (bool, string) {
+	if len(df.Include) > 0 {
+		included := false
+		for _, r := range df.Include {
+			if r.Matches(expr, doc) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false, "no include filter matches"
+		}
+	}
+	for _, r := range df.Exclude {
+		if r.Matches(expr, doc) {
+			return false, fmt.Sprintf("exclude filter %s:%s matches", r.Kind, r.Value)
+		}
+	}
+	if df.MinCVSS != nil {
+		score, ok := maxCVSS(expr, doc)
+		if !ok {
+			return false, "no CVSS base score"
+		}
+		if score < *df.MinCVSS {
+			return false, fmt.Sprintf("CVSS base score %.1f below %.1f", score, *df.MinCVSS)
+		}
+	}
+	return true, ""
+}
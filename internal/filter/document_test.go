@@ -0,0 +1,111 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package filter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+const testDocument = `{
+  "document": {
+    "category": "csaf_security_advisory",
+    "distribution": {"tlp": {"label": "WHITE"}},
+    "publisher": {"namespace": "https://example.com/csaf"}
+  },
+  "product_tree": {
+    "branches": [{
+      "branches": [{
+        "product": {
+          "product_identification_helper": {"purl": "pkg:npm/lodash@4.17.20"}
+        }
+      }]
+    }],
+    "full_product_names": [{
+      "product_identification_helper": {"cpe": "cpe:2.3:a:example:server:1.0:*:*:*:*:*:*:*"}
+    }]
+  },
+  "vulnerabilities": [
+    {"cve": "CVE-2024-0001", "scores": [{"cvss_v3": {"baseScore": 7.5}}]},
+    {"cve": "CVE-2024-0002", "scores": [{"cvss_v2": {"baseScore": 5.0}}]}
+  ]
+}`
+
+func TestParseDocumentRule(t *testing.T) {
+	for _, x := range []struct {
+		rule  string
+		valid bool
+	}{
+		{"cve:CVE-2024-0001", true},
+		{"PRODUCT:pkg:npm/", true},
+		{"expr:$.document", true},
+		{"expr:$.[", false},
+		{"cve", false},
+		{"cve:", false},
+		{"unknown:x", false},
+	} {
+		_, err := ParseDocumentRule(x.rule)
+		if valid := err == nil; valid != x.valid {
+			t.Errorf("%q: got valid %t expected %t: %v", x.rule, valid, x.valid, err)
+		}
+	}
+}
+
+func TestDocumentFilter(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(testDocument), &doc); err != nil {
+		t.Fatal(err)
+	}
+	expr := util.NewPathEval()
+
+	for _, x := range []struct {
+		name     string
+		include  []string
+		exclude  []string
+		severity string
+		accept   bool
+	}{
+		{"cve", []string{"cve:cve-2024-0002"}, nil, "", true},
+		{"other cve", []string{"cve:CVE-2024-9999"}, nil, "", false},
+		{"any include", []string{"cve:CVE-2024-9999", "category:csaf_security_advisory"}, nil, "", true},
+		{"tlp", nil, []string{"tlp:white"}, "", false},
+		{"publisher", []string{"publisher:https://example.com"}, nil, "", true},
+		{"purl", []string{"product:pkg:npm/lodash"}, nil, "", true},
+		{"cpe", []string{"product:cpe:2.3:a:example:"}, nil, "", true},
+		{"other product", []string{"product:pkg:pypi/"}, nil, "", false},
+		{"expr", []string{`expr:$.vulnerabilities[?(@.cve=="CVE-2024-0001")]`}, nil, "", true},
+		{"empty expr", []string{`expr:$.vulnerabilities[?(@.cve=="CVE-1")]`}, nil, "", false},
+		{"high", nil, nil, "high", true},
+		{"critical", nil, nil, "critical", false},
+		{"score", nil, nil, "7.5", true},
+		{"include and exclude", []string{"cve:CVE-2024-0001"}, []string{"product:pkg:npm/"}, "", false},
+	} {
+		t.Run(x.name, func(t *testing.T) {
+			df, err := NewDocumentFilter(x.include, x.exclude, x.severity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accept, reason := df.Accept(expr, doc); accept != x.accept {
+				t.Errorf("got %t expected %t (%s)", accept, x.accept, reason)
+			}
+		})
+	}
+
+	if df, err := NewDocumentFilter(nil, nil, ""); df != nil || err != nil {
+		t.Error("empty filter should be nil")
+	}
+	if accept, _ := (&DocumentFilter{MinCVSS: new(float64)}).Accept(expr, map[string]any{}); accept {
+		t.Error("documents without scores should not pass a minimal severity")
+	}
+	if _, err := ParseSeverity("11"); err == nil {
+		t.Error("score above 10 should fail")
+	}
+}
@@ -0,0 +1,112 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+// HTTP are the metrics of HTTP requests.
+type HTTP struct {
+	responses *Vec
+	durations *HistogramVec
+}
+
+// NewHTTP registers the metrics of HTTP requests with the given
+// prefix: the responses by host and status code and the
+// request latencies by host.
+func NewHTTP(r *Registry, prefix string) *HTTP {
+	return &HTTP{
+		responses: r.Counter(
+			prefix+"_http_responses_total",
+			"HTTP responses by host and status code. Failed requests have the code \"error\".",
+			"host", "code"),
+		durations: r.Histogram(
+			prefix+"_http_request_duration_seconds",
+			"Latencies of the HTTP requests by host.",
+			DefaultBuckets,
+			"host"),
+	}
+}
+
+// Client returns a client recording the metrics of its requests.
+// If h is nil the client is returned unchanged.
+func (h *HTTP) Client(client util.Client) util.Client {
+	if h == nil {
+		return client
+	}
+	return &httpClient{Client: client, h: h}
+}
+
+// httpClient is a client recording the metrics of its requests.
+type httpClient struct {
+	util.Client
+	h *HTTP
+}
+
+// observe records a request to the given URL.
+func (hc *httpClient) observe(
+	rawURL string,
+	start time.Time,
+	resp *http.Response,
+	err error,
+) (*http.Response, error) {
+	var host string
+	if u, perr := url.Parse(rawURL); perr == nil {
+		host = u.Host
+	}
+	code := "error"
+	if err == nil && resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	hc.h.responses.Inc(host, code)
+	hc.h.durations.Observe(time.Since(start).Seconds(), host)
+	return resp, err
+}
+
+// Do implements the respective method of the [util.Client] interface.
+func (hc *httpClient) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := hc.Client.Do(req)
+	return hc.observe(req.URL.String(), start, resp, err)
+}
+
+// Get implements the respective method of the [util.Client] interface.
+func (hc *httpClient) Get(url string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := hc.Client.Get(url)
+	return hc.observe(url, start, resp, err)
+}
+
+// Head implements the respective method of the [util.Client] interface.
+func (hc *httpClient) Head(url string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := hc.Client.Head(url)
+	return hc.observe(url, start, resp, err)
+}
+
+// Post implements the respective method of the [util.Client] interface.
+func (hc *httpClient) Post(url, contentType string, body io.Reader) (*http.Response, error) {
+	start := time.Now()
+	resp, err := hc.Client.Post(url, contentType, body)
+	return hc.observe(url, start, resp, err)
+}
+
+// PostForm implements the respective method of the [util.Client] interface.
+func (hc *httpClient) PostForm(url string, data url.Values) (*http.Response, error) {
+	start := time.Now()
+	resp, err := hc.Client.PostForm(url, data)
+	return hc.observe(url, start, resp, err)
+}
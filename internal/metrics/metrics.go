@@ -0,0 +1,333 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+// Package metrics implements a minimal registry of metrics
+// which are exposed in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the default upper bounds of the
+// buckets of a histogram measuring seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// kind is the type of a metric family.
+type kind string
+
+const (
+	counterKind   = kind("counter")
+	gaugeKind     = kind("gauge")
+	histogramKind = kind("histogram")
+)
+
+// series is a single time series of a family.
+type series struct {
+	values []string
+	value  float64
+	// buckets, sum and count of a histogram.
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+// family is a metric with its time series.
+type family struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// Registry is a set of metric families.
+// It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+	collect  []func()
+}
+
+// Vec is a counter or a gauge with labels.
+type Vec struct{ f *family }
+
+// HistogramVec is a histogram with labels.
+type HistogramVec struct{ f *family }
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds a new family to the registry.
+func (r *Registry) register(f *family) *family {
+	f.series = map[string]*series{}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.ContainsFunc(r.families, func(o *family) bool { return o.name == f.name }) {
+		panic(fmt.Sprintf("metric %q registered twice", f.name))
+	}
+	r.families = append(r.families, f)
+	return f
+}
+
+// Counter registers a counter with the given name, help text and labels.
+func (r *Registry) Counter(name, help string, labels ...string) *Vec {
+	return &Vec{r.register(&family{
+		name: name, help: help, kind: counterKind, labels: labels,
+	})}
+}
+
+// Gauge registers a gauge with the given name, help text and labels.
+func (r *Registry) Gauge(name, help string, labels ...string) *Vec {
+	return &Vec{r.register(&family{
+		name: name, help: help, kind: gaugeKind, labels: labels,
+	})}
+}
+
+// Histogram registers a histogram with the given name, help text,
+// upper bounds of the buckets and labels.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{r.register(&family{
+		name: name, help: help, kind: histogramKind, labels: labels,
+		buckets: slices.Sorted(slices.Values(buckets)),
+	})}
+}
+
+// OnCollect registers a function which is called before the
+// metrics are written. It is meant to update metrics which
+// are derived from other state.
+func (r *Registry) OnCollect(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collect = append(r.collect, fn)
+}
+
+// get returns the series of the given label values.
+// Has to be called with the lock of the family held.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %q needs %d label values, got %d",
+			f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s := f.series[key]
+	if s == nil {
+		s = &series{values: slices.Clone(values)}
+		if f.kind == histogramKind {
+			s.buckets = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Add adds x to the series with the given label values.
+func (v *Vec) Add(x float64, values ...string) {
+	if v == nil {
+		return
+	}
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	v.f.get(values).value += x
+}
+
+// Inc increments the series with the given label values.
+func (v *Vec) Inc(values ...string) {
+	v.Add(1, values...)
+}
+
+// Set sets the series with the given label values to x.
+func (v *Vec) Set(x float64, values ...string) {
+	if v == nil {
+		return
+	}
+	v.f.mu.Lock()
+	defer v.f.mu.Unlock()
+	v.f.get(values).value = x
+}
+
+// Observe adds an observation to the series with the given label values.
+func (h *HistogramVec) Observe(x float64, values ...string) {
+	if h == nil {
+		return
+	}
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.get(values)
+	for i, le := range h.f.buckets {
+		if x <= le {
+			s.buckets[i]++
+		}
+	}
+	s.sum += x
+	s.count++
+}
+
+// escapeHelp escapes the help text of a family.
+var escapeHelp = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace
+
+// escapeValue escapes a label value.
+var escapeValue = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace
+
+// formatFloat formats a value in the text format.
+func formatFloat(x float64) string {
+	switch {
+	case math.IsInf(x, 1):
+		return "+Inf"
+	case math.IsInf(x, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// labelPairs formats the labels of a series with optional extra pairs.
+func (f *family) labelPairs(values []string, extra ...string) string {
+	if len(values) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	pair := func(name, value string) {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeValue(value))
+		b.WriteByte('"')
+	}
+	for i, name := range f.labels {
+		pair(name, values[i])
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pair(extra[i], extra[i+1])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// write writes the family in the text format.
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.series) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	for _, key := range slices.Sorted(maps.Keys(f.series)) {
+		s := f.series[key]
+		if f.kind != histogramKind {
+			fmt.Fprintf(w, "%s%s %s\n",
+				f.name, f.labelPairs(s.values), formatFloat(s.value))
+			continue
+		}
+		for i, le := range f.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n",
+				f.name, f.labelPairs(s.values, "le", formatFloat(le)), s.buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n",
+			f.name, f.labelPairs(s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n",
+			f.name, f.labelPairs(s.values), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n",
+			f.name, f.labelPairs(s.values), s.count)
+	}
+}
+
+// countingWriter counts the bytes written.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements [io.Writer].
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// WriteTo writes all metrics in the Prometheus text format to w.
+// Families without series are left out.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collect := slices.Clone(r.collect)
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	for _, fn := range collect {
+		fn()
+	}
+	slices.SortFunc(families, func(a, b *family) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range families {
+		f.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// WriteFile writes the metrics atomically to the given file.
+// This is meant to be used with the textfile collector of
+// the Prometheus node exporter.
+func (r *Registry) WriteFile(fname string) error {
+	dir := filepath.Dir(fname)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(fname)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, fname); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
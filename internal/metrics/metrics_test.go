@@ -0,0 +1,110 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("test_total", "A counter.", "domain", "result")
+	g := r.Gauge("test_queue", "A gauge\nwith two lines.")
+	h := r.Histogram("test_seconds", "A histogram.", []float64{1, 0.5}, "host")
+	r.Gauge("test_unused", "Not written.")
+
+	c.Inc("b.example.com", "ok")
+	c.Add(2, "a.example.com", `say "hi"`)
+	r.OnCollect(func() { g.Set(3) })
+	h.Observe(0.25, "example.com")
+	h.Observe(0.75, "example.com")
+	h.Observe(2, "example.com")
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	const want = `# HELP test_queue A gauge\nwith two lines.
+# TYPE test_queue gauge
+test_queue 3
+# HELP test_seconds A histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{host="example.com",le="0.5"} 1
+test_seconds_bucket{host="example.com",le="1"} 2
+test_seconds_bucket{host="example.com",le="+Inf"} 3
+test_seconds_sum{host="example.com"} 3
+test_seconds_count{host="example.com"} 3
+# HELP test_total A counter.
+# TYPE test_total counter
+test_total{domain="a.example.com",result="say \"hi\""} 2
+test_total{domain="b.example.com",result="ok"} 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("test_total", "A counter.").Inc()
+	fname := filepath.Join(t.TempDir(), "metrics", "test.prom")
+	if err := r.WriteFile(fname); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "test_total 1\n") {
+		t.Errorf("unexpected content: %q", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(fname))
+	if len(entries) != 1 {
+		t.Errorf("temporary files left: %v", entries)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	r := NewRegistry()
+	client := NewHTTP(r, "test").Client(util.Client(server.Client()))
+	for _, p := range []string{"/", "/", "/missing"} {
+		resp, err := client.Get(server.URL + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	var b strings.Builder
+	r.WriteTo(&b)
+	host := strings.TrimPrefix(server.URL, "http://")
+	for _, want := range []string{
+		`test_http_responses_total{host="` + host + `",code="200"} 2`,
+		`test_http_responses_total{host="` + host + `",code="404"} 1`,
+		`test_http_request_duration_seconds_count{host="` + host + `"} 3`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, b.String())
+		}
+	}
+}
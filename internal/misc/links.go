@@ -0,0 +1,36 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2022 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2022 Intevation GmbH <https://intevation.de>
+
+package misc
+
+import (
+	"io"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// LinksOnPage calls visit with the targets of the links
+// of the HTML page read from r, e.g. of a directory listing.
+func LinksOnPage(r io.Reader, visit func(string) error) error {
+
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return err
+	}
+
+	doc.Find("a").Each(func(_ int, s *goquery.Selection) {
+		if err != nil {
+			return
+		}
+		if link, ok := s.Attr("href"); ok {
+			err = visit(link)
+		}
+	})
+
+	return err
+}
@@ -15,7 +15,10 @@ func JoinURL(baseURL *url.URL, relativeURL *url.URL) *url.URL {
 	u := baseURL.JoinPath(relativeURL.Path)
 	u.RawQuery = relativeURL.RawQuery
 	u.RawFragment = relativeURL.RawFragment
-	// Enforce https, this is required if the base url was only a domain
-	u.Scheme = "https"
+	// Enforce https, this is required if the base url was only a domain.
+	// Local files are kept.
+	if u.Scheme != "file" {
+		u.Scheme = "https"
+	}
 	return u
 }
@@ -0,0 +1,160 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package storage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// archiveWriter abstracts the writing of entries to tar and zip archives.
+type archiveWriter interface {
+	writeEntry(name string, data []byte, modTime time.Time) error
+	close() error
+}
+
+// Archive stores files in a tar or zip archive.
+// Archives are write once storages: Files cannot be
+// read or removed after they have been written.
+type Archive struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer archiveWriter
+}
+
+type tarWriter struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+}
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+// NewTar creates a tar archive in file fname.
+// If fname ends with ".gz" or ".tgz" the archive is gzip compressed.
+// An existing file is replaced.
+func NewTar(fname string) (*Archive, error) {
+	f, err := os.Create(fname)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		w  io.Writer = f
+		gz *gzip.Writer
+	)
+	if lower := strings.ToLower(fname); strings.HasSuffix(lower, ".gz") ||
+		strings.HasSuffix(lower, ".tgz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	return &Archive{
+		file:   f,
+		writer: &tarWriter{tw: tar.NewWriter(w), gz: gz},
+	}, nil
+}
+
+// NewZip creates a zip archive in file fname.
+// An existing file is replaced.
+func NewZip(fname string) (*Archive, error) {
+	f, err := os.Create(fname)
+	if err != nil {
+		return nil, err
+	}
+	return &Archive{
+		file:   f,
+		writer: &zipWriter{zw: zip.NewWriter(f)},
+	}, nil
+}
+
+func (tw *tarWriter) writeEntry(name string, data []byte, modTime time.Time) error {
+	if err := tw.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     0644,
+		ModTime:  modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.tw.Write(data)
+	return err
+}
+
+func (tw *tarWriter) close() error {
+	err := tw.tw.Close()
+	if tw.gz != nil {
+		err = errors.Join(err, tw.gz.Close())
+	}
+	return err
+}
+
+func (zw *zipWriter) writeEntry(name string, data []byte, modTime time.Time) error {
+	w, err := zw.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (zw *zipWriter) close() error {
+	return zw.zw.Close()
+}
+
+// WriteFile implements [Storage].
+// Writing a file more than once adds another entry
+// with the same name to the archive.
+func (a *Archive) WriteFile(name string, data []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.writer == nil {
+		return os.ErrClosed
+	}
+	return a.writer.writeEntry(name, data, time.Now())
+}
+
+// ReadFile implements [Storage].
+// As archives are write once storages the
+// returned error always wraps [os.ErrNotExist].
+func (a *Archive) ReadFile(name string) ([]byte, error) {
+	return nil, &os.PathError{Op: "read", Path: name, Err: os.ErrNotExist}
+}
+
+// Remove implements [Storage].
+// Removing files is not supported by archives.
+func (a *Archive) Remove(name string) error {
+	return &os.PathError{Op: "remove", Path: name, Err: errWriteOnce}
+}
+
+// Close implements [Storage].
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.writer == nil {
+		return nil
+	}
+	err := errors.Join(a.writer.close(), a.file.Close())
+	a.writer, a.file = nil, nil
+	return err
+}
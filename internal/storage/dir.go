@@ -0,0 +1,92 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Dir stores files in a directory of the local file system.
+type Dir struct {
+	root    string
+	mkdirMu sync.Mutex
+	lastDir string
+}
+
+// NewDir creates a storage storing its files below root.
+func NewDir(root string) *Dir {
+	return &Dir{root: root}
+}
+
+// Path returns the local path of the file with the given name.
+func (d *Dir) Path(name string) string {
+	return filepath.Join(d.root, filepath.FromSlash(name))
+}
+
+// mkdirAll creates the parent directories of a file if needed.
+func (d *Dir) mkdirAll(fname string) error {
+	dir := filepath.Dir(fname)
+	d.mkdirMu.Lock()
+	defer d.mkdirMu.Unlock()
+	if dir == d.lastDir {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	d.lastDir = dir
+	return nil
+}
+
+// WriteFile implements [Storage].
+func (d *Dir) WriteFile(name string, data []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	fname := d.Path(name)
+	if err := d.mkdirAll(fname); err != nil {
+		return err
+	}
+	return os.WriteFile(fname, data, 0644)
+}
+
+// ReadFile implements [Storage].
+func (d *Dir) ReadFile(name string) ([]byte, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(d.Path(name))
+}
+
+// Remove implements [Storage].
+func (d *Dir) Remove(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	return os.Remove(d.Path(name))
+}
+
+// Move implements [Mover].
+func (d *Dir) Move(oldName, newName string) error {
+	for _, name := range []string{oldName, newName} {
+		if err := checkName(name); err != nil {
+			return err
+		}
+	}
+	dst := d.Path(newName)
+	if err := d.mkdirAll(dst); err != nil {
+		return err
+	}
+	return os.Rename(d.Path(oldName), dst)
+}
+
+// Close implements [Storage].
+func (d *Dir) Close() error { return nil }
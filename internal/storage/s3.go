@@ -0,0 +1,271 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultS3Region is the region used for signing
+// if no region is configured.
+const defaultS3Region = "us-east-1"
+
+// S3Options are the options to access an S3 compatible object storage.
+type S3Options struct {
+	// Endpoint is the base URL of the service,
+	// e.g. "https://s3.eu-central-1.amazonaws.com"
+	// or "http://localhost:9000" for a local MinIO.
+	Endpoint string
+	// Bucket is the name of the bucket to store the files in.
+	Bucket string
+	// Prefix is an optional prefix prepended to all object keys.
+	Prefix string
+	// Region is the region used to sign the requests.
+	// Defaults to "us-east-1".
+	Region string
+	// AccessKey is the access key ID.
+	AccessKey string
+	// SecretKey is the secret access key.
+	SecretKey string
+}
+
+// S3 stores files as objects in an S3 compatible object storage.
+// The objects are addressed path-style as <endpoint>/<bucket>/<prefix>/<name>.
+type S3 struct {
+	opts     S3Options
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3 creates a storage for an S3 compatible object storage.
+// If client is nil [http.DefaultClient] is used.
+func NewS3(opts S3Options, client *http.Client) (*S3, error) {
+	if opts.Bucket == "" {
+		return nil, errors.New("missing S3 bucket")
+	}
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %w", opts.Endpoint, err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: no HTTP(S) URL", opts.Endpoint)
+	}
+	if opts.Region == "" {
+		opts.Region = defaultS3Region
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3{
+		opts:     opts,
+		endpoint: endpoint,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// objectURL returns the URL of the object of the given file name.
+func (s *S3) objectURL(name string) *url.URL {
+	key := path.Join(s.opts.Prefix, name)
+	u := *s.endpoint
+	u.Path = path.Join("/", u.Path, s.opts.Bucket, key)
+	u.RawPath = uriEncode(u.Path, false)
+	return &u
+}
+
+// do signs and sends a request for an object.
+func (s *S3) do(method, name string, body []byte) (*http.Response, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, s.objectURL(name).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body, req.GetBody = nil, nil
+	}
+	sum := sha256.Sum256(body)
+	s.sign(req, hex.EncodeToString(sum[:]), s.now())
+	return s.client.Do(req)
+}
+
+// responseError creates an error for an unexpected response.
+func responseError(op, name string, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err := fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode == http.StatusNotFound {
+		err = fmt.Errorf("%w: %w", os.ErrNotExist, err)
+	}
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+// WriteFile implements [Storage].
+func (s *S3) WriteFile(name string, data []byte) error {
+	if data == nil {
+		data = []byte{}
+	}
+	resp, err := s.do(http.MethodPut, name, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("write", name, resp)
+	}
+	return nil
+}
+
+// ReadFile implements [Storage].
+func (s *S3) ReadFile(name string) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("read", name, resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Remove implements [Storage].
+// As S3 does not report missing objects on deletion
+// removing a non-existing file does not fail.
+func (s *S3) Remove(name string) error {
+	resp, err := s.do(http.MethodDelete, name, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return responseError("remove", name, resp)
+	}
+	return nil
+}
+
+// Close implements [Storage].
+func (s *S3) Close() error { return nil }
+
+// sign signs a request with AWS Signature Version 4.
+// Besides the host all header fields already set are signed.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Collect the header fields to sign.
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name)
+		canonicalHeaders.WriteByte(':')
+		canonicalHeaders.WriteString(headers[name])
+		canonicalHeaders.WriteByte('\n')
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.opts.Region + "/s3/aws4_request"
+	crHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" +
+		hex.EncodeToString(crHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), date)
+	for _, part := range []string{s.opts.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 "+
+		"Credential="+s.opts.AccessKey+"/"+scope+","+
+		"SignedHeaders="+signedHeaders+","+
+		"Signature="+signature)
+}
+
+// canonicalQuery returns the query parameters sorted
+// and encoded as needed for signing.
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode encodes a string as specified for signing.
+// All bytes except the unreserved characters are percent encoded.
+// Slashes are only encoded if encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~',
+			c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
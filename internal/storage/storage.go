@@ -0,0 +1,70 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+// Package storage implements places to store downloaded files in.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Storage is a place to store files in.
+// File names are slash separated paths relative
+// to the root of the storage. Implementations
+// have to be safe for concurrent use.
+type Storage interface {
+	// WriteFile writes data to the file with the given name.
+	// Existing files are replaced.
+	WriteFile(name string, data []byte) error
+	// ReadFile reads the file with the given name.
+	// If the file does not exist an error wrapping
+	// [fs.ErrNotExist] is returned.
+	ReadFile(name string) ([]byte, error)
+	// Remove removes the file with the given name.
+	// If the file does not exist an error wrapping
+	// [fs.ErrNotExist] is returned.
+	Remove(name string) error
+	// Close flushes and releases the resources of the storage.
+	Close() error
+}
+
+// Mover is implemented by storages which are able to
+// move files more efficiently than copying them.
+type Mover interface {
+	Move(oldName, newName string) error
+}
+
+// Move moves a file in a storage from oldName to newName.
+func Move(s Storage, oldName, newName string) error {
+	if m, ok := s.(Mover); ok {
+		return m.Move(oldName, newName)
+	}
+	data, err := s.ReadFile(oldName)
+	if err != nil {
+		return err
+	}
+	if err := s.WriteFile(newName, data); err != nil {
+		return err
+	}
+	return s.Remove(oldName)
+}
+
+// checkName checks if a file name is a valid relative path
+// which does not escape the root of the storage.
+func checkName(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "check", Path: name, Err: fs.ErrInvalid}
+	}
+	return nil
+}
+
+// errWriteOnce is returned by write once storages
+// if they are asked to read or remove files.
+var errWriteOnce = fmt.Errorf("write once storage: %w", errors.ErrUnsupported)
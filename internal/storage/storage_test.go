@@ -0,0 +1,159 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package storage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// roundTrip checks the basic operations of a storage.
+func roundTrip(t *testing.T, s Storage) {
+	t.Helper()
+	if err := s.WriteFile("white/2024/a.json", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.ReadFile("white/2024/a.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a" {
+		t.Fatalf("got %q expected %q", data, "a")
+	}
+	if err := Move(s, "white/2024/a.json", "removed/white/2024/a.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ReadFile("white/2024/a.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("moved file still exists: %v", err)
+	}
+	if err := s.Remove("removed/white/2024/a.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ReadFile("removed/white/2024/a.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("removed file still exists: %v", err)
+	}
+	if err := s.WriteFile("../escape.json", nil); err == nil {
+		t.Fatal("escaping the root should fail")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	roundTrip(t, NewDir(t.TempDir()))
+}
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	readTar := func(fname string, gz bool) map[string]string {
+		f, err := os.Open(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		var r io.Reader = f
+		if gz {
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r = zr
+		}
+		files := map[string]string{}
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[hdr.Name] = string(data)
+		}
+		return files
+	}
+
+	readZip := func(fname string) map[string]string {
+		zr, err := zip.OpenReader(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		files := map[string]string{}
+		for _, f := range zr.File {
+			r, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[f.Name] = string(data)
+		}
+		return files
+	}
+
+	for _, x := range []struct {
+		name string
+		open func(string) (*Archive, error)
+		read func(string) map[string]string
+	}{
+		{"a.tar", NewTar, func(f string) map[string]string { return readTar(f, false) }},
+		{"a.tar.gz", NewTar, func(f string) map[string]string { return readTar(f, true) }},
+		{"a.zip", NewZip, readZip},
+	} {
+		t.Run(x.name, func(t *testing.T) {
+			fname := filepath.Join(dir, x.name)
+			a, err := x.open(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := a.WriteFile("white/2024/a.json", []byte("a")); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.WriteFile("white/2024/a.json.sha256", []byte("b")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := a.ReadFile("white/2024/a.json"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("reading from archive: %v", err)
+			}
+			if err := a.Remove("white/2024/a.json"); !errors.Is(err, errors.ErrUnsupported) {
+				t.Errorf("removing from archive: %v", err)
+			}
+			if err := a.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.WriteFile("c.json", nil); err == nil {
+				t.Error("writing to closed archive should fail")
+			}
+			files := x.read(fname)
+			if len(files) != 2 ||
+				files["white/2024/a.json"] != "a" ||
+				files["white/2024/a.json.sha256"] != "b" {
+				t.Errorf("unexpected archive content: %v", files)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// GenerateAdvisories generates n CSAF security advisories for
+// benchmarks. They are shaped like the advisories of real vendors
+// with product trees of some dozen to some hundred products, up to
+// twenty vulnerabilities and revision histories. The same n always
+// results in the same advisories.
+func GenerateAdvisories(n int) [][]byte {
+	rnd := rand.New(rand.NewPCG(uint64(n), 2026))
+	docs := make([][]byte, n)
+	for i := range docs {
+		data, err := json.Marshal(generateAdvisory(rnd, i))
+		if err != nil {
+			panic(err)
+		}
+		docs[i] = data
+	}
+	return docs
+}
+
+// generateAdvisory generates the i-th advisory.
+func generateAdvisory(rnd *rand.Rand, i int) map[string]any {
+	var (
+		tlps     = []string{"WHITE", "GREEN", "AMBER", "RED", "CLEAR"}
+		base     = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+		initial  = base.Add(time.Duration(rnd.IntN(8*365*24)) * time.Hour)
+		year     = initial.Year()
+		id       = fmt.Sprintf("ACME-SA-%d-%05d", year, i)
+		versions = 1 + rnd.IntN(6)
+		date     = func(t time.Time) string { return t.Format(time.RFC3339) }
+	)
+
+	// Revision history.
+	var (
+		history []any
+		current = initial
+	)
+	for v := 1; v <= versions; v++ {
+		history = append(history, map[string]any{
+			"date":    date(current),
+			"number":  fmt.Sprintf("%d.0.0", v),
+			"summary": fmt.Sprintf("Revision %d of the advisory.", v),
+		})
+		if v < versions {
+			current = current.Add(time.Duration(1+rnd.IntN(60*24)) * time.Hour)
+		}
+	}
+
+	// Product tree of vendors, products and their versions.
+	var (
+		branches   []any
+		productIDs []string
+	)
+	for v := range 1 + rnd.IntN(3) {
+		var products []any
+		for p := range 2 + rnd.IntN(8) {
+			var releases []any
+			for r := range 3 + rnd.IntN(15) {
+				pid := fmt.Sprintf("CSAFPID-%d-%d-%d", v, p, r)
+				productIDs = append(productIDs, pid)
+				name := fmt.Sprintf("Product %d-%d %d.%d", v, p, r/4, r%4)
+				releases = append(releases, map[string]any{
+					"category": "product_version",
+					"name":     fmt.Sprintf("%d.%d", r/4, r%4),
+					"product": map[string]any{
+						"name":       name,
+						"product_id": pid,
+						"product_identification_helper": map[string]any{
+							"cpe": fmt.Sprintf("cpe:2.3:a:vendor%d:product%d:%d.%d:*:*:*:*:*:*:*", v, p, r/4, r%4),
+						},
+					},
+				})
+			}
+			products = append(products, map[string]any{
+				"category": "product_name",
+				"name":     fmt.Sprintf("Product %d-%d", v, p),
+				"branches": releases,
+			})
+		}
+		branches = append(branches, map[string]any{
+			"category": "vendor",
+			"name":     fmt.Sprintf("Vendor %d", v),
+			"branches": products,
+		})
+	}
+	pick := func() []any {
+		var ids []any
+		for _, pid := range productIDs {
+			if rnd.IntN(4) == 0 {
+				ids = append(ids, pid)
+			}
+		}
+		if len(ids) == 0 {
+			ids = append(ids, productIDs[0])
+		}
+		return ids
+	}
+
+	// Vulnerabilities.
+	var vulnerabilities []any
+	for v := range 1 + rnd.IntN(20) {
+		affected := pick()
+		score := float64(rnd.IntN(100)) / 10
+		vulnerabilities = append(vulnerabilities, map[string]any{
+			"cve": fmt.Sprintf("CVE-%d-%d", year, 10000+rnd.IntN(90000)),
+			"cwe": map[string]any{
+				"id":   fmt.Sprintf("CWE-%d", 20+rnd.IntN(900)),
+				"name": "Improper Input Validation",
+			},
+			"notes": []any{map[string]any{
+				"category": "description",
+				"title":    "Vulnerability description",
+				"text": fmt.Sprintf("A flaw in component %d allows a remote attacker "+
+					"to execute arbitrary code by sending crafted requests.", v),
+			}},
+			"product_status": map[string]any{
+				"known_affected": affected,
+				"fixed":          pick(),
+			},
+			"remediations": []any{map[string]any{
+				"category":    "vendor_fix",
+				"details":     "Update to the latest version.",
+				"product_ids": affected,
+				"url":         fmt.Sprintf("https://acme.example.com/downloads/%s", id),
+			}},
+			"scores": []any{map[string]any{
+				"products": affected,
+				"cvss_v3": map[string]any{
+					"version":      "3.1",
+					"vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+					"baseScore":    score,
+					"baseSeverity": "HIGH",
+				},
+			}},
+		})
+	}
+
+	return map[string]any{
+		"document": map[string]any{
+			"category":     "csaf_security_advisory",
+			"csaf_version": "2.0",
+			"title":        fmt.Sprintf("Multiple vulnerabilities in ACME products (%s)", id),
+			"lang":         "en-US",
+			"distribution": map[string]any{
+				"tlp": map[string]any{
+					"label": tlps[rnd.IntN(len(tlps))],
+					"url":   "https://www.first.org/tlp/",
+				},
+			},
+			"notes": []any{
+				map[string]any{
+					"category": "legal_disclaimer",
+					"title":    "Terms of use",
+					"text":     "The information is provided as is without any warranty.",
+				},
+				map[string]any{
+					"category": "summary",
+					"title":    "Summary",
+					"text":     fmt.Sprintf("Several vulnerabilities were found in the products listed in %s.", id),
+				},
+			},
+			"publisher": map[string]any{
+				"category":  "vendor",
+				"name":      "ACME Inc.",
+				"namespace": "https://acme.example.com",
+			},
+			"references": []any{map[string]any{
+				"category": "self",
+				"summary":  "Canonical URL",
+				"url":      fmt.Sprintf("https://acme.example.com/.well-known/csaf/white/%d/%s.json", year, id),
+			}},
+			"tracking": map[string]any{
+				"id":                   id,
+				"status":               "final",
+				"version":              fmt.Sprintf("%d.0.0", versions),
+				"initial_release_date": date(initial),
+				"current_release_date": date(current),
+				"revision_history":     history,
+				"generator": map[string]any{
+					"engine": map[string]any{"name": "ACME CSAF generator", "version": "1.2.3"},
+				},
+			},
+		},
+		"product_tree": map[string]any{
+			"branches": branches,
+		},
+		"vulnerabilities": vulnerabilities,
+	}
+}
@@ -10,9 +10,12 @@
 package testutil
 
 import (
+	"errors"
 	"html/template"
+	"io/fs"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -26,15 +29,18 @@ type ProviderParams struct {
 	JSONContentType string
 }
 
+// providerPath returns the path of the files of the test provider.
+func providerPath(directoryProvider bool) string {
+	if directoryProvider {
+		return "../../testdata/simple-directory-provider"
+	}
+	return "../../testdata/simple-rolie-provider"
+}
+
 // ProviderHandler returns a test provider handler with the specified configuration.
 func ProviderHandler(params *ProviderParams, directoryProvider bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		path := "../../testdata/"
-		if directoryProvider {
-			path += "simple-directory-provider"
-		} else {
-			path += "simple-rolie-provider"
-		}
+		path := providerPath(directoryProvider)
 
 		jsonContenType := "application/json"
 		if params.JSONContentType != "" {
@@ -85,3 +91,40 @@ func ProviderHandler(params *ProviderParams, directoryProvider bool) http.Handle
 		}
 	}
 }
+
+// WriteProvider writes the files of a test provider with the
+// specified configuration into the folder dir. params.URL should
+// be the file:// URL of dir.
+func WriteProvider(dir string, params *ProviderParams, directoryProvider bool) error {
+	src := providerPath(directoryProvider)
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dir, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(dst, 0755)
+		case strings.HasSuffix(path, ".sha256") && directoryProvider && !params.EnableSha256,
+			strings.HasSuffix(path, ".sha512") && directoryProvider && !params.EnableSha512:
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		tmplt, err := template.New("base").Parse(string(content))
+		if err != nil {
+			return err
+		}
+		f, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		return errors.Join(tmplt.Execute(f, params), f.Close())
+	})
+}
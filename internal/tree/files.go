@@ -6,15 +6,15 @@
 // SPDX-FileCopyrightText: 2022 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
 // Software-Engineering: 2022 Intevation GmbH <https://intevation.de>
 
-package main
+package tree
 
 import (
 	"fmt"
 	"os"
 )
 
-// writeHash writes a hash to file.
-func writeHash(fname, name string, hash []byte) error {
+// WriteHash writes a hash to file.
+func WriteHash(fname, name string, hash []byte) error {
 	f, err := os.Create(fname)
 	if err != nil {
 		return err
@@ -23,16 +23,16 @@ func writeHash(fname, name string, hash []byte) error {
 	return f.Close()
 }
 
-// writeFileHashes writes a file and its hashes to files.
-func writeFileHashes(fname, name string, data, s256, s512 []byte) error {
+// WriteFileHashes writes a file and its hashes to files.
+func WriteFileHashes(fname, name string, data, s256, s512 []byte) error {
 	// Write the file itself.
 	if err := os.WriteFile(fname, data, 0644); err != nil {
 		return err
 	}
 	// Write SHA256 sum.
-	if err := writeHash(fname+".sha256", name, s256); err != nil {
+	if err := WriteHash(fname+".sha256", name, s256); err != nil {
 		return err
 	}
 	// Write SHA512 sum.
-	return writeHash(fname+".sha512", name, s512)
+	return WriteHash(fname+".sha512", name, s512)
 }
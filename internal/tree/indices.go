@@ -0,0 +1,404 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2022 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2022 Intevation GmbH <https://intevation.de>
+
+// Package tree writes the index files of the directory and
+// ROLIE based distributions of CSAF provider trees.
+package tree
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+const (
+	// InterimsCSV is the name of the file to store the URLs
+	// of the interim advisories.
+	InterimsCSV = "interims.csv"
+
+	// ChangesCSV is the name of the file to store the
+	// the paths to the advisories sorted in descending order
+	// of the release date along with the release date.
+	ChangesCSV = "changes.csv"
+
+	// IndexTXT is the name of the file to store the
+	// the paths of the advisories.
+	IndexTXT = "index.txt"
+)
+
+// Summary is the summary of an advisory in a tree.
+// The advisory is stored in the folder of its TLP label
+// and the year of its initial release date.
+type Summary struct {
+	Filename string
+	Summary  *csaf.AdvisorySummary
+	URL      string
+}
+
+// Writer writes the index files of a tree.
+type Writer struct {
+	// Dir is the root folder of the tree.
+	Dir string
+	// BaseURL is the URL the root folder is served under.
+	BaseURL *url.URL
+	// Indices tells if index.txt and changes.csv are written.
+	Indices bool
+	// ROLIE tells if the ROLIE feeds are written.
+	ROLIE bool
+	// Service tells if a service document is written
+	// and linked from the ROLIE feeds.
+	Service bool
+	// Log is used to log the progress. Defaults to
+	// the default logger.
+	Log *slog.Logger
+}
+
+func (w *Writer) log() *slog.Logger {
+	if w.Log != nil {
+		return w.Log
+	}
+	return slog.Default()
+}
+
+// WriteInterims writes the interims.csv of the given label.
+func (w *Writer) WriteInterims(label string, summaries []Summary) error {
+
+	// Filter out the interims.
+	var ss []Summary
+	for _, s := range summaries {
+		if s.Summary.Status == "interim" {
+			ss = append(ss, s)
+		}
+	}
+
+	// No interims -> nothing to write
+	if len(ss) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(ss, func(i, j int) bool {
+		return ss[i].Summary.CurrentReleaseDate.After(
+			ss[j].Summary.CurrentReleaseDate)
+	})
+
+	fname := filepath.Join(w.Dir, label, InterimsCSV)
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(f)
+
+	record := make([]string, 3)
+
+	for i := range ss {
+		s := &ss[i]
+		record[0] =
+			s.Summary.CurrentReleaseDate.Format(time.RFC3339)
+		record[1] =
+			strconv.Itoa(s.Summary.InitialReleaseDate.Year()) + "/" + s.Filename
+		record[2] = s.URL
+		if err := out.Write(record); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	out.Flush()
+	err1 := out.Error()
+	err2 := f.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// WriteCSV writes the changes.csv of the given label.
+func (w *Writer) WriteCSV(label string, summaries []Summary) error {
+
+	fname := filepath.Join(w.Dir, label, ChangesCSV)
+
+	// If we don't have any entries remove existing file.
+	if len(summaries) == 0 {
+		// Does it really exist?
+		if err := os.RemoveAll(fname); err != nil {
+			return fmt.Errorf("unable to remove %q: %w", fname, err)
+		}
+		return nil
+	}
+
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+
+	// Do not sort in-place.
+	ss := make([]Summary, len(summaries))
+	copy(ss, summaries)
+
+	sort.SliceStable(ss, func(i, j int) bool {
+		return ss[i].Summary.CurrentReleaseDate.After(
+			ss[j].Summary.CurrentReleaseDate)
+	})
+
+	out := util.NewFullyQuotedCSWWriter(f)
+
+	record := make([]string, 2)
+
+	const (
+		pathColumn = 0
+		timeColumn = 1
+	)
+
+	for i := range ss {
+		s := &ss[i]
+		record[pathColumn] =
+			strconv.Itoa(s.Summary.InitialReleaseDate.Year()) + "/" + s.Filename
+		record[timeColumn] =
+			s.Summary.CurrentReleaseDate.Format(time.RFC3339)
+		if err := out.Write(record); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	out.Flush()
+	err1 := out.Error()
+	err2 := f.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// WriteIndex writes the index.txt of the given label.
+func (w *Writer) WriteIndex(label string, summaries []Summary) error {
+
+	fname := filepath.Join(w.Dir, label, IndexTXT)
+
+	// If we don't have any entries remove existing file.
+	if len(summaries) == 0 {
+		// Does it really exist?
+		if err := os.RemoveAll(fname); err != nil {
+			return fmt.Errorf("unable to remove %q: %w", fname, err)
+		}
+		return nil
+	}
+
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(f)
+	for i := range summaries {
+		s := &summaries[i]
+		fmt.Fprintf(
+			out, "%d/%s\n",
+			s.Summary.InitialReleaseDate.Year(),
+			s.Filename)
+	}
+	err1 := out.Flush()
+	err2 := f.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// feedLinks returns the links of the ROLIE feed of the given label.
+func (w *Writer) feedLinks(labelFolder string) []csaf.Link {
+	fname := "csaf-feed-tlp-" + labelFolder + ".json"
+
+	links := []csaf.Link{{
+		Rel:  "self",
+		HRef: w.BaseURL.JoinPath(labelFolder, fname).String(),
+	}}
+
+	if w.Service {
+		links = append(links, csaf.Link{
+			Rel:  "service",
+			HRef: w.BaseURL.JoinPath("service.json").String(),
+		})
+	}
+	return links
+}
+
+// WriteROLIE writes the ROLIE feed of the given label.
+// Without summaries a feed without entries is written.
+func (w *Writer) WriteROLIE(label string, summaries []Summary) error {
+
+	labelFolder := strings.ToLower(label)
+
+	fname := "csaf-feed-tlp-" + labelFolder + ".json"
+
+	entries := make([]*csaf.Entry, len(summaries))
+
+	format := csaf.Format{
+		Schema:  "https://docs.oasis-open.org/csaf/csaf/v2.0/csaf_json_schema.json",
+		Version: "2.0",
+	}
+
+	for i := range summaries {
+		s := &summaries[i]
+
+		csafURLString := w.BaseURL.JoinPath(label,
+			strconv.Itoa(s.Summary.InitialReleaseDate.Year()),
+			s.Filename).String()
+
+		entries[i] = &csaf.Entry{
+			ID:        s.Summary.ID,
+			Titel:     s.Summary.Title,
+			Published: csaf.TimeStamp(s.Summary.InitialReleaseDate),
+			Updated:   csaf.TimeStamp(s.Summary.CurrentReleaseDate),
+			Link: []csaf.Link{
+				{Rel: "self", HRef: csafURLString},
+				{Rel: "hash", HRef: csafURLString + ".sha256"},
+				{Rel: "hash", HRef: csafURLString + ".sha512"},
+				{Rel: "signature", HRef: csafURLString + ".asc"},
+			},
+			Format: format,
+			Content: csaf.Content{
+				Type: "application/json",
+				Src:  csafURLString,
+			},
+		}
+		if s.Summary.Summary != "" {
+			entries[i].Summary = &csaf.Summary{
+				Content: s.Summary.Summary,
+			}
+		}
+	}
+
+	rolie := &csaf.ROLIEFeed{
+		Feed: csaf.FeedData{
+			ID:    "csaf-feed-tlp-" + strings.ToLower(label),
+			Title: "CSAF feed (TLP:" + strings.ToUpper(label) + ")",
+			Link:  w.feedLinks(labelFolder),
+			Category: []csaf.ROLIECategory{{
+				Scheme: "urn:ietf:params:rolie:category:information-type",
+				Term:   "csaf",
+			}},
+			Updated: csaf.TimeStamp(time.Now().UTC()),
+			Entry:   entries,
+		},
+	}
+
+	// Sort by descending updated order.
+	rolie.SortEntriesByUpdated()
+
+	path := filepath.Join(w.Dir, labelFolder, fname)
+	return util.WriteToFile(path, rolie)
+}
+
+// WriteCategories writes the ROLIE category document of the given label.
+func (w *Writer) WriteCategories(label string, categories util.Set[string]) error {
+	if len(categories) == 0 {
+		return nil
+	}
+	rcd := csaf.NewROLIECategoryDocument(categories.Keys()...)
+
+	labelFolder := strings.ToLower(label)
+	fname := "category-" + labelFolder + ".json"
+	path := filepath.Join(w.Dir, labelFolder, fname)
+	return util.WriteToFile(path, rcd)
+}
+
+// WriteService writes a service.json document
+// listing the feeds of the given labels if configured.
+func (w *Writer) WriteService(labels []string) error {
+
+	if !w.Service {
+		return nil
+	}
+	lower := make([]string, len(labels))
+	for i, label := range labels {
+		lower[i] = strings.ToLower(label)
+	}
+	sort.Strings(lower)
+
+	categories := csaf.ROLIEServiceWorkspaceCollectionCategories{
+		Category: []csaf.ROLIEServiceWorkspaceCollectionCategoriesCategory{{
+			Scheme: "urn:ietf:params:rolie:category:information-type",
+			Term:   "csaf",
+		}},
+	}
+
+	var collections []csaf.ROLIEServiceWorkspaceCollection
+
+	for _, ts := range lower {
+		feedName := "csaf-feed-tlp-" + ts + ".json"
+
+		collection := csaf.ROLIEServiceWorkspaceCollection{
+			Title:      "CSAF feed (TLP:" + strings.ToUpper(ts) + ")",
+			HRef:       w.BaseURL.JoinPath(ts, feedName).String(),
+			Categories: categories,
+		}
+		collections = append(collections, collection)
+	}
+
+	rsd := &csaf.ROLIEServiceDocument{
+		Service: csaf.ROLIEService{
+			Workspace: []csaf.ROLIEServiceWorkspace{{
+				Title:      "CSAF feeds",
+				Collection: collections,
+			}},
+		},
+	}
+
+	path := filepath.Join(w.Dir, "service.json")
+	return util.WriteToFile(path, rsd)
+}
+
+// WriteIndices writes the index files of the advisories
+// given by their summaries and categories per label.
+func (w *Writer) WriteIndices(
+	summaries map[string][]Summary,
+	categories map[string]util.Set[string],
+) error {
+
+	labels := make([]string, 0, len(summaries))
+
+	for label, summaries := range summaries {
+		w.log().Debug("Writing indices", "label", label, "summaries.num", len(summaries))
+		labels = append(labels, label)
+		if err := w.WriteInterims(label, summaries); err != nil {
+			return err
+		}
+		// Only write index.txt and changes.csv if configured.
+		if w.Indices {
+			if err := w.WriteCSV(label, summaries); err != nil {
+				return err
+			}
+			if err := w.WriteIndex(label, summaries); err != nil {
+				return err
+			}
+		}
+		if !w.ROLIE {
+			continue
+		}
+		if err := w.WriteROLIE(label, summaries); err != nil {
+			return err
+		}
+		if err := w.WriteCategories(label, categories[label]); err != nil {
+			return err
+		}
+	}
+
+	if !w.ROLIE {
+		return nil
+	}
+	return w.WriteService(labels)
+}
@@ -0,0 +1,106 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package tree
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/util"
+)
+
+func TestWriteIndices(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "white"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	baseURL, _ := url.Parse("https://example.com/.well-known/csaf")
+
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	summaries := map[string][]Summary{
+		"white": {{
+			Filename: "a-001.json",
+			Summary: &csaf.AdvisorySummary{
+				ID:                 "A-001",
+				InitialReleaseDate: date("2023-01-01T00:00:00Z"),
+				CurrentReleaseDate: date("2023-02-01T00:00:00Z"),
+				Status:             "final",
+			},
+		}, {
+			Filename: "a-002.json",
+			Summary: &csaf.AdvisorySummary{
+				ID:                 "A-002",
+				InitialReleaseDate: date("2024-01-01T00:00:00Z"),
+				CurrentReleaseDate: date("2024-01-01T00:00:00Z"),
+				Status:             "interim",
+			},
+			URL: "https://example.com/a-002.json",
+		}},
+	}
+	w := &Writer{
+		Dir:     dir,
+		BaseURL: baseURL,
+		Indices: true,
+		ROLIE:   true,
+		Service: true,
+	}
+	if err := w.WriteIndices(summaries, map[string]util.Set[string]{
+		"white": {"products": {}},
+	}); err != nil {
+		t.Fatalf("writing indices failed: %v", err)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if got, want := read("white/"+IndexTXT), "2023/a-001.json\n2024/a-002.json\n"; got != want {
+		t.Errorf("index.txt: got %q, want %q", got, want)
+	}
+	if got, want := read("white/"+ChangesCSV),
+		"\"2024/a-002.json\",\"2024-01-01T00:00:00Z\"\n"+
+			"\"2023/a-001.json\",\"2023-02-01T00:00:00Z\"\n"; got != want {
+		t.Errorf("changes.csv: got %q, want %q", got, want)
+	}
+	if got, want := read("white/"+InterimsCSV),
+		"2024-01-01T00:00:00Z,2024/a-002.json,https://example.com/a-002.json\n"; got != want {
+		t.Errorf("interims.csv: got %q, want %q", got, want)
+	}
+
+	var feed csaf.ROLIEFeed
+	if err := json.Unmarshal([]byte(read("white/csaf-feed-tlp-white.json")), &feed); err != nil {
+		t.Fatalf("invalid feed: %v", err)
+	}
+	if len(feed.Feed.Entry) != 2 || feed.Feed.Entry[0].ID != "A-002" {
+		t.Errorf("unexpected feed entries")
+	}
+	if want := "https://example.com/.well-known/csaf/white/2023/a-001.json"; feed.Feed.Entry[1].Content.Src != want {
+		t.Errorf("entry URL: got %q, want %q", feed.Feed.Entry[1].Content.Src, want)
+	}
+	if len(feed.Feed.Link) != 2 || feed.Feed.Link[1].Rel != "service" {
+		t.Error("missing link to the service document")
+	}
+	read("white/category-white.json")
+	read("service.json")
+}
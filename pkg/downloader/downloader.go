@@ -0,0 +1,274 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+// Package downloader is a library to download CSAF advisories
+// from the providers with the checks of the csaf_downloader tool.
+//
+// The advisories are not stored but passed to the caller
+// together with their metadata:
+//
+//	client, err := downloader.New(downloader.WithWorkers(4))
+//	if err != nil {
+//		return err
+//	}
+//	for adv, err := range client.All(ctx, []string{"example.com"}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(adv.URL, adv.TLP, adv.Signature)
+//	}
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"sync"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	core "github.com/gocsaf/csaf/v3/internal/downloader"
+	"github.com/gocsaf/csaf/v3/pkg/options"
+)
+
+// SignatureStatus is the result of the signature check of an advisory.
+type SignatureStatus string
+
+const (
+	// SignatureVerified means the signature was verified.
+	SignatureVerified = SignatureStatus("verified")
+	// SignatureInvalid means the signature is missing or
+	// could not be verified.
+	SignatureInvalid = SignatureStatus("invalid")
+	// SignatureUnchecked means the signature was not checked,
+	// e.g. because the provider has no OpenPGP keys.
+	SignatureUnchecked = SignatureStatus("unchecked")
+)
+
+// DomainError is the error which occurred while downloading
+// the advisories of a domain.
+type DomainError struct {
+	Domain string
+	Err    error
+}
+
+func (de *DomainError) Error() string {
+	return fmt.Sprintf("domain %q: %v", de.Domain, de.Err)
+}
+
+func (de *DomainError) Unwrap() error {
+	return de.Err
+}
+
+// Failure is a failed check of an advisory.
+type Failure struct {
+	// Class is the kind of the check which failed,
+	// e.g. "sha256_mismatch", "bad_signature" or "schema".
+	Class string
+	// Message describes the failure.
+	Message string
+}
+
+// Advisory is a downloaded advisory with its metadata.
+type Advisory struct {
+	// Domain is the domain the advisory was downloaded from.
+	Domain string
+	// URL is the URL the advisory was downloaded from.
+	URL string
+	// Filename is the file name of the advisory.
+	Filename string
+	// TLP is the TLP label of the feed listing the advisory.
+	TLP csaf.TLPLabel
+	// Data are the raw bytes of the advisory.
+	Data []byte
+	// Document is the advisory decoded as generic JSON.
+	Document any
+	// SHA256 and SHA512 are the hashes of Data.
+	SHA256 []byte
+	SHA512 []byte
+	// Signature is the result of the signature check.
+	Signature SignatureStatus
+	// Fingerprint is the fingerprint of the OpenPGP key
+	// which verified the signature.
+	Fingerprint string
+	// Valid tells if the advisory passed all the checks.
+	// Invalid advisories are only passed in unsafe validation mode.
+	Valid bool
+	// Failures are the failed checks.
+	Failures []Failure
+}
+
+// Parse decodes the advisory into the CSAF model.
+func (adv *Advisory) Parse() (*csaf.Advisory, error) {
+	var doc csaf.Advisory
+	if err := json.Unmarshal(adv.Data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Client downloads advisories.
+type Client struct {
+	cfg core.Config
+}
+
+// New creates a client configured by the given options.
+func New(opts ...Option) (*Client, error) {
+	c := &Client{cfg: core.Config{
+		NoStore:                true,
+		Worker:                 defaultWorker,
+		Parallel:               defaultParallel,
+		ValidationMode:         core.ValidationStrict,
+		SignaturePolicy:        core.SignatureOptional,
+		RemoteValidatorPresets: []string{defaultPreset},
+		LogLevel:               &options.LogLevel{Level: slog.LevelInfo},
+		Logger:                 slog.New(slog.DiscardHandler),
+	}}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.cfg.Prepare(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Download downloads the advisories from the given domains and
+// calls fn with each of them. The domains may also be given as
+// URLs of provider-metadata.json files. fn is not called
+// concurrently. If fn returns an error the download is stopped
+// and the error is returned. The errors of the domains are
+// returned joined as [DomainError]s.
+func (c *Client) Download(
+	ctx context.Context,
+	domains []string,
+	fn func(*Advisory) error,
+) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	d, err := core.NewDownloader(&c.cfg)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	var (
+		mu      sync.Mutex
+		stopErr error
+	)
+	d.Handler = func(a *core.Advisory) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopErr != nil {
+			return stopErr
+		}
+		if err := fn(convert(a)); err != nil {
+			stopErr = err
+			cancel(err)
+			return err
+		}
+		return nil
+	}
+
+	err = d.Run(ctx, domains)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if stopErr != nil {
+		return stopErr
+	}
+	return convertError(err)
+}
+
+// convertError converts the errors of the domains
+// joined by the downloader.
+func convertError(err error) error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return err
+	}
+	errs := joined.Unwrap()
+	converted := make([]error, len(errs))
+	for i, e := range errs {
+		if de, ok := e.(*core.DomainError); ok {
+			e = &DomainError{Domain: de.Domain, Err: de.Err}
+		}
+		converted[i] = e
+	}
+	return errors.Join(converted...)
+}
+
+// All returns an iterator over the advisories downloaded from
+// the given domains. An error ends the iteration. Breaking
+// out of the loop stops the download.
+func (c *Client) All(ctx context.Context, domains []string) iter.Seq2[*Advisory, error] {
+	return func(yield func(*Advisory, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var (
+			advisories = make(chan *Advisory)
+			done       = make(chan error, 1)
+		)
+		go func() {
+			done <- c.Download(ctx, domains, func(adv *Advisory) error {
+				select {
+				case advisories <- adv:
+					return nil
+				case <-ctx.Done():
+					return context.Cause(ctx)
+				}
+			})
+		}()
+
+		for {
+			select {
+			case adv := <-advisories:
+				if !yield(adv, nil) {
+					cancel()
+					<-done
+					return
+				}
+			case err := <-done:
+				if err != nil {
+					yield(nil, err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// convert converts an advisory of the downloader.
+func convert(a *core.Advisory) *Advisory {
+	adv := &Advisory{
+		Domain:      a.Domain,
+		URL:         a.URL,
+		Filename:    a.Filename,
+		TLP:         a.Label,
+		Data:        a.Data,
+		Document:    a.Document,
+		SHA256:      a.SHA256,
+		SHA512:      a.SHA512,
+		Signature:   SignatureStatus(a.Signature),
+		Fingerprint: a.Fingerprint,
+		Valid:       a.Valid,
+	}
+	for _, f := range a.Failures {
+		adv.Failures = append(adv.Failures, Failure{
+			Class:   f.Class,
+			Message: f.Message,
+		})
+	}
+	return adv
+}
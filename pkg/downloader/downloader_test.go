@@ -0,0 +1,154 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocsaf/csaf/v3/csaf"
+	"github.com/gocsaf/csaf/v3/internal/testutil"
+)
+
+func TestAll(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	logger := slog.Default()
+
+	client, err := New(WithInsecure(), WithWorkers(1))
+	if err != nil {
+		t.Fatalf("creating client failed: %v", err)
+	}
+
+	var advisories []*Advisory
+	for adv, err := range client.All(
+		context.Background(),
+		[]string{server.URL + "/provider-metadata.json"},
+	) {
+		if err != nil {
+			t.Fatalf("download failed: %v", err)
+		}
+		advisories = append(advisories, adv)
+	}
+
+	if slog.Default() != logger {
+		t.Error("default logger was changed")
+	}
+
+	if len(advisories) != 1 {
+		t.Fatalf("expected 1 advisory, got %d", len(advisories))
+	}
+	adv := advisories[0]
+	if want := server.URL + "/white/avendor-advisory-0004.json"; adv.URL != want {
+		t.Errorf("URL: got %q, want %q", adv.URL, want)
+	}
+	if adv.Filename != "avendor-advisory-0004.json" {
+		t.Errorf("unexpected file name %q", adv.Filename)
+	}
+	if adv.TLP != csaf.TLPLabelWhite {
+		t.Errorf("TLP: got %q, want %q", adv.TLP, csaf.TLPLabelWhite)
+	}
+	if sum := sha256.Sum256(adv.Data); string(sum[:]) != string(adv.SHA256) {
+		t.Error("SHA256 does not match the data")
+	}
+	if len(adv.SHA512) == 0 {
+		t.Error("missing SHA512")
+	}
+	if adv.Signature != SignatureVerified || adv.Fingerprint == "" {
+		t.Errorf("signature not verified: %q %q", adv.Signature, adv.Fingerprint)
+	}
+	if !adv.Valid || len(adv.Failures) != 0 {
+		t.Errorf("advisory not valid: %v", adv.Failures)
+	}
+	if adv.Document == nil {
+		t.Error("missing document")
+	}
+	doc, err := adv.Parse()
+	if err != nil {
+		t.Fatalf("parsing failed: %v", err)
+	}
+	if doc.Document == nil || doc.Document.Tracking == nil ||
+		doc.Document.Tracking.ID == nil ||
+		*doc.Document.Tracking.ID != "Avendor-advisory-0004" {
+		t.Error("unexpected tracking ID")
+	}
+}
+
+func TestDownloadStop(t *testing.T) {
+	params := testutil.ProviderParams{EnableSha256: true, EnableSha512: true}
+	server := httptest.NewTLSServer(testutil.ProviderHandler(&params, false))
+	defer server.Close()
+	params.URL = server.URL
+
+	client, err := New(WithInsecure())
+	if err != nil {
+		t.Fatalf("creating client failed: %v", err)
+	}
+
+	stop := errors.New("stop")
+	err = client.Download(
+		context.Background(),
+		[]string{server.URL + "/provider-metadata.json"},
+		func(*Advisory) error { return stop })
+	if err != stop {
+		t.Errorf("expected the handler error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var called bool
+	err = client.Download(ctx,
+		[]string{server.URL + "/provider-metadata.json"},
+		func(*Advisory) error { called = true; return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+	if called {
+		t.Error("handler called after cancellation")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	for _, opt := range []Option{
+		WithWorkers(0),
+		WithParallel(-1),
+		WithRate(0),
+		WithLogger(nil),
+		WithSignaturePolicy("unknown"),
+	} {
+		if _, err := New(opt); err == nil {
+			t.Error("expected an error")
+		}
+	}
+}
+
+func TestDomainError(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client, err := New(WithInsecure())
+	if err != nil {
+		t.Fatalf("creating client failed: %v", err)
+	}
+	domain := server.URL + "/provider-metadata.json"
+	err = client.Download(context.Background(), []string{domain},
+		func(*Advisory) error { return nil })
+	var de *DomainError
+	if !errors.As(err, &de) || de.Domain != domain {
+		t.Errorf("expected error of domain %q, got %v", domain, err)
+	}
+}
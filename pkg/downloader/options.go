@@ -0,0 +1,210 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package downloader
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	core "github.com/gocsaf/csaf/v3/internal/downloader"
+	"github.com/gocsaf/csaf/v3/pkg/models"
+)
+
+const (
+	defaultWorker   = 2
+	defaultParallel = 4
+	defaultPreset   = "mandatory"
+)
+
+// SignaturePolicy is the policy how the integrity
+// of the advisories has to be proven.
+type SignaturePolicy string
+
+const (
+	// SignatureOptional checks the signatures if the provider has keys.
+	SignatureOptional = SignaturePolicy("optional")
+	// SignatureRequired requires a valid signature for every advisory.
+	SignatureRequired = SignaturePolicy("required")
+	// SignatureHashes requires a valid hash and ignores the signatures.
+	SignatureHashes = SignaturePolicy("hashes")
+)
+
+// Option configures a [Client].
+type Option func(*Client) error
+
+// WithLogger sets the logger. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) error {
+		if logger == nil {
+			return errors.New("no logger given")
+		}
+		c.cfg.Logger = logger
+		return nil
+	}
+}
+
+// WithWorkers sets the number of concurrent downloads per domain.
+func WithWorkers(n int) Option {
+	return func(c *Client) error {
+		if n < 1 {
+			return errors.New("number of workers must be positive")
+		}
+		c.cfg.Worker = n
+		return nil
+	}
+}
+
+// WithParallel sets the number of domains processed concurrently.
+func WithParallel(n int) Option {
+	return func(c *Client) error {
+		if n < 1 {
+			return errors.New("number of parallel domains must be positive")
+		}
+		c.cfg.Parallel = n
+		return nil
+	}
+}
+
+// WithRate limits the average number of HTTP requests per second.
+func WithRate(rate float64) Option {
+	return func(c *Client) error {
+		if rate <= 0 {
+			return errors.New("rate must be positive")
+		}
+		c.cfg.Rate = &rate
+		return nil
+	}
+}
+
+// WithHeader adds an extra HTTP header field to the requests.
+func WithHeader(key, value string) Option {
+	return func(c *Client) error {
+		if c.cfg.ExtraHeader == nil {
+			c.cfg.ExtraHeader = http.Header{}
+		}
+		c.cfg.ExtraHeader.Add(key, value)
+		return nil
+	}
+}
+
+// WithInsecure disables the checks of the TLS certificates
+// of the providers.
+func WithInsecure() Option {
+	return func(c *Client) error {
+		c.cfg.Insecure = true
+		return nil
+	}
+}
+
+// WithClientCertificate sets the files of the TLS client
+// certificate and its private key. passphrase may be empty.
+func WithClientCertificate(certFile, keyFile, passphrase string) Option {
+	return func(c *Client) error {
+		c.cfg.ClientCert = &certFile
+		c.cfg.ClientKey = &keyFile
+		if passphrase != "" {
+			c.cfg.ClientPassphrase = &passphrase
+		}
+		return nil
+	}
+}
+
+// WithTimeRange only downloads the advisories
+// changed in the given time range.
+func WithTimeRange(r models.TimeRange) Option {
+	return func(c *Client) error {
+		c.cfg.Range = &r
+		return nil
+	}
+}
+
+// WithIgnorePatterns skips the advisories whose URLs
+// match any of the given regular expressions.
+func WithIgnorePatterns(patterns ...string) Option {
+	return func(c *Client) error {
+		c.cfg.IgnorePattern = append(c.cfg.IgnorePattern, patterns...)
+		return nil
+	}
+}
+
+// WithFilters only passes the advisories matching any of the
+// include filters and none of the exclude filters. The filters
+// are the ones of the --include and --exclude options of the
+// csaf_downloader.
+func WithFilters(include, exclude []string) Option {
+	return func(c *Client) error {
+		c.cfg.Include = append(c.cfg.Include, include...)
+		c.cfg.Exclude = append(c.cfg.Exclude, exclude...)
+		return nil
+	}
+}
+
+// WithUnsafeValidation passes advisories failing the checks,
+// too. Their failures are reported in [Advisory.Failures].
+func WithUnsafeValidation() Option {
+	return func(c *Client) error {
+		c.cfg.ValidationMode = core.ValidationUnsafe
+		return nil
+	}
+}
+
+// WithRemoteValidator validates the advisories with the remote
+// validator at the given URL. If no presets are given
+// the "mandatory" preset is used.
+func WithRemoteValidator(url string, presets ...string) Option {
+	return func(c *Client) error {
+		c.cfg.RemoteValidator = url
+		if len(presets) > 0 {
+			c.cfg.RemoteValidatorPresets = presets
+		}
+		return nil
+	}
+}
+
+// WithSignaturePolicy sets how the integrity
+// of the advisories has to be proven.
+func WithSignaturePolicy(policy SignaturePolicy) Option {
+	return func(c *Client) error {
+		switch policy {
+		case SignatureOptional, SignatureRequired, SignatureHashes:
+			c.cfg.SignaturePolicy = core.SignaturePolicy(policy)
+			return nil
+		}
+		return errors.New("unknown signature policy " + string(policy))
+	}
+}
+
+// WithTrustedKeys only trusts the OpenPGP keys
+// with the given fingerprints.
+func WithTrustedKeys(fingerprints ...string) Option {
+	return func(c *Client) error {
+		c.cfg.TrustedKeys = append(c.cfg.TrustedKeys, fingerprints...)
+		return nil
+	}
+}
+
+// WithKeyring uses the OpenPGP keys in the given file
+// instead of the ones of the providers.
+func WithKeyring(file string) Option {
+	return func(c *Client) error {
+		c.cfg.Keyring = file
+		return nil
+	}
+}
+
+// WithAggregator uses the mirrors listed by the aggregator at the
+// given URL or file if a provider is not reachable. If no domains
+// are passed to the download all listed providers are downloaded.
+func WithAggregator(aggregator string) Option {
+	return func(c *Client) error {
+		c.cfg.Aggregator = aggregator
+		return nil
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 
 	"golang.org/x/time/rate"
 )
@@ -46,6 +47,58 @@ type HeaderClient struct {
 	Header http.Header
 }
 
+// CountingClient counts the bytes of the bodies of the responses.
+type CountingClient struct {
+	Client
+	Bytes *atomic.Int64
+}
+
+// countingBody counts the bytes read from a response body.
+type countingBody struct {
+	io.ReadCloser
+	bytes *atomic.Int64
+}
+
+// Read implements [io.Reader].
+func (cb *countingBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	cb.bytes.Add(int64(n))
+	return n, err
+}
+
+// count wraps the body of the response to be counted.
+func (cc *CountingClient) count(resp *http.Response, err error) (*http.Response, error) {
+	if err == nil && resp != nil && resp.Body != nil {
+		resp.Body = &countingBody{ReadCloser: resp.Body, bytes: cc.Bytes}
+	}
+	return resp, err
+}
+
+// Do implements the respective method of the [Client] interface.
+func (cc *CountingClient) Do(req *http.Request) (*http.Response, error) {
+	return cc.count(cc.Client.Do(req))
+}
+
+// Get implements the respective method of the [Client] interface.
+func (cc *CountingClient) Get(url string) (*http.Response, error) {
+	return cc.count(cc.Client.Get(url))
+}
+
+// Head implements the respective method of the [Client] interface.
+func (cc *CountingClient) Head(url string) (*http.Response, error) {
+	return cc.count(cc.Client.Head(url))
+}
+
+// Post implements the respective method of the [Client] interface.
+func (cc *CountingClient) Post(url, contentType string, body io.Reader) (*http.Response, error) {
+	return cc.count(cc.Client.Post(url, contentType, body))
+}
+
+// PostForm implements the respective method of the [Client] interface.
+func (cc *CountingClient) PostForm(url string, data url.Values) (*http.Response, error) {
+	return cc.count(cc.Client.PostForm(url, data))
+}
+
 // Do implements the respective method of the [Client] interface.
 func (hc *HeaderClient) Do(req *http.Request) (*http.Response, error) {
 	// Maybe this overly careful but this minimizes
@@ -0,0 +1,90 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package util //revive:disable-line:var-naming
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileClient is a [Client] which serves file:// URLs from the
+// local file system. Folders are served as HTML listings.
+// All other URLs are passed to the embedded [Client].
+// As it grants access to the local file system it should only
+// be used for sources which are local themselves.
+type FileClient struct {
+	Client
+}
+
+// localFiles fetches file:// URLs.
+var localFiles = &http.Client{
+	Transport: http.NewFileTransport(localFileSystem{}),
+}
+
+// localFileSystem opens the files named by the paths of file:// URLs.
+type localFileSystem struct{}
+
+// Open implements [http.FileSystem].
+func (localFileSystem) Open(name string) (http.File, error) {
+	return os.Open(FilePath(name))
+}
+
+// IsFileURL tells if s is a file:// URL.
+func IsFileURL(s string) bool {
+	return strings.HasPrefix(s, "file://")
+}
+
+// FileURL returns the file:// URL of a local path.
+func FileURL(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	abs = filepath.ToSlash(abs)
+	// Windows paths start with a drive letter.
+	if !strings.HasPrefix(abs, "/") {
+		abs = "/" + abs
+	}
+	return (&url.URL{Scheme: "file", Path: abs}).String(), nil
+}
+
+// FilePath returns the local path of the path of a file:// URL.
+func FilePath(path string) string {
+	if filepath.Separator == '\\' && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// Do implements the respective method of the [Client] interface.
+func (fc *FileClient) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "file" {
+		return localFiles.Do(req)
+	}
+	return fc.Client.Do(req)
+}
+
+// Get implements the respective method of the [Client] interface.
+func (fc *FileClient) Get(url string) (*http.Response, error) {
+	if !IsFileURL(url) {
+		return fc.Client.Get(url)
+	}
+	return localFiles.Get(url)
+}
+
+// Head implements the respective method of the [Client] interface.
+func (fc *FileClient) Head(url string) (*http.Response, error) {
+	if !IsFileURL(url) {
+		return fc.Client.Head(url)
+	}
+	return localFiles.Head(url)
+}
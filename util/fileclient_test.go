@@ -0,0 +1,85 @@
+// This file is Free Software under the Apache-2.0 License
+// without warranty, see README.md and LICENSES/Apache-2.0.txt for details.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// SPDX-FileCopyrightText: 2026 German Federal Office for Information Security (BSI) <https://www.bsi.bund.de>
+// Software-Engineering: 2026 Intevation GmbH <https://intevation.de>
+
+package util //revive:disable-line:var-naming
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// remoteClient fails all requests to tell they were passed on.
+type remoteClient struct{ Client }
+
+var errRemote = errors.New("remote")
+
+func (remoteClient) Get(string) (*http.Response, error) { return nil, errRemote }
+
+func TestFileURL(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "a b.json")
+	u, err := FileURL(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsFileURL(u) {
+		t.Errorf("%q is no file URL", u)
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FilePath(parsed.Path); got != fname {
+		t.Errorf("got %q, want %q", got, fname)
+	}
+}
+
+func TestFileClient(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	base, err := FileURL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &FileClient{Client: remoteClient{}}
+
+	get := func(u string) (int, string) {
+		t.Helper()
+		resp, err := client.Get(u)
+		if err != nil {
+			t.Fatalf("fetching %s failed: %v", u, err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(data)
+	}
+
+	if status, body := get(base + "/a.json"); status != http.StatusOK || body != `{}` {
+		t.Errorf("unexpected file response %d %q", status, body)
+	}
+	if status, _ := get(base + "/missing.json"); status != http.StatusNotFound {
+		t.Errorf("unexpected status %d for missing file", status)
+	}
+	if status, body := get(base + "/"); status != http.StatusOK || !strings.Contains(body, `href="a.json"`) {
+		t.Errorf("unexpected listing %d %q", status, body)
+	}
+	if _, err := client.Get("https://example.com/a.json"); !errors.Is(err, errRemote) {
+		t.Errorf("remote URL not passed on: %v", err)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Intevation/gval"
@@ -34,24 +35,57 @@ type PathEval struct {
 	exprs   map[string]gval.Evaluable
 }
 
+var (
+	// pathLanguage is the JSONPath language of all PathEvals.
+	pathLanguage = sync.OnceValue(func() gval.Language {
+		return gval.Full(jsonpath.Language())
+	})
+	// compiledExprs caches the compiled expressions of all
+	// PathEvals as compiling them is expensive. The compiled
+	// expressions keep no state and can be shared.
+	compiledExprs = struct {
+		sync.RWMutex
+		exprs map[string]gval.Evaluable
+	}{exprs: map[string]gval.Evaluable{}}
+)
+
+// maxCompiledExprs limits the number of expressions in the shared
+// cache. The expressions used by the tools are a small fixed set
+// and are compiled first. Expressions coming from documents or
+// user input beyond the limit are only cached per PathEval.
+const maxCompiledExprs = 256
+
 // NewPathEval creates a new PathEval.
 func NewPathEval() *PathEval {
 	return &PathEval{
-		builder: gval.Full(jsonpath.Language()),
+		builder: pathLanguage(),
 		exprs:   map[string]gval.Evaluable{},
 	}
 }
 
 // Compile compiles an expression and stores it in the
-// internal cache on success.
+// internal cache on success. Expressions already compiled
+// by other PathEvals are taken from a shared cache.
 func (pe *PathEval) Compile(expr string) (gval.Evaluable, error) {
 	if eval := pe.exprs[expr]; eval != nil {
 		return eval, nil
 	}
+	compiledExprs.RLock()
+	eval := compiledExprs.exprs[expr]
+	compiledExprs.RUnlock()
+	if eval != nil {
+		pe.exprs[expr] = eval
+		return eval, nil
+	}
 	eval, err := pe.builder.NewEvaluable(expr)
 	if err != nil {
 		return nil, err
 	}
+	compiledExprs.Lock()
+	if len(compiledExprs.exprs) < maxCompiledExprs {
+		compiledExprs.exprs[expr] = eval
+	}
+	compiledExprs.Unlock()
 	pe.exprs[expr] = eval
 	return eval, nil
 }
@@ -62,13 +96,9 @@ func (pe *PathEval) Eval(expr string, doc any) (any, error) {
 	if doc == nil {
 		return nil, errors.New("no document to extract data from")
 	}
-	eval := pe.exprs[expr]
-	if eval == nil {
-		var err error
-		if eval, err = pe.builder.NewEvaluable(expr); err != nil {
-			return nil, err
-		}
-		pe.exprs[expr] = eval
+	eval, err := pe.Compile(expr)
+	if err != nil {
+		return nil, err
 	}
 	return eval(context.Background(), doc)
 }
@@ -10,9 +10,16 @@ package util
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/Intevation/gval"
+	"github.com/Intevation/jsonpath"
+
+	"github.com/gocsaf/csaf/v3/internal/testutil"
 )
 
 func TestPathEval_Compile(t *testing.T) {
@@ -182,6 +189,24 @@ func TestPathEval_Strings(t *testing.T) {
 	}
 }
 
+func TestPathEval_CompileBounded(t *testing.T) {
+	pe := NewPathEval()
+	for i := range maxCompiledExprs + 10 {
+		if _, err := pe.Compile(fmt.Sprintf("$.a%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	compiledExprs.RLock()
+	n := len(compiledExprs.exprs)
+	compiledExprs.RUnlock()
+	if n > maxCompiledExprs {
+		t.Errorf("shared cache holds %d expressions, limit is %d", n, maxCompiledExprs)
+	}
+	if len(pe.exprs) != maxCompiledExprs+10 {
+		t.Errorf("PathEval caches %d expressions, expected %d", len(pe.exprs), maxCompiledExprs+10)
+	}
+}
+
 func TestAsStrings(t *testing.T) {
 	arg := []any{"foo", "bar"}
 	want := []string{"foo", "bar"}
@@ -194,3 +219,55 @@ func TestAsStrings(t *testing.T) {
 		t.Errorf("AsStrings: Expected %v, got %v", want, got)
 	}
 }
+
+func BenchmarkPathEval(b *testing.B) {
+	advisories := testutil.GenerateAdvisories(2000)
+	docs := make([]any, len(advisories))
+	for i, data := range advisories {
+		if err := json.Unmarshal(data, &docs[i]); err != nil {
+			b.Fatal(err)
+		}
+	}
+	advisories = nil
+	// The expressions used to summarize an advisory.
+	exprs := []string{
+		`$.document.tracking.id`,
+		`$.document.title`,
+		`$.document.publisher`,
+		`$.document.tracking.initial_release_date`,
+		`$.document.tracking.current_release_date`,
+		`$.document.distribution.tlp.label`,
+		`$.document.notes[? @.category=="summary" || @.type=="summary"].text`,
+		`$.document.tracking.status`,
+	}
+	// The downloader and the aggregator use
+	// a new PathEval per download or mirror.
+	b.Run("shared", func(b *testing.B) {
+		for b.Loop() {
+			for _, doc := range docs {
+				pe := NewPathEval()
+				for _, expr := range exprs {
+					if _, err := pe.Eval(expr, doc); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		language := gval.Full(jsonpath.Language())
+		for b.Loop() {
+			for _, doc := range docs {
+				for _, expr := range exprs {
+					eval, err := language.NewEvaluable(expr)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := eval(context.Background(), doc); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		}
+	})
+}